@@ -0,0 +1,343 @@
+package ocsp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive is the interface implemented by the sinks receiving every response
+// signed by a responder, to retain them for audits. SignBatch and
+// StatusUpdater call it after signing each response, and they do not return
+// the responses that cannot be archived.
+type Archive interface {
+	Archive(ctx context.Context, record ArchiveRecord) error
+}
+
+// ArchiveRecord is a signed OCSP response with the fields used to find it in
+// an archive.
+type ArchiveRecord struct {
+	// Response is the DER-encoded OCSP response.
+	Response []byte
+	// CertID identifies the certificate the response is for.
+	CertID CertID
+	// Status is the status of the certificate, Good, Revoked or Unknown.
+	Status     int
+	ThisUpdate time.Time
+	NextUpdate time.Time
+	// SignedAt is the time the response was signed.
+	SignedAt time.Time
+}
+
+// NewArchiveRecord returns the ArchiveRecord of the response in der, signed
+// at signedAt. If the response contains several statuses, the first one is
+// used.
+func NewArchiveRecord(der []byte, signedAt time.Time) (ArchiveRecord, error) {
+	errDone := errors.New("done")
+	rec := ArchiveRecord{Response: der, SignedAt: signedAt}
+	err := ForEachSingleResponse(der, func(single SingleResponse) error {
+		rec.CertID = single.CertID
+		rec.Status = single.Status
+		rec.ThisUpdate = single.ThisUpdate
+		rec.NextUpdate = single.NextUpdate
+		return errDone
+	})
+	if err == nil {
+		return ArchiveRecord{}, ParseError("OCSP response contains no statuses")
+	}
+	if err != errDone {
+		return ArchiveRecord{}, err
+	}
+	return rec, nil
+}
+
+// archive sends the response in der to a, if not nil.
+func archive(ctx context.Context, a Archive, der []byte, signedAt time.Time) error {
+	if a == nil {
+		return nil
+	}
+	rec, err := NewArchiveRecord(der, signedAt)
+	if err != nil {
+		return err
+	}
+	if err := a.Archive(ctx, rec); err != nil {
+		return fmt.Errorf("ocsp: error archiving response: %w", err)
+	}
+	return nil
+}
+
+type multiArchive []Archive
+
+func (m multiArchive) Archive(ctx context.Context, rec ArchiveRecord) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Archive(ctx, rec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MultiArchive returns an Archive sending the records to all the given
+// archives. It returns the errors of the archives that failed, if any.
+func MultiArchive(archives ...Archive) Archive {
+	return multiArchive(archives)
+}
+
+// archiveLine is the JSON encoding of an ArchiveRecord in a FileArchive.
+type archiveLine struct {
+	Response       []byte    `json:"response"`
+	HashAlgorithm  string    `json:"hashAlgorithm"`
+	IssuerNameHash string    `json:"issuerNameHash"`
+	IssuerKeyHash  string    `json:"issuerKeyHash"`
+	SerialNumber   string    `json:"serialNumber"`
+	Status         int       `json:"status"`
+	ThisUpdate     time.Time `json:"thisUpdate"`
+	NextUpdate     time.Time `json:"nextUpdate"`
+	SignedAt       time.Time `json:"signedAt"`
+}
+
+// FileArchiveOptions contains options for NewFileArchive.
+type FileArchiveOptions struct {
+	// MaxSize is the number of uncompressed bytes written to a file before
+	// starting a new one. If zero, 64 MiB is used.
+	MaxSize int64
+	// MaxAge is how long records are written to a file before starting a
+	// new one. If zero, 24 hours is used.
+	MaxAge time.Duration
+}
+
+func (opts *FileArchiveOptions) maxSize() int64 {
+	if opts == nil || opts.MaxSize <= 0 {
+		return 64 << 20
+	}
+	return opts.MaxSize
+}
+
+func (opts *FileArchiveOptions) maxAge() time.Duration {
+	if opts == nil || opts.MaxAge <= 0 {
+		return 24 * time.Hour
+	}
+	return opts.MaxAge
+}
+
+// FileArchive is an Archive writing the records to gzip-compressed files in a
+// directory, one JSON object per line. A new file, named after the time it
+// was created, is started when the current one gets too large or too old.
+// The files can be read with ReadArchive.
+type FileArchive struct {
+	mu       sync.Mutex
+	dir      string
+	opts     *FileArchiveOptions
+	f        *os.File
+	gz       *gzip.Writer
+	written  int64
+	openedAt time.Time
+	namedAt  time.Time
+	now      func() time.Time
+}
+
+// NewFileArchive returns a FileArchive writing to the directory dir, which
+// must exist. If opts is nil then sensible defaults are used.
+func NewFileArchive(dir string, opts *FileArchiveOptions) (*FileArchive, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("ocsp: %s is not a directory", dir)
+	}
+	return &FileArchive{
+		dir:  dir,
+		opts: opts,
+		now:  time.Now,
+	}, nil
+}
+
+// Archive writes rec to the current file, starting a new one if needed. The
+// record is flushed to the file before returning.
+func (a *FileArchive) Archive(_ context.Context, rec ArchiveRecord) error {
+	line, err := json.Marshal(archiveLine{
+		Response:       rec.Response,
+		HashAlgorithm:  getOIDFromHashAlgorithm(rec.CertID.HashAlgorithm).String(),
+		IssuerNameHash: hex.EncodeToString(rec.CertID.IssuerNameHash),
+		IssuerKeyHash:  hex.EncodeToString(rec.CertID.IssuerKeyHash),
+		SerialNumber:   serialHex(rec.CertID.SerialNumber),
+		Status:         rec.Status,
+		ThisUpdate:     rec.ThisUpdate,
+		NextUpdate:     rec.NextUpdate,
+		SignedAt:       rec.SignedAt,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	if a.gz != nil && (a.written+int64(len(line)) > a.opts.maxSize() || now.Sub(a.openedAt) >= a.opts.maxAge()) {
+		if err := a.closeFile(); err != nil {
+			return err
+		}
+	}
+	if a.gz == nil {
+		if err := a.openFile(now); err != nil {
+			return err
+		}
+	}
+	if _, err := a.gz.Write(line); err != nil {
+		return err
+	}
+	a.written += int64(len(line))
+	return a.gz.Flush()
+}
+
+// Close closes the current file.
+func (a *FileArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.gz == nil {
+		return nil
+	}
+	return a.closeFile()
+}
+
+// openFile creates a new file named after now. The lock must be held.
+func (a *FileArchive) openFile(now time.Time) error {
+	// Keep the names of the files in the order they were created, even if
+	// the clock did not advance.
+	named := now
+	if !named.After(a.namedAt) {
+		named = a.namedAt.Add(time.Nanosecond)
+	}
+	base := "ocsp-" + named.UTC().Format("20060102T150405.000000000Z")
+	for i := 0; ; i++ {
+		name := base + ".jsonl.gz"
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d.jsonl.gz", base, i)
+		}
+		f, err := os.OpenFile(filepath.Join(a.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		a.f = f
+		a.gz = gzip.NewWriter(f)
+		a.written = 0
+		a.openedAt, a.namedAt = now, named
+		return nil
+	}
+}
+
+// closeFile finishes and closes the current file. The lock must be held.
+func (a *FileArchive) closeFile() error {
+	err := a.gz.Close()
+	if serr := a.f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := a.f.Close(); err == nil {
+		err = cerr
+	}
+	a.f, a.gz = nil, nil
+	return err
+}
+
+// ReadArchive calls fn with each record in a file written by a FileArchive.
+// It stops at the first error returned by fn, and returns it.
+func ReadArchive(r io.Reader, fn func(ArchiveRecord) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	s := bufio.NewScanner(gz)
+	s.Buffer(nil, 1<<24)
+	for s.Scan() {
+		var line archiveLine
+		if err := json.Unmarshal(s.Bytes(), &line); err != nil {
+			return err
+		}
+		rec, err := line.record()
+		if err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func (line *archiveLine) record() (ArchiveRecord, error) {
+	oid, err := parseOID(line.HashAlgorithm)
+	if err != nil {
+		return ArchiveRecord{}, err
+	}
+	hash := getHashAlgorithmFromOID(oid)
+	if hash == 0 {
+		return ArchiveRecord{}, fmt.Errorf("ocsp: unknown hash algorithm %s in archive", line.HashAlgorithm)
+	}
+	nameHash, err := hex.DecodeString(line.IssuerNameHash)
+	if err != nil {
+		return ArchiveRecord{}, err
+	}
+	keyHash, err := hex.DecodeString(line.IssuerKeyHash)
+	if err != nil {
+		return ArchiveRecord{}, err
+	}
+	serial, ok := new(big.Int).SetString(line.SerialNumber, 16)
+	if !ok {
+		return ArchiveRecord{}, fmt.Errorf("ocsp: bad serial number %q in archive", line.SerialNumber)
+	}
+	return ArchiveRecord{
+		Response: line.Response,
+		CertID: CertID{
+			HashAlgorithm:  hash,
+			IssuerNameHash: nameHash,
+			IssuerKeyHash:  keyHash,
+			SerialNumber:   serial,
+		},
+		Status:     line.Status,
+		ThisUpdate: line.ThisUpdate,
+		NextUpdate: line.NextUpdate,
+		SignedAt:   line.SignedAt,
+	}, nil
+}
+
+// serialHex returns the hexadecimal encoding of serial, or "0" if it is nil.
+func serialHex(serial *big.Int) string {
+	if serial == nil {
+		return "0"
+	}
+	return serial.Text(16)
+}
+
+// parseOID parses the dotted decimal form of an object identifier.
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, arc := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(arc)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("ocsp: bad object identifier %q", s)
+		}
+		oid = append(oid, n)
+	}
+	return oid, nil
+}
@@ -0,0 +1,165 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryArchive is an Archive keeping the records in memory. It fails for the
+// serial numbers in fail.
+type memoryArchive struct {
+	mu      sync.Mutex
+	records []ArchiveRecord
+	fail    map[int64]bool
+}
+
+func (a *memoryArchive) Archive(_ context.Context, rec ArchiveRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.fail[rec.CertID.SerialNumber.Int64()] {
+		return errors.New("archive is full")
+	}
+	a.records = append(a.records, rec)
+	return nil
+}
+
+func TestNewArchiveRecord(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	signedAt := time.Now()
+	rec, err := NewArchiveRecord(der, signedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.CertID.Key() != resp.Key() || rec.Status != resp.Status ||
+		!rec.ThisUpdate.Equal(resp.ThisUpdate) || !rec.NextUpdate.Equal(resp.NextUpdate) ||
+		!rec.SignedAt.Equal(signedAt) || !bytes.Equal(rec.Response, der) {
+		t.Errorf("NewArchiveRecord: got %+v", rec)
+	}
+
+	errResp, _ := hex.DecodeString(errorResponseHex)
+	if _, err := NewArchiveRecord(errResp, signedAt); err == nil {
+		t.Error("NewArchiveRecord didn't fail with an error response")
+	}
+}
+
+func TestFileArchive(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFileArchive(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Error("NewFileArchive didn't fail with a missing directory")
+	}
+
+	der, _ := hex.DecodeString(ocspResponseHex)
+	rec, err := NewArchiveRecord(der, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each file has room for two records.
+	a, err := NewFileArchive(dir, &FileArchiveOptions{MaxSize: 2500, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		rec.CertID.SerialNumber = big.NewInt(int64(i))
+		if err := a.Archive(context.Background(), rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Old files are rotated.
+	now = now.Add(time.Hour)
+	rec.CertID.SerialNumber = big.NewInt(5)
+	if err := a.Archive(context.Background(), rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "ocsp-*.jsonl.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if len(files) != 4 {
+		t.Fatalf("got %d files, want 4", len(files))
+	}
+	var serials []int64
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ReadArchive(f, func(got ArchiveRecord) error {
+			serials = append(serials, got.CertID.SerialNumber.Int64())
+			if got.CertID.HashAlgorithm != crypto.SHA1 ||
+				!bytes.Equal(got.CertID.IssuerNameHash, rec.CertID.IssuerNameHash) ||
+				!bytes.Equal(got.CertID.IssuerKeyHash, rec.CertID.IssuerKeyHash) ||
+				got.Status != rec.Status || !got.ThisUpdate.Equal(rec.ThisUpdate) ||
+				!got.SignedAt.Equal(rec.SignedAt) || !bytes.Equal(got.Response, der) {
+				t.Errorf("ReadArchive: got %+v", got)
+			}
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, serial := range serials {
+		if serial != int64(i) {
+			t.Errorf("ReadArchive: got serial numbers %v", serials)
+			break
+		}
+	}
+}
+
+func TestSignBatchArchive(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, key := newTestResponder(t, "responder")
+
+	templates := make([]Response, 4)
+	for i := range templates {
+		templates[i] = Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(int64(i)),
+			ThisUpdate:   time.Now().Truncate(time.Second),
+		}
+	}
+	a := &memoryArchive{fail: map[int64]bool{2: true}}
+	results := SignBatch(context.Background(), issuer, responder, templates, key, &BatchOptions{Archive: a})
+	for i, result := range results {
+		if i == 2 {
+			if result.Err == nil || result.Response != nil {
+				t.Errorf("results[%d]: got a response that was not archived", i)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("results[%d].Err: %v", i, result.Err)
+		}
+	}
+	if len(a.records) != 3 {
+		t.Errorf("got %d archived responses, want 3", len(a.records))
+	}
+}
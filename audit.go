@@ -0,0 +1,139 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditSink is the interface implemented by the sinks receiving a record of
+// every answer of a responder, as required by audits like WebTrust's.
+// Responders call it after answering each request.
+type AuditSink interface {
+	Audit(ctx context.Context, rec AuditRecord) error
+}
+
+// AuditRecord describes the answer of a responder to a request.
+type AuditRecord struct {
+	// Time is the time the request was answered.
+	Time time.Time
+	// CertID identifies the certificate the request was for.
+	CertID CertID
+	// RemoteAddr is the IP address of the requester.
+	RemoteAddr string
+	// HasNonce indicates that the request included a nonce.
+	HasNonce bool
+	// ResponseStatus is the status of the response, Success unless an error
+	// response, like tryLater, was returned.
+	ResponseStatus ResponseStatus
+	// Status is the status of the certificate, Good, Revoked or Unknown. It
+	// is only meaningful if ResponseStatus is Success.
+	Status int
+	// CacheHit indicates that the response was served from a cache instead
+	// of being signed for the request.
+	CacheHit bool
+	// KeyID identifies the key that signed the response, as the SHA-1 hash
+	// of the responder public key used in responder IDs by key. It is empty
+	// for error responses, which are not signed.
+	KeyID []byte
+}
+
+// NewAuditRecord returns the AuditRecord of the response in der, returned at
+// t to the request req received from remoteAddr. The signature of the
+// response is not verified. Responses that do not include the responder key
+// hash must embed the responder certificate for KeyID to be set.
+func NewAuditRecord(req *Request, der []byte, remoteAddr string, cacheHit bool, t time.Time) (AuditRecord, error) {
+	rec := AuditRecord{
+		Time:       t,
+		CertID:     *req.CertID(),
+		RemoteAddr: remoteAddr,
+		HasNonce:   len(req.Nonce) > 0,
+		CacheHit:   cacheHit,
+	}
+	match, err := certIDMatcher(&rec.CertID)
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	resp, err := parseResponse(der, match, nil, "CertID", nil, &ParseOptions{SkipSignatureVerification: true})
+	var respErr ResponseError
+	switch {
+	case errors.As(err, &respErr):
+		rec.ResponseStatus = respErr.Status
+		return rec, nil
+	case err != nil:
+		return AuditRecord{}, err
+	}
+	rec.ResponseStatus = Success
+	rec.Status = resp.Status
+	switch {
+	case len(resp.ResponderKeyHash) > 0:
+		rec.KeyID = resp.ResponderKeyHash
+	case resp.Certificate != nil:
+		if rec.KeyID, err = publicKeyHash(resp.Certificate, crypto.SHA1); err != nil {
+			return AuditRecord{}, err
+		}
+	}
+	return rec, nil
+}
+
+// auditLine is the JSON encoding of an AuditRecord in a JSONAuditLog.
+type auditLine struct {
+	Time           time.Time `json:"time"`
+	HashAlgorithm  string    `json:"hashAlgorithm"`
+	IssuerNameHash string    `json:"issuerNameHash"`
+	IssuerKeyHash  string    `json:"issuerKeyHash"`
+	SerialNumber   string    `json:"serialNumber"`
+	RemoteAddr     string    `json:"remoteAddr"`
+	HasNonce       bool      `json:"hasNonce"`
+	ResponseStatus string    `json:"responseStatus"`
+	Status         *int      `json:"status,omitempty"`
+	CacheHit       bool      `json:"cacheHit"`
+	KeyID          string    `json:"keyID,omitempty"`
+}
+
+// JSONAuditLog is an AuditSink writing the records to an io.Writer, one JSON
+// object per line, with hex-encoded hashes, serial number and key ID.
+type JSONAuditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAuditLog returns a JSONAuditLog writing to w. Each record is written
+// with a single call to w.Write.
+func NewJSONAuditLog(w io.Writer) *JSONAuditLog {
+	return &JSONAuditLog{w: w}
+}
+
+// Audit writes rec to the log.
+func (l *JSONAuditLog) Audit(_ context.Context, rec AuditRecord) error {
+	line := auditLine{
+		Time:           rec.Time,
+		HashAlgorithm:  getOIDFromHashAlgorithm(rec.CertID.HashAlgorithm).String(),
+		IssuerNameHash: hex.EncodeToString(rec.CertID.IssuerNameHash),
+		IssuerKeyHash:  hex.EncodeToString(rec.CertID.IssuerKeyHash),
+		SerialNumber:   serialHex(rec.CertID.SerialNumber),
+		RemoteAddr:     rec.RemoteAddr,
+		HasNonce:       rec.HasNonce,
+		ResponseStatus: rec.ResponseStatus.String(),
+		CacheHit:       rec.CacheHit,
+		KeyID:          hex.EncodeToString(rec.KeyID),
+	}
+	if rec.ResponseStatus == Success {
+		line.Status = &rec.Status
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(b)
+	return err
+}
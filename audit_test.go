@@ -0,0 +1,126 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewAuditRecord(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	der, err := CreateResponse(responder, responder, Response{
+		Status:       Revoked,
+		SerialNumber: big.NewInt(42),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		RevokedAt:    time.Now().Add(-time.Hour).Truncate(time.Second),
+		Certificate:  responder,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{
+		HashAlgorithm:  resp.IssuerHash,
+		IssuerNameHash: resp.IssuerNameHash,
+		IssuerKeyHash:  resp.IssuerKeyHash,
+		SerialNumber:   big.NewInt(42),
+		Nonce:          []byte("nonce"),
+	}
+	now := time.Now()
+	rec, err := NewAuditRecord(req, der, "192.0.2.1", true, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, err := publicKeyHash(responder, crypto.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.CertID.Key() != resp.Key() || rec.RemoteAddr != "192.0.2.1" || !rec.HasNonce || !rec.CacheHit ||
+		rec.ResponseStatus != Success || rec.Status != Revoked || !bytes.Equal(rec.KeyID, keyID) || !rec.Time.Equal(now) {
+		t.Errorf("NewAuditRecord: got %+v", rec)
+	}
+
+	// A response for another certificate is an error.
+	req.SerialNumber = big.NewInt(43)
+	if _, err := NewAuditRecord(req, der, "192.0.2.1", false, now); err == nil {
+		t.Error("NewAuditRecord didn't fail with a response for another certificate")
+	}
+
+	errResp, _ := hex.DecodeString(errorResponseHex)
+	rec, err = NewAuditRecord(req, errResp, "192.0.2.1", false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ResponseStatus == Success || rec.KeyID != nil {
+		t.Errorf("NewAuditRecord: got %+v for an error response", rec)
+	}
+}
+
+func TestJSONAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewJSONAuditLog(&buf)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []AuditRecord{
+		{
+			Time:           now,
+			CertID:         CertID{HashAlgorithm: crypto.SHA1, IssuerNameHash: []byte{1}, IssuerKeyHash: []byte{2}, SerialNumber: big.NewInt(255)},
+			RemoteAddr:     "192.0.2.1",
+			HasNonce:       true,
+			ResponseStatus: Success,
+			Status:         Good,
+			KeyID:          []byte{3},
+		},
+		{
+			Time:           now,
+			CertID:         CertID{HashAlgorithm: crypto.SHA1, SerialNumber: big.NewInt(1)},
+			RemoteAddr:     "2001:db8::1",
+			ResponseStatus: TryLater,
+			CacheHit:       true,
+		},
+	}
+	for _, rec := range records {
+		if err := log.Audit(context.Background(), rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != len(records) {
+		t.Fatalf("JSONAuditLog: got %d lines, want %d", len(lines), len(records))
+	}
+	want := []map[string]any{
+		{
+			"time": "2026-01-01T00:00:00Z", "hashAlgorithm": "1.3.14.3.2.26", "issuerNameHash": "01", "issuerKeyHash": "02",
+			"serialNumber": "ff", "remoteAddr": "192.0.2.1", "hasNonce": true, "responseStatus": "success",
+			"status": float64(Good), "cacheHit": false, "keyID": "03",
+		},
+		{
+			"time": "2026-01-01T00:00:00Z", "hashAlgorithm": "1.3.14.3.2.26", "issuerNameHash": "", "issuerKeyHash": "",
+			"serialNumber": "1", "remoteAddr": "2001:db8::1", "hasNonce": false, "responseStatus": TryLater.String(),
+			"cacheHit": true,
+		},
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal(line, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want[i]) {
+			t.Errorf("line %d: got %v, want %v", i, got, want[i])
+			continue
+		}
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("line %d: got %s %v, want %v", i, k, got[k], v)
+			}
+		}
+	}
+}
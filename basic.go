@@ -0,0 +1,204 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"time"
+)
+
+// BasicResponse is the basic OCSP response of RFC 6960, section 4.2.1, with
+// all the certificate statuses it contains. Unlike Response, it can represent
+// and build any basic response, for example, one with several statuses or
+// with a responder ID not derived from a certificate.
+//
+// A BasicResponse is built by setting its fields and calling Sign, and it is
+// encoded with Marshal.
+type BasicResponse struct {
+	// RawTBSResponseData is the DER-encoded ResponseData covered by the
+	// signature. It is set by ParseBasicResponse and Sign.
+	RawTBSResponseData []byte
+	// RawResponderID is the DER-encoded ResponderID, either a Name with
+	// tag 1 or a key hash with tag 2.
+	RawResponderID []byte
+	ProducedAt     time.Time
+	// Responses are the statuses in the response.
+	Responses []SingleResponse
+	// Extensions are the responseExtensions of the response.
+	Extensions []pkix.Extension
+
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	// Certificates are the certificates included in the response, starting
+	// with the one of the responder, if any.
+	Certificates []*x509.Certificate
+}
+
+// ParseBasicResponse parses the basic response in the DER-encoded OCSP
+// response der. It does not verify the signature of the response; use
+// CheckSignature for that.
+func ParseBasicResponse(der []byte) (*BasicResponse, error) {
+	basicResp, _, err := parseRawBasicResponse(der)
+	if err != nil {
+		return nil, err
+	}
+	var tbs responseData
+	if _, err := asn1.Unmarshal(basicResp.TBSResponseData.FullBytes, &tbs); err != nil {
+		return nil, err
+	}
+
+	ret := &BasicResponse{
+		RawTBSResponseData: basicResp.TBSResponseData.FullBytes,
+		RawResponderID:     tbs.RawResponderID.FullBytes,
+		ProducedAt:         tbs.ProducedAt,
+		Responses:          make([]SingleResponse, len(tbs.Responses)),
+		Extensions:         tbs.ResponseExtensions,
+		SignatureAlgorithm: basicResp.SignatureAlgorithm,
+		Signature:          basicResp.Signature.RightAlign(),
+	}
+	for i := range tbs.Responses {
+		ret.Responses[i] = newSingleResponse(&tbs.Responses[i])
+	}
+	for _, raw := range basicResp.Certificates {
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, ParseError("bad embedded certificate: " + err.Error())
+		}
+		ret.Certificates = append(ret.Certificates, cert)
+	}
+	return ret, nil
+}
+
+// MarshalTBS returns the DER-encoded ResponseData built from the fields of b.
+// RawTBSResponseData is ignored.
+func (b *BasicResponse) MarshalTBS() ([]byte, error) {
+	if len(b.RawResponderID) == 0 {
+		return nil, errors.New("ocsp: responder ID is required")
+	}
+	if len(b.Responses) == 0 {
+		return nil, errors.New("ocsp: at least one response is required")
+	}
+	tbs := responseData{
+		RawResponderID:     asn1.RawValue{FullBytes: b.RawResponderID},
+		ProducedAt:         b.ProducedAt.UTC(),
+		Responses:          make([]singleResponse, len(b.Responses)),
+		ResponseExtensions: b.Extensions,
+	}
+	for i := range b.Responses {
+		var err error
+		if tbs.Responses[i], err = b.Responses[i].asn1(); err != nil {
+			return nil, err
+		}
+	}
+	return asn1.Marshal(tbs)
+}
+
+// Sign encodes the fields of b with MarshalTBS and signs them with priv,
+// setting RawTBSResponseData, SignatureAlgorithm and Signature. If sigAlgo is
+// zero, a default signature algorithm for the key of priv is used.
+func (b *BasicResponse) Sign(priv crypto.Signer, sigAlgo x509.SignatureAlgorithm) error {
+	tbs, err := b.MarshalTBS()
+	if err != nil {
+		return err
+	}
+	algo, signature, err := signResponseData(priv, sigAlgo, 0, tbs)
+	if err != nil {
+		return err
+	}
+	b.RawTBSResponseData = tbs
+	b.SignatureAlgorithm = algo
+	b.Signature = signature
+	return nil
+}
+
+// CheckSignature checks that the signature of b is a valid signature of
+// RawTBSResponseData by pub.
+func (b *BasicResponse) CheckSignature(pub crypto.PublicKey) error {
+	algo, saltLength := getSignatureAlgorithmFromAI(b.SignatureAlgorithm)
+	return verificationError(checkSignature(algo, b.RawTBSResponseData, b.Signature, pub, saltLength))
+}
+
+// Marshal returns the DER-encoded OCSP response containing b. The signed
+// RawTBSResponseData is used as is, so b must have been signed or parsed.
+func (b *BasicResponse) Marshal() ([]byte, error) {
+	if len(b.RawTBSResponseData) == 0 || len(b.Signature) == 0 {
+		return nil, errors.New("ocsp: basic response is not signed")
+	}
+	response := rawBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: b.RawTBSResponseData},
+		SignatureAlgorithm: b.SignatureAlgorithm,
+		Signature: asn1.BitString{
+			Bytes:     b.Signature,
+			BitLength: 8 * len(b.Signature),
+		},
+	}
+	for _, cert := range b.Certificates {
+		response.Certificates = append(response.Certificates, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	responseDER, err := asn1.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(responseASN1{
+		Status: asn1.Enumerated(Success),
+		Response: responseBytes{
+			ResponseType: idPKIXOCSPBasic,
+			Response:     responseDER,
+		},
+	})
+}
+
+// ResignResponse signs again the OCSP response der with priv, for example,
+// after rotating the responder key. The statuses and extensions of the
+// response are kept, while the responder ID is set from responderCert, using
+// the same form as the original response, and ProducedAt is set to the
+// current time. The certificates of the original response are replaced by
+// responderCert, so that clients can verify a delegated responder. The
+// certificate is only left out if it was also left out of the original
+// response and its key signed the original response. A parsed response can
+// be signed again using its Raw field.
+//
+// The signature of der is not verified.
+func ResignResponse(der []byte, responderCert *x509.Certificate, priv crypto.Signer) ([]byte, error) {
+	if responderCert == nil || priv == nil {
+		return nil, errors.New("ocsp: responder certificate and signer are required")
+	}
+	b, err := ParseBasicResponse(der)
+	if err != nil {
+		return nil, err
+	}
+	var oldID asn1.RawValue
+	if _, err := asn1.Unmarshal(b.RawResponderID, &oldID); err != nil {
+		return nil, err
+	}
+
+	newID := asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        1, // Name (explicit tag)
+		IsCompound: true,
+		Bytes:      responderCert.RawSubject,
+	}
+	if oldID.Tag == 2 {
+		keyHash, err := publicKeyHash(responderCert, crypto.SHA1)
+		if err != nil {
+			return nil, err
+		}
+		newID.Tag = 2 // KeyHash (explicit tag)
+		if newID.Bytes, err = asn1.Marshal(keyHash); err != nil {
+			return nil, err
+		}
+	}
+	if b.RawResponderID, err = asn1.Marshal(newID); err != nil {
+		return nil, err
+	}
+	b.ProducedAt = time.Now().Truncate(time.Minute).UTC()
+	if len(b.Certificates) > 0 || b.CheckSignature(responderCert.PublicKey) != nil {
+		b.Certificates = []*x509.Certificate{responderCert}
+	}
+	if err := b.Sign(priv, 0); err != nil {
+		return nil, err
+	}
+	return b.Marshal()
+}
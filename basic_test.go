@@ -0,0 +1,245 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseBasicResponse(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	want, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseBasicResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.RawTBSResponseData, want.TBSResponseData) || !bytes.Equal(b.Signature, want.Signature) ||
+		!b.ProducedAt.Equal(want.ProducedAt) || len(b.Responses) != 1 ||
+		!bytes.Equal(b.Responses[0].Raw, want.RawSingleResponse) {
+		t.Errorf("ParseBasicResponse: got %+v", b)
+	}
+	got, err := b.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, der) {
+		t.Error("Marshal of a parsed response does not match the original")
+	}
+	tbs, err := b.MarshalTBS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tbs, b.RawTBSResponseData) {
+		t.Error("MarshalTBS of a parsed response does not match the original")
+	}
+
+	der, _ = hex.DecodeString(errorResponseHex)
+	if _, err := ParseBasicResponse(der); err == nil {
+		t.Error("ParseBasicResponse didn't fail with an error response")
+	}
+}
+
+func TestBasicResponseSign(t *testing.T) {
+	responder, key := newTestResponder(t, "responder")
+	responderID, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: responder.RawSubject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	single := func(serial int64, status int) SingleResponse {
+		r := SingleResponse{
+			CertID: CertID{
+				HashAlgorithm:  crypto.SHA256,
+				IssuerNameHash: bytes.Repeat([]byte{1}, 32),
+				IssuerKeyHash:  bytes.Repeat([]byte{2}, 32),
+				SerialNumber:   big.NewInt(serial),
+			},
+			Status:     status,
+			ThisUpdate: now,
+			NextUpdate: now.Add(time.Hour),
+		}
+		if status == Revoked {
+			r.RevokedAt = now.Add(-time.Hour)
+			r.RevocationReason = KeyCompromise
+		}
+		return r
+	}
+	b := &BasicResponse{
+		RawResponderID: responderID,
+		ProducedAt:     now,
+		Responses:      []SingleResponse{single(1, Good), single(2, Revoked), single(3, Unknown)},
+		Certificates:   []*x509.Certificate{responder},
+	}
+	if _, err := b.Marshal(); err == nil {
+		t.Error("Marshal didn't fail before signing")
+	}
+	if err := b.Sign(key, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CheckSignature(key.Public()); err != nil {
+		t.Error(err)
+	}
+	der, err := b.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range b.Responses {
+		resp, err := ParseResponseForSerial(der, want.CertID.SerialNumber, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != want.Status || !resp.ThisUpdate.Equal(want.ThisUpdate) ||
+			!resp.RevokedAt.Equal(want.RevokedAt) || resp.RevocationReason != want.RevocationReason ||
+			resp.IssuerHash != crypto.SHA256 || !resp.Certificate.Equal(responder) {
+			t.Errorf("got %+v, want %+v", resp, want)
+		}
+	}
+
+	parsed, err := ParseBasicResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range parsed.Responses {
+		got, err := ParseSingleResponse(r.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := got.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, r.Raw) {
+			t.Errorf("response %d: Marshal does not match the parsed encoding", i)
+		}
+	}
+
+	b.Responses[0].Status = 7
+	if err := b.Sign(key, 0); err == nil {
+		t.Error("Sign didn't fail with an invalid status")
+	}
+	b.Responses = nil
+	if _, err := b.MarshalTBS(); err == nil {
+		t.Error("MarshalTBS didn't fail without responses")
+	}
+}
+
+func TestResignResponse(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCert, oldKey := newTestResponder(t, "old responder")
+	newCert, newKey := newTestResponder(t, "new responder")
+	template := Response{
+		Status:           Revoked,
+		SerialNumber:     big.NewInt(42),
+		ThisUpdate:       time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		NextUpdate:       time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		RevokedAt:        time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second),
+		RevocationReason: Superseded,
+		Certificate:      oldCert,
+	}
+
+	for _, opts := range []*CreateResponseOptions{nil, {ResponderIDByKey: true, OmitCertificate: true}} {
+		der, err := CreateResponseWithOptions(issuer, oldCert, template, oldKey, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resigned, err := ResignResponse(der, newCert, newKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(resigned, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := resp.CheckSignatureFromKey(newKey.Public()); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(resp.RawSingleResponse, old.RawSingleResponse) {
+			t.Error("ResignResponse changed the status of the response")
+		}
+		if _, err := resp.FindResponder([]*x509.Certificate{newCert}); err != nil {
+			t.Errorf("ResignResponse: responder ID does not match the new certificate: %v", err)
+		}
+		// The new responder did not sign the original response, so its
+		// certificate is always embedded.
+		if resp.Certificate == nil || !resp.Certificate.Equal(newCert) {
+			t.Errorf("ResignResponse: got certificate %v", resp.Certificate)
+		}
+	}
+
+	// Signing again with the original responder keeps the certificate out.
+	der, err := CreateResponseWithOptions(issuer, oldCert, template, oldKey, &CreateResponseOptions{OmitCertificate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resigned, err := ResignResponse(der, oldCert, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := ParseResponse(resigned, nil); err != nil || resp.Certificate != nil {
+		t.Errorf("ResignResponse: got certificate and error %v, %v, want none", resp.Certificate, err)
+	}
+}
+
+func TestResignResponseDelegated(t *testing.T) {
+	issuer, issuerKey := newTestResponder(t, "Issuer")
+	responderKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Responder"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, issuer, responderKey.Public(), issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A response signed directly by the issuer does not embed certificates.
+	der, err := CreateResponse(issuer, issuer, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resigned, err := ResignResponse(der, responder, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(resigned, issuer)
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if resp.Certificate == nil || !resp.Certificate.Equal(responder) {
+		t.Errorf("ResignResponse: got certificate %v, want the delegated responder", resp.Certificate)
+	}
+}
@@ -0,0 +1,194 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Limiter is the interface used to rate limit operations. It is implemented
+// by golang.org/x/time/rate.Limiter.
+type Limiter interface {
+	// Wait blocks until the operation is allowed to happen, or returns an
+	// error if the context is done first.
+	Wait(ctx context.Context) error
+}
+
+// BatchOptions contains options for SignBatch.
+type BatchOptions struct {
+	// Concurrency is the maximum number of responses signed concurrently.
+	// If zero, runtime.GOMAXPROCS(0) is used.
+	Concurrency int
+
+	// Limiter optionally limits the rate at which responses are signed, for
+	// example, to avoid overloading an HSM.
+	Limiter Limiter
+
+	// Archive optionally receives every signed response. Responses that
+	// cannot be archived are not returned, and their results contain the
+	// error instead.
+	Archive Archive
+
+	// Hashes optionally sets the CertID hash algorithms each template is
+	// signed for, overriding template.IssuerHash, so clients using any of
+	// them are answered. The variants of each template are stored with their
+	// BatchResult.Key in a Cache or Store, which are looked up with the Key of
+	// the requests.
+	Hashes []crypto.Hash
+}
+
+func (opts *BatchOptions) concurrency() int {
+	if opts == nil || opts.Concurrency <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return opts.Concurrency
+}
+
+func (opts *BatchOptions) limiter() Limiter {
+	if opts == nil {
+		return nil
+	}
+	return opts.Limiter
+}
+
+func (opts *BatchOptions) hashes() []crypto.Hash {
+	if opts == nil {
+		return nil
+	}
+	return opts.Hashes
+}
+
+func (opts *BatchOptions) archive() Archive {
+	if opts == nil {
+		return nil
+	}
+	return opts.Archive
+}
+
+// BatchResult is the result of signing one of the templates in SignBatch.
+type BatchResult struct {
+	// Response is the DER-encoded OCSP response, nil if Err is not nil.
+	Response []byte
+	// Err is the error signing the response.
+	Err error
+	// Key is the CertIDKey of the response, set if Err is nil.
+	Key CertIDKey
+}
+
+// SignBatch creates an OCSP response for each template using
+// CreateResponseContext, signing up to opts.Concurrency responses concurrently.
+// If opts is nil then sensible defaults are used.
+//
+// The returned slice contains one result for each template, in the same order.
+// If opts.Hashes is not empty, it contains one result for each template and
+// hash instead, with the results of template i at indexes i*len(opts.Hashes)
+// to (i+1)*len(opts.Hashes)-1, in the order of opts.Hashes. If ctx is done
+// before all the responses are signed, the results of the remaining templates
+// contain the context error.
+func SignBatch(ctx context.Context, issuer, responderCert *x509.Certificate, templates []Response, priv crypto.Signer, opts *BatchOptions) []BatchResult {
+	if hashes := opts.hashes(); len(hashes) > 0 {
+		variants := make([]Response, 0, len(templates)*len(hashes))
+		for _, template := range templates {
+			for _, hash := range hashes {
+				variants = append(variants, hashVariant(template, hash))
+			}
+		}
+		templates = variants
+	}
+	results := make([]BatchResult, len(templates))
+	limiter, archiver := opts.limiter(), opts.archive()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.concurrency())
+	for i := range templates {
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					results[i].Err = err
+					return
+				}
+			}
+			der, err := CreateResponseContext(ctx, issuer, responderCert, templates[i], priv)
+			if err == nil {
+				err = archive(ctx, archiver, der, time.Now())
+			}
+			var key CertIDKey
+			if err == nil {
+				key, err = templateKey(issuer, &templates[i])
+			}
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Response = der
+			results[i].Key = key
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// hashVariant returns a copy of template for the CertID hash algorithm hash.
+// Issuer hashes computed with another algorithm are cleared, so they are
+// computed again from the issuer.
+func hashVariant(template Response, hash crypto.Hash) Response {
+	issuerHash := template.IssuerHash
+	if issuerHash == 0 {
+		issuerHash = crypto.SHA1
+	}
+	if issuerHash != hash {
+		template.IssuerNameHash = nil
+		template.IssuerKeyHash = nil
+	}
+	template.IssuerHash = hash
+	return template
+}
+
+// templateKey returns the CertIDKey of the response created from template,
+// using the issuer hashes of the template, or computing them from issuer.
+func templateKey(issuer *x509.Certificate, template *Response) (CertIDKey, error) {
+	hash := template.IssuerHash
+	if hash == 0 {
+		hash = crypto.SHA1
+	}
+	nameHash, keyHash := template.IssuerNameHash, template.IssuerKeyHash
+	if len(nameHash) == 0 || len(keyHash) == 0 {
+		var err error
+		if nameHash, keyHash, err = issuerHashes(issuer, hash); err != nil {
+			return CertIDKey{}, err
+		}
+	}
+	return certIDKey(hash, nameHash, keyHash, template.SerialNumber), nil
+}
+
+// issuerHashes returns the IssuerNameHash and IssuerKeyHash of a CertID for
+// the certificates of issuer.
+func issuerHashes(issuer *x509.Certificate, hash crypto.Hash) (nameHash, keyHash []byte, err error) {
+	keyHash, err = publicKeyHash(issuer, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, _ := newHash(hash)
+	h.Write(issuer.RawSubject)
+	return h.Sum(nil), keyHash, nil
+}
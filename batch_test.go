@@ -0,0 +1,172 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSigner records the maximum number of concurrent calls to Sign.
+type countingSigner struct {
+	crypto.Signer
+	current, max int32
+}
+
+func (s *countingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	n := atomic.AddInt32(&s.current, 1)
+	defer atomic.AddInt32(&s.current, -1)
+	for {
+		m := atomic.LoadInt32(&s.max)
+		if n <= m || atomic.CompareAndSwapInt32(&s.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+type countingLimiter struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	return ctx.Err()
+}
+
+func TestSignBatch(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates := make([]Response, 20)
+	for i := range templates {
+		templates[i] = Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(int64(i)),
+			ThisUpdate:   time.Now().Truncate(time.Second),
+		}
+	}
+	templates[5].IssuerHash = crypto.MD5
+
+	signer := &countingSigner{Signer: key}
+	limiter := &countingLimiter{}
+	results := SignBatch(context.Background(), issuer, responder, templates, signer, &BatchOptions{
+		Concurrency: 3,
+		Limiter:     limiter,
+	})
+	if len(results) != len(templates) {
+		t.Fatalf("len(results): got %d, want %d", len(results), len(templates))
+	}
+	for i, result := range results {
+		if i == 5 {
+			if result.Err == nil {
+				t.Errorf("results[%d].Err: got nil, want error", i)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("results[%d].Err: %v", i, result.Err)
+			continue
+		}
+		resp, err := ParseResponse(result.Response, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.SerialNumber.Int64() != int64(i) {
+			t.Errorf("results[%d] serial: got %d, want %d", i, resp.SerialNumber, i)
+		}
+	}
+	if signer.max > 3 {
+		t.Errorf("concurrent signatures: got %d, want at most 3", signer.max)
+	}
+	if limiter.calls != len(templates) {
+		t.Errorf("limiter calls: got %d, want %d", limiter.calls, len(templates))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, result := range SignBatch(ctx, issuer, responder, templates, key, nil) {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("results[%d].Err: got %v, want %v", i, result.Err, context.Canceled)
+		}
+	}
+}
+
+func TestSignBatchHashes(t *testing.T) {
+	issuer, key := newTestResponder(t, "issuer")
+
+	templates := []Response{
+		{Status: Good, SerialNumber: big.NewInt(1), ThisUpdate: time.Now().Truncate(time.Second)},
+		{Status: Revoked, SerialNumber: big.NewInt(2), ThisUpdate: time.Now().Truncate(time.Second), RevokedAt: time.Now().Truncate(time.Second)},
+	}
+	hashes := []crypto.Hash{crypto.SHA1, crypto.SHA256}
+	results := SignBatch(context.Background(), issuer, issuer, templates, key, &BatchOptions{Hashes: hashes})
+	if len(results) != len(templates)*len(hashes) {
+		t.Fatalf("len(results): got %d, want %d", len(results), len(templates)*len(hashes))
+	}
+
+	variants := make(map[CertIDKey][]byte)
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("results[%d].Err: %v", i, result.Err)
+		}
+		resp, err := ParseResponse(result.Response, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template, hash := templates[i/len(hashes)], hashes[i%len(hashes)]
+		if resp.IssuerHash != hash || resp.SerialNumber.Cmp(template.SerialNumber) != 0 || resp.Status != template.Status {
+			t.Errorf("results[%d]: got hash %v, serial %d and status %d", i, resp.IssuerHash, resp.SerialNumber, resp.Status)
+		}
+		if result.Key != resp.Key() {
+			t.Errorf("results[%d].Key: got %v, want %v", i, result.Key, resp.Key())
+		}
+		variants[result.Key] = result.Response
+	}
+
+	leaf := &x509.Certificate{SerialNumber: big.NewInt(2)}
+	for _, hash := range hashes {
+		der, err := CreateRequest(leaf, issuer, &RequestOptions{Hash: hash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := ParseRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, ok := variants[req.Key()]
+		if !ok {
+			t.Errorf("no variant for a %v request", hash)
+			continue
+		}
+		if _, err := ParseResponseForCert(resp, leaf, issuer); err != nil {
+			t.Errorf("%v variant: %v", hash, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package ocsp
+
+import (
+	"container/heap"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// CertIDKey is a digest of the hash algorithm, issuer hashes and serial number
+// of a CertID. It identifies the certificate of a response in a cache.
+type CertIDKey [sha256.Size]byte
+
+// certIDKey returns the CertIDKey of the given CertID fields. Each field is
+// length-prefixed so different fields cannot produce the same input.
+func certIDKey(hash crypto.Hash, nameHash, keyHash []byte, serial *big.Int) CertIDKey {
+	h := sha256.New()
+	var buf [8]byte
+	write := func(b []byte) {
+		binary.BigEndian.PutUint64(buf[:], uint64(len(b)))
+		h.Write(buf[:])
+		h.Write(b)
+	}
+	binary.BigEndian.PutUint64(buf[:], uint64(hash))
+	h.Write(buf[:])
+	write(nameHash)
+	write(keyHash)
+	if serial != nil {
+		h.Write([]byte{byte(serial.Sign() + 1)})
+		write(serial.Bytes())
+	} else {
+		write(nil)
+	}
+
+	var key CertIDKey
+	h.Sum(key[:0])
+	return key
+}
+
+// Key returns the CertIDKey of the certificate the response is for, computed
+// from IssuerHash, IssuerNameHash, IssuerKeyHash and SerialNumber.
+func (resp *Response) Key() CertIDKey {
+	return certIDKey(resp.IssuerHash, resp.IssuerNameHash, resp.IssuerKeyHash, resp.SerialNumber)
+}
+
+// Key returns the CertIDKey of the certificate the request is for, computed
+// from HashAlgorithm, IssuerNameHash, IssuerKeyHash and SerialNumber. The
+// extensions of the request, like the nonce, are not included, so it matches
+// the Key of the responses to the request.
+func (req *Request) Key() CertIDKey {
+	return certIDKey(req.HashAlgorithm, req.IssuerNameHash, req.IssuerKeyHash, req.SerialNumber)
+}
+
+// NormalizeRequest returns the canonical encoding of the DER-encoded OCSP
+// request der, and its CertIDKey. Only the CertID of the request is kept, with
+// the hash algorithm parameters used by CreateRequest, while the extensions,
+// like the nonce, and the requestor name are removed. Requests differing only
+// in those details have the same canonical encoding, so responders and HTTP
+// caches keyed by the request bytes, like the GET URL, get more hits.
+func NormalizeRequest(der []byte) ([]byte, CertIDKey, error) {
+	req, err := ParseRequest(der)
+	if err != nil {
+		return nil, CertIDKey{}, err
+	}
+	normalized := &Request{
+		HashAlgorithm:  req.HashAlgorithm,
+		IssuerNameHash: req.IssuerNameHash,
+		IssuerKeyHash:  req.IssuerKeyHash,
+		SerialNumber:   req.SerialNumber,
+	}
+	ret, err := normalized.Marshal()
+	if err != nil {
+		return nil, CertIDKey{}, err
+	}
+	return ret, normalized.Key(), nil
+}
+
+// Key returns the CertIDKey of id.
+func (id *CertID) Key() CertIDKey {
+	return certIDKey(id.HashAlgorithm, id.IssuerNameHash, id.IssuerKeyHash, id.SerialNumber)
+}
+
+// ErrCacheMiss is returned by a Cache when there is no entry for a key.
+var ErrCacheMiss = errors.New("ocsp: cache miss")
+
+// Cache is the interface implemented by response caches. It can be
+// implemented in memory, like ShardedCache, or backed by an external store
+// shared by several responders, like the one returned by NewKVCache.
+type Cache interface {
+	// Get returns the entry stored for key, or ErrCacheMiss if there is
+	// none.
+	Get(ctx context.Context, key CertIDKey) (CacheEntry, error)
+	// Put stores entry for key, replacing any previous entry. The caches in
+	// this package only replace entries with strictly fresher ones, see
+	// CompareFreshness.
+	Put(ctx context.Context, key CertIDKey, entry CacheEntry) error
+	// Delete removes the entry stored for key. It does not return an error
+	// if there is none.
+	Delete(ctx context.Context, key CertIDKey) error
+}
+
+// CacheEntry is a DER-encoded OCSP response stored in a cache, with the
+// validity interval of the response.
+type CacheEntry struct {
+	Response   []byte
+	ThisUpdate time.Time
+	NextUpdate time.Time
+}
+
+// cacheEntryOverhead is the estimated memory used by an entry besides the
+// response bytes.
+const cacheEntryOverhead = 128
+
+// minShardBytes is the minimum budget of a ShardedCache shard, with room for
+// a typical response including its certificates.
+const minShardBytes = 4096 + cacheEntryOverhead
+
+func (e *CacheEntry) size() int64 {
+	return int64(len(e.Response)) + cacheEntryOverhead
+}
+
+// fresherThan reports whether e is strictly fresher than old, as defined by
+// CompareFreshness. Entries with the same ThisUpdate time are compared by
+// parsing their responses, and they are not fresher if that fails.
+func (e *CacheEntry) fresherThan(old *CacheEntry) bool {
+	if c := compareTime(e.ThisUpdate, old.ThisUpdate); c != 0 {
+		return c > 0
+	}
+	a, err := ParseResponse(e.Response, nil)
+	if err != nil {
+		return false
+	}
+	b, err := ParseResponse(old.Response, nil)
+	if err != nil {
+		return false
+	}
+	c, err := CompareFreshness(a, b)
+	return err == nil && c > 0
+}
+
+// expired reports whether the NextUpdate time of the entry has passed.
+func (e *CacheEntry) expired(now time.Time) bool {
+	return !e.NextUpdate.IsZero() && !now.Before(e.NextUpdate)
+}
+
+// ShardedCache is an in-memory cache of OCSP responses with a hard memory
+// budget. Entries are spread across independently locked shards by their key,
+// so it scales with the number of concurrent readers and writers. When a
+// shard exceeds its share of the budget, the entries closest to their
+// NextUpdate time are evicted first.
+type ShardedCache struct {
+	shards []cacheShard
+}
+
+// NewShardedCache returns a cache using up to maxBytes bytes split across the
+// given number of shards. If shards is not positive, 64 shards are used. Fewer
+// shards are used if needed to give each one room for at least one typical
+// response of a few kilobytes, so a small budget is not split into shards too
+// small to store anything.
+func NewShardedCache(maxBytes int64, shards int) *ShardedCache {
+	if shards <= 0 {
+		shards = 64
+	}
+	if n := maxBytes / minShardBytes; n < int64(shards) {
+		shards = int(max(n, 1))
+	}
+	c := &ShardedCache{shards: make([]cacheShard, shards)}
+	for i := range c.shards {
+		c.shards[i].maxBytes = maxBytes / int64(shards)
+		c.shards[i].items = make(map[CertIDKey]*cacheItem)
+	}
+	return c
+}
+
+func (c *ShardedCache) shard(key CertIDKey) *cacheShard {
+	return &c.shards[binary.BigEndian.Uint64(key[:8])%uint64(len(c.shards))]
+}
+
+// Get returns the entry stored for key, or ErrCacheMiss if there is none or
+// if its NextUpdate time has passed. Expired entries are evicted first when
+// room is needed.
+func (c *ShardedCache) Get(_ context.Context, key CertIDKey) (CacheEntry, error) {
+	if entry, ok := c.shard(key).get(key); ok && !entry.expired(time.Now()) {
+		return entry, nil
+	}
+	return CacheEntry{}, ErrCacheMiss
+}
+
+// Put stores entry for key, replacing any previous entry that is less fresh,
+// and evicts entries if needed. Entries that are not strictly fresher than the
+// stored one are ignored, so a replayed older response does not replace a
+// newer one. Entries larger than the budget of a shard are not stored.
+func (c *ShardedCache) Put(_ context.Context, key CertIDKey, entry CacheEntry) error {
+	c.shard(key).put(key, entry)
+	return nil
+}
+
+// Delete removes the entry stored for key.
+func (c *ShardedCache) Delete(_ context.Context, key CertIDKey) error {
+	c.shard(key).delete(key)
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (c *ShardedCache) Len() int {
+	var n int
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		n += len(s.items)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Size returns the estimated memory used by the entries in the cache.
+func (c *ShardedCache) Size() int64 {
+	var n int64
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		n += s.bytes
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+type cacheItem struct {
+	key   CertIDKey
+	entry CacheEntry
+	index int
+}
+
+type cacheShard struct {
+	mu       sync.RWMutex
+	items    map[CertIDKey]*cacheItem
+	expiry   expiryHeap
+	bytes    int64
+	maxBytes int64
+}
+
+func (s *cacheShard) get(key CertIDKey) (CacheEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if it, ok := s.items[key]; ok {
+		return it.entry, true
+	}
+	return CacheEntry{}, false
+}
+
+func (s *cacheShard) put(key CertIDKey, entry CacheEntry) {
+	size := entry.size()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	if ok && !entry.fresherThan(&it.entry) {
+		return
+	}
+	if size > s.maxBytes {
+		if ok {
+			s.remove(it)
+		}
+		return
+	}
+	if ok {
+		// Replace the entry in place to avoid an allocation.
+		s.bytes += size - it.entry.size()
+		it.entry = entry
+		heap.Fix(&s.expiry, it.index)
+	} else {
+		it = &cacheItem{key: key, entry: entry}
+		s.items[key] = it
+		heap.Push(&s.expiry, it)
+		s.bytes += size
+	}
+	for s.bytes > s.maxBytes {
+		s.remove(s.expiry[0])
+	}
+}
+
+func (s *cacheShard) delete(key CertIDKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if it, ok := s.items[key]; ok {
+		s.remove(it)
+	}
+}
+
+// remove removes it from the shard. The lock must be held.
+func (s *cacheShard) remove(it *cacheItem) {
+	heap.Remove(&s.expiry, it.index)
+	delete(s.items, it.key)
+	s.bytes -= it.entry.size()
+}
+
+// expiryHeap is a min-heap of cache items ordered by expiration.
+type expiryHeap []*cacheItem
+
+func (h expiryHeap) Len() int { return len(h) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Less orders entries by NextUpdate, with entries without NextUpdate last.
+func (h expiryHeap) Less(i, j int) bool {
+	a, b := h[i].entry.NextUpdate, h[j].entry.NextUpdate
+	switch {
+	case a.IsZero():
+		return false
+	case b.IsZero():
+		return true
+	default:
+		return a.Before(b)
+	}
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	it := x.(*cacheItem)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
@@ -0,0 +1,282 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testCacheKey(i int) CertIDKey {
+	return certIDKey(crypto.SHA1, []byte("name"), []byte("key"), big.NewInt(int64(i)))
+}
+
+func TestCertIDKey(t *testing.T) {
+	resp := &Response{
+		IssuerHash:     crypto.SHA1,
+		IssuerNameHash: []byte("name"),
+		IssuerKeyHash:  []byte("key"),
+		SerialNumber:   big.NewInt(1),
+	}
+	if resp.Key() != testCacheKey(1) {
+		t.Error("Key: got different keys for the same CertID")
+	}
+	req := &Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: []byte("name"),
+		IssuerKeyHash:  []byte("key"),
+		SerialNumber:   big.NewInt(1),
+		Extensions:     []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}, Value: []byte{4, 1, 0}}},
+	}
+	if req.Key() != resp.Key() || req.CertID().Key() != resp.Key() {
+		t.Error("Key: got different keys for a request and its response")
+	}
+
+	keys := map[CertIDKey]string{}
+	for name, k := range map[string]CertIDKey{
+		"base":          testCacheKey(1),
+		"serial":        testCacheKey(2),
+		"negative":      testCacheKey(-1),
+		"hash":          certIDKey(crypto.SHA256, []byte("name"), []byte("key"), big.NewInt(1)),
+		"shifted":       certIDKey(crypto.SHA1, []byte("namek"), []byte("ey"), big.NewInt(1)),
+		"nil serial":    certIDKey(crypto.SHA1, []byte("name"), []byte("key"), nil),
+		"zero serial":   certIDKey(crypto.SHA1, []byte("name"), []byte("key"), big.NewInt(0)),
+		"swapped names": certIDKey(crypto.SHA1, []byte("key"), []byte("name"), big.NewInt(1)),
+	} {
+		if other, ok := keys[k]; ok {
+			t.Errorf("certIDKey: %s and %s have the same key", name, other)
+		}
+		keys[k] = name
+	}
+}
+
+func TestShardedCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	entry := func(size int, nextUpdate time.Time) CacheEntry {
+		return CacheEntry{Response: make([]byte, size), ThisUpdate: now, NextUpdate: nextUpdate}
+	}
+
+	// A single shard with room for three entries of 100 bytes.
+	c := NewShardedCache(3*(100+cacheEntryOverhead), 1)
+	c.Put(ctx, testCacheKey(1), entry(100, now.Add(3*time.Hour)))
+	c.Put(ctx, testCacheKey(2), entry(100, now.Add(1*time.Hour)))
+	c.Put(ctx, testCacheKey(3), entry(100, time.Time{}))
+	if c.Len() != 3 || c.Size() != 3*(100+cacheEntryOverhead) {
+		t.Fatalf("got %d entries and %d bytes", c.Len(), c.Size())
+	}
+	if e, err := c.Get(ctx, testCacheKey(1)); err != nil || len(e.Response) != 100 || !e.NextUpdate.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("Get: got %v, %v", e, err)
+	}
+
+	// The entry closest to expiry is evicted first, and entries without
+	// NextUpdate last.
+	c.Put(ctx, testCacheKey(4), entry(100, now.Add(2*time.Hour)))
+	if _, err := c.Get(ctx, testCacheKey(2)); !errors.Is(err, ErrCacheMiss) {
+		t.Error("Get: entry closest to expiry was not evicted")
+	}
+	c.Put(ctx, testCacheKey(5), entry(100, now.Add(4*time.Hour)))
+	if _, err := c.Get(ctx, testCacheKey(4)); !errors.Is(err, ErrCacheMiss) {
+		t.Error("Get: entry closest to expiry was not evicted")
+	}
+	c.Put(ctx, testCacheKey(6), entry(100, now.Add(5*time.Hour)))
+	if _, err := c.Get(ctx, testCacheKey(1)); !errors.Is(err, ErrCacheMiss) {
+		t.Error("Get: entry closest to expiry was not evicted")
+	}
+	if _, err := c.Get(ctx, testCacheKey(3)); err != nil {
+		t.Error("Get: entry without NextUpdate was evicted")
+	}
+
+	// Entries that are not fresher do not replace the stored one.
+	c.Put(ctx, testCacheKey(3), entry(50, now.Add(time.Hour)))
+	if e, err := c.Get(ctx, testCacheKey(3)); err != nil || len(e.Response) != 100 {
+		t.Errorf("Get: got %v, %v after replaying an older entry", e, err)
+	}
+
+	// Replacing an entry updates the accounting.
+	fresher := func(size int, thisUpdate time.Duration) CacheEntry {
+		e := entry(size, now.Add(time.Hour))
+		e.ThisUpdate = now.Add(thisUpdate)
+		return e
+	}
+	c.Put(ctx, testCacheKey(3), fresher(50, time.Minute))
+	if c.Len() != 3 || c.Size() != 250+3*cacheEntryOverhead {
+		t.Errorf("got %d entries and %d bytes", c.Len(), c.Size())
+	}
+
+	// Entries larger than the budget are not stored, and replace the
+	// previous one.
+	c.Put(ctx, testCacheKey(3), fresher(1000, 2*time.Minute))
+	if _, err := c.Get(ctx, testCacheKey(3)); !errors.Is(err, ErrCacheMiss) {
+		t.Error("Get: got entry larger than the budget")
+	}
+
+	c.Delete(ctx, testCacheKey(5))
+	c.Delete(ctx, testCacheKey(6))
+	c.Delete(ctx, testCacheKey(7))
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Errorf("got %d entries and %d bytes after deleting all entries", c.Len(), c.Size())
+	}
+
+	// Expired entries are not returned.
+	c.Put(ctx, testCacheKey(8), entry(100, now.Add(-time.Second)))
+	if _, err := c.Get(ctx, testCacheKey(8)); !errors.Is(err, ErrCacheMiss) {
+		t.Error("Get: got expired entry")
+	}
+
+	// A small budget is not split into shards too small for any entry.
+	c = NewShardedCache(minShardBytes, 64)
+	if len(c.shards) != 1 {
+		t.Errorf("got %d shards, want 1", len(c.shards))
+	}
+	c.Put(ctx, testCacheKey(1), entry(1000, now.Add(time.Hour)))
+	if _, err := c.Get(ctx, testCacheKey(1)); err != nil {
+		t.Errorf("Get with a small budget: %v", err)
+	}
+}
+
+func TestShardedCacheConcurrency(t *testing.T) {
+	ctx := context.Background()
+	c := NewShardedCache(1<<20, 0)
+	nextUpdate := time.Now().Add(time.Hour)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := testCacheKey(g*1000 + i)
+				c.Put(ctx, key, CacheEntry{Response: make([]byte, 100), NextUpdate: nextUpdate.Add(time.Duration(i) * time.Second)})
+				c.Get(ctx, key)
+				if i%3 == 0 {
+					c.Delete(ctx, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if c.Size() > 1<<20 {
+		t.Errorf("Size: got %d, want at most %d", c.Size(), 1<<20)
+	}
+}
+
+// mapCache is the plain map and mutex cache ShardedCache is compared with.
+type mapCache struct {
+	mu    sync.RWMutex
+	items map[CertIDKey]CacheEntry
+}
+
+func (c *mapCache) Get(_ context.Context, key CertIDKey) (CacheEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e, ok := c.items[key]; ok {
+		return e, nil
+	}
+	return CacheEntry{}, ErrCacheMiss
+}
+
+func (c *mapCache) Put(_ context.Context, key CertIDKey, entry CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = entry
+	return nil
+}
+
+func (c *mapCache) Delete(_ context.Context, key CertIDKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func benchmarkCache(b *testing.B, c Cache) {
+	ctx := context.Background()
+	const n = 1 << 14
+	keys := make([]CertIDKey, n)
+	entry := CacheEntry{Response: make([]byte, 500), NextUpdate: time.Now().Add(time.Hour)}
+	for i := range keys {
+		keys[i] = testCacheKey(i)
+		c.Put(ctx, keys[i], entry)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			key := keys[i%n]
+			if i%10 == 0 {
+				c.Put(ctx, key, entry)
+			} else {
+				c.Get(ctx, key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkCache(b *testing.B) {
+	b.Run("Sharded", func(b *testing.B) {
+		benchmarkCache(b, NewShardedCache(1<<30, 0))
+	})
+	b.Run("Map", func(b *testing.B) {
+		benchmarkCache(b, &mapCache{items: make(map[CertIDKey]CacheEntry)})
+	})
+	for _, shards := range []int{1, 16, 256} {
+		b.Run("Shards"+strconv.Itoa(shards), func(b *testing.B) {
+			benchmarkCache(b, NewShardedCache(1<<30, shards))
+		})
+	}
+}
+
+func TestNormalizeRequest(t *testing.T) {
+	base := &Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: make([]byte, 20),
+		IssuerKeyHash:  make([]byte, 20),
+		SerialNumber:   big.NewInt(42),
+	}
+	want, err := base.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withNonce := *base
+	withNonce.Nonce = []byte("0123456789abcdef")
+	withNonce.RequestorName = &pkix.Name{CommonName: "requestor"}
+	withNonceDER, err := withNonce.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SHA-1 without the NULL parameters.
+	withoutParams, err := asn1.Marshal(ocspRequest{tbsRequest{
+		RequestList: []request{{Cert: certID{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1},
+			NameHash:      base.IssuerNameHash,
+			IssuerKeyHash: base.IssuerKeyHash,
+			SerialNumber:  base.SerialNumber,
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, der := range [][]byte{want, withNonceDER, withoutParams} {
+		got, key, err := NormalizeRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) || key != base.Key() {
+			t.Errorf("NormalizeRequest(%x): got %x, want %x", der, got, want)
+		}
+	}
+
+	if _, _, err := NormalizeRequest([]byte{0x30, 0x00}); err == nil {
+		t.Error("NormalizeRequest: expected error for a malformed request")
+	}
+}
@@ -0,0 +1,125 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// ErrNotCanonical is matched by the errors returned by VerifyCanonicalDER.
+var ErrNotCanonical = errors.New("ocsp: not canonical DER")
+
+// The canonical* types mirror the response structures without the raw fields,
+// so re-encoding them does not copy the parsed bytes. The revocation reason is
+// kept raw, as a present unspecified reason would be omitted when encoding an
+// Enumerated.
+type canonicalCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type canonicalRevokedInfo struct {
+	RevocationTime time.Time     `asn1:"generalized"`
+	Reason         asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type canonicalSingleResponse struct {
+	CertID           canonicalCertID
+	Good             asn1.Flag            `asn1:"tag:0,optional"`
+	Revoked          canonicalRevokedInfo `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag            `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time            `asn1:"generalized"`
+	NextUpdate       time.Time            `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension     `asn1:"explicit,tag:1,optional"`
+}
+
+type canonicalResponseData struct {
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []canonicalSingleResponse
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// checkCanonical decodes der into the value pointed to by v, encodes it again, and checks that the
+// result is identical to der.
+func checkCanonical(name string, der []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return fmt.Errorf("%w: invalid %s: %v", ErrNotCanonical, name, err)
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("%w: trailing data after %s", ErrNotCanonical, name)
+	}
+	encoded, err := asn1.Marshal(reflect.ValueOf(v).Elem().Interface())
+	if err != nil {
+		return fmt.Errorf("%w: cannot encode %s: %v", ErrNotCanonical, name, err)
+	}
+	if !bytes.Equal(encoded, der) {
+		return fmt.Errorf("%w: %s differs from its DER encoding", ErrNotCanonical, name)
+	}
+	return nil
+}
+
+func checkCanonicalTime(name string, t time.Time) error {
+	if _, offset := t.Zone(); offset != 0 {
+		return fmt.Errorf("%w: %s is not in UTC", ErrNotCanonical, name)
+	}
+	return nil
+}
+
+// VerifyCanonicalDER checks that the OCSP response der is encoded with the
+// Distinguished Encoding Rules, by decoding its structures, encoding them
+// again, and comparing the result with the original bytes. It detects, for
+// example, fields explicitly encoded with their default value, and times with
+// fractional seconds or not in UTC. The embedded certificates, the responder
+// ID, the extension values and the revocation reason are not re-encoded.
+//
+// The returned errors match ErrNotCanonical.
+func VerifyCanonicalDER(der []byte) error {
+	var resp responseASN1
+	if err := checkCanonical("OCSPResponse", der, &resp); err != nil {
+		return err
+	}
+	if ResponseStatus(resp.Status) != Success || !resp.Response.ResponseType.Equal(idPKIXOCSPBasic) {
+		return nil
+	}
+
+	var basicResp rawBasicResponse
+	if err := checkCanonical("BasicOCSPResponse", resp.Response.Response, &basicResp); err != nil {
+		return err
+	}
+	var tbs canonicalResponseData
+	if err := checkCanonical("ResponseData", basicResp.TBSResponseData.FullBytes, &tbs); err != nil {
+		return err
+	}
+
+	if err := checkCanonicalTime("producedAt", tbs.ProducedAt); err != nil {
+		return err
+	}
+	for i, r := range tbs.Responses {
+		if err := checkCanonicalTime(fmt.Sprintf("thisUpdate of response %d", i), r.ThisUpdate); err != nil {
+			return err
+		}
+		if err := checkCanonicalTime(fmt.Sprintf("nextUpdate of response %d", i), r.NextUpdate); err != nil {
+			return err
+		}
+		if err := checkCanonicalTime(fmt.Sprintf("revocationTime of response %d", i), r.Revoked.RevocationTime); err != nil {
+			return err
+		}
+		if len(r.Revoked.Reason.FullBytes) > 0 {
+			var reason asn1.Enumerated
+			if err := checkCanonical(fmt.Sprintf("revocationReason of response %d", i), r.Revoked.Reason.Bytes, &reason); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testSingleResponse and testResponseData allow encoding non-canonical
+// responses, with raw times and versions.
+type testSingleResponse struct {
+	CertID     certID
+	Good       asn1.Flag `asn1:"tag:0"`
+	ThisUpdate asn1.RawValue
+	Extensions []testExtension `asn1:"explicit,tag:1,optional"`
+}
+
+type testExtension struct {
+	ID       asn1.ObjectIdentifier
+	Critical bool
+	Value    []byte
+}
+
+type testResponseData struct {
+	Version        asn1.RawValue `asn1:"optional"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []testSingleResponse
+}
+
+func newTestCanonicalResponse(t *testing.T, modify func(*testResponseData)) []byte {
+	t.Helper()
+	tbs := testResponseData{
+		RawResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: []byte{4, 1, 0}},
+		ProducedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Responses: []testSingleResponse{{
+			CertID: certID{
+				HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: getOIDFromHashAlgorithm(crypto.SHA1), Parameters: asn1.NullRawValue},
+				NameHash:      make([]byte, 20),
+				IssuerKeyHash: make([]byte, 20),
+				SerialNumber:  big.NewInt(1),
+			},
+			Good:       true,
+			ThisUpdate: asn1.RawValue{Tag: asn1.TagGeneralizedTime, Bytes: []byte("20240101000000Z")},
+		}},
+	}
+	modify(&tbs)
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basicDER, err := asn1.Marshal(rawBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbsDER},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: []byte{1, 2, 3}, BitLength: 24},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(responseASN1{
+		Status:   asn1.Enumerated(Success),
+		Response: responseBytes{ResponseType: idPKIXOCSPBasic, Response: basicDER},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestVerifyCanonicalDER(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	now := time.Now().Truncate(time.Second)
+	created := map[string]Response{
+		"good": {Status: Good, SerialNumber: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour), Certificate: responder},
+		"revoked": {Status: Revoked, SerialNumber: big.NewInt(2), ThisUpdate: now, RevokedAt: now, RevocationReason: KeyCompromise,
+			ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 3}, Value: []byte{5, 0}}}},
+		"unspecified reason": {Status: Revoked, SerialNumber: big.NewInt(3), ThisUpdate: now, RevokedAt: now},
+	}
+	for name, template := range created {
+		der, err := CreateResponse(responder, responder, template, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyCanonicalDER(der); err != nil {
+			t.Errorf("VerifyCanonicalDER with a %s response: %v", name, err)
+		}
+	}
+
+	for name, h := range map[string]string{
+		"ocspResponseHex":            ocspResponseHex,
+		"ocspResponseWithoutCertHex": ocspResponseWithoutCertHex,
+		"errorResponseHex":           errorResponseHex,
+		"UnauthorizedErrorResponse":  hex.EncodeToString(UnauthorizedErrorResponse),
+	} {
+		der, _ := hex.DecodeString(h)
+		if err := VerifyCanonicalDER(der); err != nil {
+			t.Errorf("VerifyCanonicalDER with %s: %v", name, err)
+		}
+	}
+
+	// These fixtures encode the default version explicitly.
+	for name, h := range map[string]string{
+		"ocspResponseWithExtensionHex":         ocspResponseWithExtensionHex,
+		"ocspResponseWithCriticalExtensionHex": ocspResponseWithCriticalExtensionHex,
+	} {
+		der, _ := hex.DecodeString(h)
+		if err := VerifyCanonicalDER(der); !errors.Is(err, ErrNotCanonical) {
+			t.Errorf("VerifyCanonicalDER with %s: got %v, want ErrNotCanonical", name, err)
+		}
+	}
+
+	if err := VerifyCanonicalDER(newTestCanonicalResponse(t, func(*testResponseData) {})); err != nil {
+		t.Errorf("VerifyCanonicalDER: %v", err)
+	}
+
+	explicitVersion, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{2, 1, 0}})
+	tests := map[string]func(*testResponseData){
+		"explicit default version": func(tbs *testResponseData) {
+			tbs.Version = asn1.RawValue{FullBytes: explicitVersion}
+		},
+		"explicit non-critical extension": func(tbs *testResponseData) {
+			tbs.Responses[0].Extensions = []testExtension{{ID: asn1.ObjectIdentifier{1, 2, 3}, Value: []byte{5, 0}}}
+		},
+		"fractional seconds": func(tbs *testResponseData) {
+			tbs.Responses[0].ThisUpdate.Bytes = []byte("20240101000000.5Z")
+		},
+		"time offset": func(tbs *testResponseData) {
+			tbs.Responses[0].ThisUpdate.Bytes = []byte("20240101010000+0100")
+		},
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			der := newTestCanonicalResponse(t, modify)
+			if _, err := ParseResponse(der, nil); err != nil {
+				t.Fatalf("ParseResponse: %v", err)
+			}
+			if err := VerifyCanonicalDER(der); !errors.Is(err, ErrNotCanonical) {
+				t.Errorf("VerifyCanonicalDER: got %v, want ErrNotCanonical", err)
+			}
+		})
+	}
+
+	der, _ := hex.DecodeString(ocspResponseHex)
+	if err := VerifyCanonicalDER(append(der, 0)); !errors.Is(err, ErrNotCanonical) {
+		t.Errorf("VerifyCanonicalDER with trailing data: got %v, want ErrNotCanonical", err)
+	}
+}
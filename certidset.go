@@ -0,0 +1,189 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"math/big"
+)
+
+// CertIDSet is a set of certificates, identified by the key of their issuer
+// and their serial number, with constant-time lookup of CertIDs using any of
+// the supported hash algorithms. It can be used by responders to match
+// incoming requests against a large inventory of certificates.
+// ParseResponseForCert also uses one to prefer the statuses of the issuer.
+//
+// The issuer name hash is not used for matching, as the issuer key hash
+// already identifies the issuer.
+//
+// A CertIDSet is not safe for concurrent use if it is being modified.
+type CertIDSet struct {
+	issuers map[certIDSetIssuer]int
+	serials map[certIDSetSerial]struct{}
+	next    int
+}
+
+// certIDSetIssuer identifies an issuer by the hash of its key with a given
+// hash algorithm.
+type certIDSetIssuer struct {
+	hash    crypto.Hash
+	keyHash string
+}
+
+// certIDSetSerial identifies a certificate by the index of its issuer and its
+// serial number.
+type certIDSetSerial struct {
+	issuer int
+	serial string
+}
+
+// NewCertIDSet returns an empty CertIDSet.
+func NewCertIDSet() *CertIDSet {
+	return &CertIDSet{
+		issuers: make(map[certIDSetIssuer]int),
+		serials: make(map[certIDSetSerial]struct{}),
+	}
+}
+
+// Add adds the certificate of issuer with the given serial number to the set.
+// It matches CertIDs using any hash algorithm supported by this package.
+func (s *CertIDSet) Add(issuer *x509.Certificate, serial *big.Int) error {
+	hashOIDsMu.RLock()
+	hashes := make([]crypto.Hash, 0, len(hashOIDs))
+	for hash := range hashOIDs {
+		hashes = append(hashes, hash)
+	}
+	hashOIDsMu.RUnlock()
+
+	idx := -1
+	keyHashes := make([]certIDSetIssuer, 0, len(hashes))
+	for _, hash := range hashes {
+		if _, ok := newHash(hash); !ok {
+			continue
+		}
+		keyHash, err := publicKeyHash(issuer, hash)
+		if err != nil {
+			return err
+		}
+		k := certIDSetIssuer{hash: hash, keyHash: string(keyHash)}
+		if i, ok := s.issuers[k]; ok {
+			if idx >= 0 && i != idx {
+				// The issuer was added with AddCertID using different
+				// hash algorithms.
+				s.mergeIssuer(i, idx)
+			} else {
+				idx = i
+			}
+		}
+		keyHashes = append(keyHashes, k)
+	}
+	if idx < 0 {
+		idx = s.newIssuer()
+	}
+	for _, k := range keyHashes {
+		s.issuers[k] = idx
+	}
+	s.serials[certIDSetSerial{issuer: idx, serial: serialKey(serial)}] = struct{}{}
+	return nil
+}
+
+// AddCertID adds the certificate identified by id to the set. Unlike Add, it
+// only matches CertIDs using the hash algorithm of id, unless the issuer has
+// also been added with Add.
+func (s *CertIDSet) AddCertID(id *CertID) {
+	k := certIDSetIssuer{hash: id.HashAlgorithm, keyHash: string(id.IssuerKeyHash)}
+	idx, ok := s.issuers[k]
+	if !ok {
+		idx = s.newIssuer()
+		s.issuers[k] = idx
+	}
+	s.serials[certIDSetSerial{issuer: idx, serial: serialKey(id.SerialNumber)}] = struct{}{}
+}
+
+// Contains reports whether the certificate identified by id is in the set.
+func (s *CertIDSet) Contains(id *CertID) bool {
+	return s.contains(id.HashAlgorithm, id.IssuerKeyHash, id.SerialNumber)
+}
+
+// Len returns the number of certificates in the set.
+func (s *CertIDSet) Len() int {
+	return len(s.serials)
+}
+
+// addIssuerHash adds the key hash of issuer with the given hash algorithm to
+// the issuer with index idx. It returns false if the hash algorithm is not
+// available.
+func (s *CertIDSet) addIssuerHash(idx int, issuer *x509.Certificate, hash crypto.Hash) bool {
+	if _, ok := newHash(hash); !ok {
+		return false
+	}
+	keyHash, err := publicKeyHash(issuer, hash)
+	if err != nil {
+		return false
+	}
+	s.issuers[certIDSetIssuer{hash: hash, keyHash: string(keyHash)}] = idx
+	return true
+}
+
+// issuerKeyMatcher returns a function reporting whether a CertID is for the
+// certificate of issuer with the given serial number. It is used by
+// ParseResponseForCert to prefer the statuses of the right issuer. Unlike
+// Add, the key hash of issuer is only computed for the hash algorithms of
+// the CertIDs being matched, and CertIDs using an unavailable hash algorithm
+// are matched on the serial number alone.
+func issuerKeyMatcher(issuer *x509.Certificate, serial *big.Int) func(*certID) bool {
+	s := NewCertIDSet()
+	idx := s.newIssuer()
+	s.serials[certIDSetSerial{issuer: idx, serial: serialKey(serial)}] = struct{}{}
+	available := make(map[crypto.Hash]bool)
+	return func(id *certID) bool {
+		hash := getHashAlgorithmFromOID(id.HashAlgorithm.Algorithm)
+		ok, seen := available[hash]
+		if !seen {
+			ok = s.addIssuerHash(idx, issuer, hash)
+			available[hash] = ok
+		}
+		if !ok {
+			return serial.Cmp(id.SerialNumber) == 0
+		}
+		return s.contains(hash, id.IssuerKeyHash, id.SerialNumber)
+	}
+}
+
+func (s *CertIDSet) contains(hash crypto.Hash, keyHash []byte, serial *big.Int) bool {
+	idx, ok := s.issuers[certIDSetIssuer{hash: hash, keyHash: string(keyHash)}]
+	if !ok {
+		return false
+	}
+	_, ok = s.serials[certIDSetSerial{issuer: idx, serial: serialKey(serial)}]
+	return ok
+}
+
+// mergeIssuer moves the certificates of the issuer with index from to the
+// issuer with index to.
+func (s *CertIDSet) mergeIssuer(from, to int) {
+	for k, i := range s.issuers {
+		if i == from {
+			s.issuers[k] = to
+		}
+	}
+	for k := range s.serials {
+		if k.issuer == from {
+			delete(s.serials, k)
+			s.serials[certIDSetSerial{issuer: to, serial: k.serial}] = struct{}{}
+		}
+	}
+}
+
+func (s *CertIDSet) newIssuer() int {
+	idx := s.next
+	s.next++
+	return idx
+}
+
+// serialKey returns a map key for serial, including its sign.
+func serialKey(serial *big.Int) string {
+	if serial == nil {
+		return ""
+	}
+	return string(append([]byte{byte(serial.Sign() + 1)}, serial.Bytes()...))
+}
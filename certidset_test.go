@@ -0,0 +1,111 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testCertID(t *testing.T, issuer *x509.Certificate, hash crypto.Hash, serial int64) *CertID {
+	t.Helper()
+	keyHash, err := publicKeyHash(issuer, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := newHash(hash)
+	h.Write(issuer.RawSubject)
+	return &CertID{
+		HashAlgorithm:  hash,
+		IssuerNameHash: h.Sum(nil),
+		IssuerKeyHash:  keyHash,
+		SerialNumber:   big.NewInt(serial),
+	}
+}
+
+func TestCertIDSet(t *testing.T) {
+	issuer, _ := newTestResponder(t, "issuer")
+	other, _ := newTestResponder(t, "other")
+
+	s := NewCertIDSet()
+	for i := int64(1); i <= 3; i++ {
+		if err := s.Add(issuer, big.NewInt(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.AddCertID(testCertID(t, other, crypto.SHA256, 1))
+	if s.Len() != 4 {
+		t.Errorf("Len: got %d, want 4", s.Len())
+	}
+
+	for _, test := range []struct {
+		issuer *x509.Certificate
+		hash   crypto.Hash
+		serial int64
+		want   bool
+	}{
+		{issuer, crypto.SHA1, 1, true},
+		{issuer, crypto.SHA256, 2, true},
+		{issuer, crypto.SHA3_512, 3, true},
+		{issuer, crypto.SHA1, 4, false},
+		{other, crypto.SHA256, 1, true},
+		{other, crypto.SHA1, 1, false},
+		{other, crypto.SHA256, 2, false},
+	} {
+		id := testCertID(t, test.issuer, test.hash, test.serial)
+		if got := s.Contains(id); got != test.want {
+			t.Errorf("Contains(%s, %v, %d): got %v, want %v", test.issuer.Subject.CommonName, test.hash, test.serial, got, test.want)
+		}
+	}
+
+	// Adding the issuer merges the CertIDs added with other hashes.
+	s.AddCertID(testCertID(t, other, crypto.SHA1, 2))
+	if err := s.Add(other, big.NewInt(3)); err != nil {
+		t.Fatal(err)
+	}
+	for _, serial := range []int64{1, 2, 3} {
+		if id := testCertID(t, other, crypto.SHA384, serial); !s.Contains(id) {
+			t.Errorf("Contains(other, SHA-384, %d): got false, want true", serial)
+		}
+	}
+	if s.Len() != 6 {
+		t.Errorf("Len: got %d, want 6", s.Len())
+	}
+}
+
+func TestParseResponseForCertIssuer(t *testing.T) {
+	issuer, key := newTestResponder(t, "issuer")
+	responderID, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: issuer.RawSubject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	otherID := *testCertID(t, issuer, crypto.SHA256, 1)
+	otherID.IssuerKeyHash = bytes.Repeat([]byte{1}, 32)
+	b := &BasicResponse{
+		RawResponderID: responderID,
+		ProducedAt:     now,
+		Responses: []SingleResponse{
+			{CertID: otherID, Status: Revoked, ThisUpdate: now, RevokedAt: now},
+			{CertID: *testCertID(t, issuer, crypto.SHA256, 1), Status: Good, ThisUpdate: now},
+		},
+	}
+	if err := b.Sign(key, 0); err != nil {
+		t.Fatal(err)
+	}
+	der, err := b.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ParseResponseForCert(der, &x509.Certificate{SerialNumber: big.NewInt(1)}, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != Good {
+		t.Errorf("Status: got %d, want %d", resp.Status, Good)
+	}
+}
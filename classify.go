@@ -0,0 +1,168 @@
+package ocsp
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ErrStaleResponse is matched by the errors returned by CheckValidAt for
+// responses outside their validity interval.
+var ErrStaleResponse = errors.New("ocsp: stale response")
+
+// ErrInvalidSignature is matched by the errors returned when a signature of a
+// response or of an embedded certificate does not verify, like the ones
+// returned by Response.CheckSignatureFrom and Response.CheckSignatureFromKey.
+// Unsupported or disallowed signature algorithms are not matched.
+var ErrInvalidSignature = errors.New("ocsp: invalid signature")
+
+// signatureError is a signature verification error matching
+// ErrInvalidSignature, with the message of the underlying error.
+type signatureError struct {
+	err error
+}
+
+func (e signatureError) Error() string {
+	return e.err.Error()
+}
+
+func (e signatureError) Unwrap() []error {
+	return []error{ErrInvalidSignature, e.err}
+}
+
+// verificationError returns err, returned verifying a signature, wrapped to
+// match ErrInvalidSignature unless it reports an unsupported or disallowed
+// algorithm.
+func verificationError(err error) error {
+	var insecureErr x509.InsecureAlgorithmError
+	if err == nil || errors.Is(err, x509.ErrUnsupportedAlgorithm) ||
+		errors.Is(err, ErrNotFIPSApproved) || errors.As(err, &insecureErr) {
+		return err
+	}
+	return signatureError{err: err}
+}
+
+// parseSignatureError is returned when the signature checks done while
+// parsing a response fail. It matches ParseError with errors.As, as the
+// errors returned before it were ParseErrors, and it wraps the error of the
+// check, which matches ErrInvalidSignature if a signature is invalid.
+type parseSignatureError struct {
+	msg string
+	err error
+}
+
+func (e *parseSignatureError) Error() string {
+	return e.msg + e.err.Error()
+}
+
+func (e *parseSignatureError) Unwrap() error {
+	return e.err
+}
+
+// As sets a *ParseError target to the message of the error.
+func (e *parseSignatureError) As(target any) bool {
+	if p, ok := target.(*ParseError); ok {
+		*p = ParseError(e.Error())
+		return true
+	}
+	return false
+}
+
+// CheckValidAt returns an error matching ErrStaleResponse if t is not within
+// the validity interval of the response, as reported by ValidAt.
+func (resp *Response) CheckValidAt(t time.Time) error {
+	switch {
+	case t.Before(resp.ThisUpdate):
+		return fmt.Errorf("%w: ThisUpdate %v is in the future", ErrStaleResponse, resp.ThisUpdate)
+	case resp.Expired(t):
+		return fmt.Errorf("%w: NextUpdate %v has passed", ErrStaleResponse, resp.NextUpdate)
+	default:
+		return nil
+	}
+}
+
+// ErrorClass is the class of an error, as returned by ClassifyError. It can be
+// used to bucket failures in metrics and alerts.
+type ErrorClass int
+
+const (
+	// ClassNone is the class of a nil error.
+	ClassNone ErrorClass = iota
+	// ClassNetwork is the class of errors sending a request or reading a
+	// response, including timeouts.
+	ClassNetwork
+	// ClassResponder is the class of the ResponseErrors returned for error
+	// responses, like tryLater. The status is available with errors.As.
+	ClassResponder
+	// ClassParse is the class of malformed input, including input exceeding
+	// the Limits.
+	ClassParse
+	// ClassSignature is the class of invalid signatures of the response or
+	// of the embedded certificates.
+	ClassSignature
+	// ClassStale is the class of responses outside their validity interval.
+	ClassStale
+	// ClassPolicy is the class of responses rejected by the
+	// ValidationOptions or by FIPS mode.
+	ClassPolicy
+	// ClassOther is the class of all the other errors.
+	ClassOther
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassNone:
+		return "none"
+	case ClassNetwork:
+		return "network"
+	case ClassResponder:
+		return "responder"
+	case ClassParse:
+		return "parse"
+	case ClassSignature:
+		return "signature"
+	case ClassStale:
+		return "stale"
+	case ClassPolicy:
+		return "policy"
+	case ClassOther:
+		return "other"
+	default:
+		return "unknown error class " + strconv.Itoa(int(c))
+	}
+}
+
+// ClassifyError returns the class of err, looking at the errors it wraps.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ClassNone
+	}
+
+	var respErr ResponseError
+	var parseErr ParseError
+	var netErr net.Error
+	var structuralErr asn1.StructuralError
+	var syntaxErr asn1.SyntaxError
+	switch {
+	case errors.As(err, &respErr):
+		return ClassResponder
+	case errors.Is(err, ErrStaleResponse):
+		return ClassStale
+	case errors.Is(err, ErrNotAllowed), errors.Is(err, ErrNotFIPSApproved):
+		return ClassPolicy
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, rsa.ErrVerification):
+		return ClassSignature
+	case errors.As(err, &parseErr), errors.Is(err, ErrLimitExceeded), errors.Is(err, ErrNotCanonical),
+		errors.As(err, &structuralErr), errors.As(err, &syntaxErr):
+		return ClassParse
+	case errors.As(err, &netErr):
+		return ClassNetwork
+	default:
+		return ClassOther
+	}
+}
@@ -0,0 +1,85 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	errorResp, _ := hex.DecodeString(errorResponseHex)
+	_, responderErr := ParseResponse(errorResp, nil)
+
+	der, _ := hex.DecodeString(ocspResponseHex)
+	other, _ := newTestResponder(t, "other")
+	_, signatureErr := ParseResponse(der, other)
+
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckValidAt(resp.ThisUpdate); err != nil {
+		t.Errorf("CheckValidAt: %v", err)
+	}
+	staleErr := resp.CheckValidAt(resp.NextUpdate)
+	notYetValidErr := resp.CheckValidAt(resp.ThisUpdate.Add(-time.Second))
+
+	// ECDSA verification failures from crypto/x509 do not wrap any error.
+	responder, key := newTestResponder(t, "responder")
+	ecdsaDER, err := CreateResponse(nil, responder, Response{
+		Status:         Good,
+		SerialNumber:   big.NewInt(1),
+		IssuerHash:     crypto.SHA1,
+		IssuerNameHash: make([]byte, 20),
+		IssuerKeyHash:  make([]byte, 20),
+		ThisUpdate:     time.Now(),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaResp, err := ParseResponse(ecdsaDER, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKeyErr := ecdsaResp.CheckSignatureFromKey(other.PublicKey)
+	ecdsaCertErr := ecdsaResp.CheckSignatureFrom(other)
+	if !errors.Is(ecdsaKeyErr, ErrInvalidSignature) || !errors.Is(ecdsaCertErr, ErrInvalidSignature) {
+		t.Errorf("got %v and %v, want ErrInvalidSignature", ecdsaKeyErr, ecdsaCertErr)
+	}
+
+	_, asn1Err := ParseResponse([]byte{0x30, 0x01}, nil)
+	_, limitErr := ParseResponseWithOptions(der, nil, nil, &ParseOptions{Limits: Limits{MaxSize: 1}})
+
+	for _, test := range []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ClassNone},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ClassNetwork},
+		{fmt.Errorf("fetching: %w", context.DeadlineExceeded), ClassNetwork},
+		{responderErr, ClassResponder},
+		{fmt.Errorf("checking: %w", responderErr), ClassResponder},
+		{ParseError("bad OCSP response type"), ClassParse},
+		{asn1Err, ClassParse},
+		{limitErr, ClassParse},
+		{signatureErr, ClassSignature},
+		{&parseSignatureError{msg: "bad OCSP signature: ", err: fmt.Errorf("%w: algorithm", errors.ErrUnsupported)}, ClassParse},
+		{ecdsaKeyErr, ClassSignature},
+		{fmt.Errorf("checking: %w", ecdsaCertErr), ClassSignature},
+		{staleErr, ClassStale},
+		{notYetValidErr, ClassStale},
+		{fmt.Errorf("%w: signature algorithm", ErrNotAllowed), ClassPolicy},
+		{fmt.Errorf("%w: signature algorithm", ErrNotFIPSApproved), ClassPolicy},
+		{errors.New("something else"), ClassOther},
+	} {
+		if got := ClassifyError(test.err); got != test.want {
+			t.Errorf("ClassifyError(%v): got %v, want %v", test.err, got, test.want)
+		}
+	}
+}
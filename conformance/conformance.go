@@ -0,0 +1,196 @@
+// Package conformance provides a corpus of OCSP responses with their expected
+// parsed values, and a helper to check that a parser handles all of them like
+// this module does. It can be used by forks and alternative implementations
+// of the parser to prove their compatibility.
+//
+// The corpus does not include captures from every major responder yet. The
+// missing ones are listed in corpus/README.md.
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+//go:embed corpus
+var corpus embed.FS
+
+// Expected contains the expected result of parsing a Vector.
+type Expected struct {
+	// Description describes the response and where it comes from.
+	Description string `json:"description"`
+	// Error is true if parsing the response must fail.
+	Error bool `json:"error,omitempty"`
+	// Status is "good", "revoked" or "unknown".
+	Status string `json:"status,omitempty"`
+	// SerialNumber is the hex-encoded serial number.
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// ProducedAt, ThisUpdate, NextUpdate and RevokedAt are the times of the
+	// response. They are omitted from the JSON file if they are not set.
+	ProducedAt       time.Time `json:"producedAt"`
+	ThisUpdate       time.Time `json:"thisUpdate"`
+	NextUpdate       time.Time `json:"nextUpdate"`
+	RevokedAt        time.Time `json:"revokedAt"`
+	RevocationReason int       `json:"revocationReason,omitempty"`
+}
+
+// Vector is a DER-encoded OCSP response of the corpus.
+type Vector struct {
+	Name     string
+	DER      []byte
+	Expected Expected
+}
+
+// Vectors returns the vectors of the corpus sorted by name. Each vector is
+// stored in the corpus as a name.der file with the response and a name.json
+// file with the expected values.
+func Vectors() ([]Vector, error) {
+	entries, err := corpus.ReadDir("corpus")
+	if err != nil {
+		return nil, err
+	}
+	var vectors []Vector
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".der")
+		if !ok {
+			continue
+		}
+		der, err := corpus.ReadFile(path.Join("corpus", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		data, err := corpus.ReadFile(path.Join("corpus", name+".json"))
+		if err != nil {
+			return nil, err
+		}
+		v := Vector{Name: name, DER: der}
+		if err := json.Unmarshal(data, &v.Expected); err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, v)
+	}
+	sort.Slice(vectors, func(i, j int) bool {
+		return vectors[i].Name < vectors[j].Name
+	})
+	return vectors, nil
+}
+
+// Result is the result of parsing a response that is compared with the
+// expected values.
+type Result struct {
+	Status           int
+	SerialNumber     *big.Int
+	ProducedAt       time.Time
+	ThisUpdate       time.Time
+	NextUpdate       time.Time
+	RevokedAt        time.Time
+	RevocationReason int
+	// Encoded optionally contains the response encoded again by the parser.
+	// If set, it must be identical to the parsed response.
+	Encoded []byte
+}
+
+// ParseFunc parses an OCSP response containing a single status, without
+// verifying its signature, like ocsp.ParseResponse with a nil issuer.
+type ParseFunc func(der []byte) (*Result, error)
+
+// ParseResponse is the ParseFunc of this module, using ocsp.ParseResponse
+// and Response.Marshal.
+func ParseResponse(der []byte) (*Result, error) {
+	resp, err := ocsp.ParseResponse(der, nil)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := resp.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Status:           resp.Status,
+		SerialNumber:     resp.SerialNumber,
+		ProducedAt:       resp.ProducedAt,
+		ThisUpdate:       resp.ThisUpdate,
+		NextUpdate:       resp.NextUpdate,
+		RevokedAt:        resp.RevokedAt,
+		RevocationReason: resp.RevocationReason,
+		Encoded:          encoded,
+	}, nil
+}
+
+var statusNames = map[int]string{
+	ocsp.Good:    "good",
+	ocsp.Revoked: "revoked",
+	ocsp.Unknown: "unknown",
+}
+
+// Run parses each vector of the corpus with parse in a subtest, and reports
+// the differences with the expected values.
+func Run(t *testing.T, parse ParseFunc) {
+	t.Helper()
+	vectors, err := Vectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			res, err := parse(v.DER)
+			if v.Expected.Error {
+				if err == nil {
+					t.Errorf("expected an error parsing %s", v.Expected.Description)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsing %s: %v", v.Expected.Description, err)
+			}
+			for _, diff := range compare(&v, res) {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// compare returns the differences between the result of parsing v and its
+// expected values.
+func compare(v *Vector, got *Result) []string {
+	want := v.Expected
+	var diffs []string
+	if status := statusNames[got.Status]; status != want.Status {
+		diffs = append(diffs, fmt.Sprintf("Status = %q, want %q", status, want.Status))
+	}
+	if got.SerialNumber == nil || hex.EncodeToString(got.SerialNumber.Bytes()) != want.SerialNumber {
+		diffs = append(diffs, fmt.Sprintf("SerialNumber = %x, want %s", got.SerialNumber, want.SerialNumber))
+	}
+	for _, tc := range []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"ProducedAt", got.ProducedAt, want.ProducedAt},
+		{"ThisUpdate", got.ThisUpdate, want.ThisUpdate},
+		{"NextUpdate", got.NextUpdate, want.NextUpdate},
+		{"RevokedAt", got.RevokedAt, want.RevokedAt},
+	} {
+		if !tc.got.Equal(tc.want) {
+			diffs = append(diffs, fmt.Sprintf("%s = %v, want %v", tc.name, tc.got, tc.want))
+		}
+	}
+	if got.RevocationReason != want.RevocationReason {
+		diffs = append(diffs, fmt.Sprintf("RevocationReason = %d, want %d", got.RevocationReason, want.RevocationReason))
+	}
+	if got.Encoded != nil && !bytes.Equal(got.Encoded, v.DER) {
+		diffs = append(diffs, "the encoded response does not match the parsed response")
+	}
+	return diffs
+}
@@ -0,0 +1,71 @@
+package conformance
+
+import (
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	vectors, err := Vectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("the corpus is empty")
+	}
+	for i, v := range vectors {
+		if len(v.DER) == 0 || v.Expected.Description == "" {
+			t.Errorf("vector %s is incomplete", v.Name)
+		}
+		if !v.Expected.Error && v.Expected.Status == "" {
+			t.Errorf("vector %s does not have an expected status", v.Name)
+		}
+		if i > 0 && vectors[i-1].Name >= v.Name {
+			t.Errorf("vectors are not sorted: %s before %s", vectors[i-1].Name, v.Name)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	Run(t, ParseResponse)
+}
+
+func TestCompare(t *testing.T) {
+	vectors, err := Vectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		if v.Expected.Error {
+			continue
+		}
+		res, err := ParseResponse(v.DER)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diffs := compare(&v, res); len(diffs) != 0 {
+			t.Fatalf("compare(%s) = %v, want no differences", v.Name, diffs)
+		}
+
+		for name, modify := range map[string]func(*Result){
+			"status":        func(r *Result) { r.Status = 42 },
+			"serial number": func(r *Result) { r.SerialNumber = nil },
+			"produced at":   func(r *Result) { r.ProducedAt = r.ProducedAt.Add(1) },
+			"this update":   func(r *Result) { r.ThisUpdate = r.ThisUpdate.Add(1) },
+			"next update":   func(r *Result) { r.NextUpdate = r.NextUpdate.Add(1) },
+			"revoked at":    func(r *Result) { r.RevokedAt = r.RevokedAt.Add(1) },
+			"reason":        func(r *Result) { r.RevocationReason = 42 },
+			"encoding":      func(r *Result) { r.Encoded = r.Encoded[1:] },
+		} {
+			got := *res
+			modify(&got)
+			if diffs := compare(&v, &got); len(diffs) != 1 {
+				t.Errorf("compare(%s) with a different %s = %v, want one difference", v.Name, name, diffs)
+			}
+		}
+
+		res.Encoded = nil
+		if diffs := compare(&v, res); len(diffs) != 0 {
+			t.Errorf("compare(%s) without encoding = %v, want no differences", v.Name, diffs)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DifferenceKind is the kind of a Difference between two responses.
+type DifferenceKind int
+
+const (
+	// StatusChanged indicates a different Status, RevokedAt or
+	// RevocationReason.
+	StatusChanged DifferenceKind = iota
+	// CertIDChanged indicates a different SerialNumber, IssuerHash,
+	// IssuerNameHash or IssuerKeyHash.
+	CertIDChanged
+	// TimeShifted indicates a different ProducedAt, ThisUpdate or
+	// NextUpdate.
+	TimeShifted
+	// ExtensionAdded indicates an extension only present in the second
+	// response.
+	ExtensionAdded
+	// ExtensionRemoved indicates an extension only present in the first
+	// response.
+	ExtensionRemoved
+	// ExtensionChanged indicates an extension with a different value or
+	// criticality.
+	ExtensionChanged
+	// ResponderChanged indicates a different responder ID or responder
+	// certificate.
+	ResponderChanged
+	// SignatureChanged indicates a different signature algorithm or
+	// signature.
+	SignatureChanged
+)
+
+func (k DifferenceKind) String() string {
+	switch k {
+	case StatusChanged:
+		return "status changed"
+	case CertIDChanged:
+		return "CertID changed"
+	case TimeShifted:
+		return "time shifted"
+	case ExtensionAdded:
+		return "extension added"
+	case ExtensionRemoved:
+		return "extension removed"
+	case ExtensionChanged:
+		return "extension changed"
+	case ResponderChanged:
+		return "responder changed"
+	case SignatureChanged:
+		return "signature changed"
+	default:
+		return "unknown difference kind " + strconv.Itoa(int(k))
+	}
+}
+
+// Difference is a field-level difference between two responses, as returned
+// by DiffResponses.
+type Difference struct {
+	Kind DifferenceKind
+	// Field is the name of the Response field, followed by the object
+	// identifier for extensions, like "Extensions 1.3.6.1.5.5.7.48.1.2".
+	Field string
+	// A and B are the values of the field in each response, formatted for
+	// humans. They are empty if the field is not present.
+	A, B string
+	// Shift is B minus A for TimeShifted differences.
+	Shift time.Duration
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s: %q -> %q", d.Field, d.Kind, d.A, d.B)
+}
+
+// DiffResponses returns the differences between the fields of a and b, in a
+// stable order. It returns nil if the responses are equivalent. Raw and
+// TBSResponseData are not compared, as their differences are reported on the
+// fields they encode.
+func DiffResponses(a, b *Response) []Difference {
+	var diffs []Difference
+	add := func(kind DifferenceKind, field, x, y string) {
+		if x != y {
+			diffs = append(diffs, Difference{Kind: kind, Field: field, A: x, B: y})
+		}
+	}
+	addTime := func(field string, x, y time.Time) {
+		if x.Equal(y) {
+			return
+		}
+		d := Difference{Kind: TimeShifted, Field: field, A: formatTime(x), B: formatTime(y)}
+		if !x.IsZero() && !y.IsZero() {
+			d.Shift = y.Sub(x)
+		}
+		diffs = append(diffs, d)
+	}
+
+	add(CertIDChanged, "SerialNumber", formatSerial(a), formatSerial(b))
+	add(CertIDChanged, "IssuerHash", a.IssuerHash.String(), b.IssuerHash.String())
+	add(CertIDChanged, "IssuerNameHash", hex.EncodeToString(a.IssuerNameHash), hex.EncodeToString(b.IssuerNameHash))
+	add(CertIDChanged, "IssuerKeyHash", hex.EncodeToString(a.IssuerKeyHash), hex.EncodeToString(b.IssuerKeyHash))
+
+	add(StatusChanged, "Status", formatStatus(a.Status), formatStatus(b.Status))
+	if a.Status == Revoked || b.Status == Revoked {
+		add(StatusChanged, "RevokedAt", formatTime(a.RevokedAt), formatTime(b.RevokedAt))
+		add(StatusChanged, "RevocationReason", strconv.Itoa(a.RevocationReason), strconv.Itoa(b.RevocationReason))
+	}
+
+	addTime("ProducedAt", a.ProducedAt, b.ProducedAt)
+	addTime("ThisUpdate", a.ThisUpdate, b.ThisUpdate)
+	addTime("NextUpdate", a.NextUpdate, b.NextUpdate)
+
+	diffs = diffExtensions(diffs, "Extensions", a.Extensions, b.Extensions)
+	diffs = diffExtensions(diffs, "ResponseExtensions", a.ResponseExtensions, b.ResponseExtensions)
+
+	add(ResponderChanged, "RawResponderName", hex.EncodeToString(a.RawResponderName), hex.EncodeToString(b.RawResponderName))
+	add(ResponderChanged, "ResponderKeyHash", hex.EncodeToString(a.ResponderKeyHash), hex.EncodeToString(b.ResponderKeyHash))
+	add(ResponderChanged, "Certificate", formatCertificate(a), formatCertificate(b))
+
+	add(SignatureChanged, "SignatureAlgorithm", a.SignatureAlgorithm.String(), b.SignatureAlgorithm.String())
+	add(SignatureChanged, "Signature", hex.EncodeToString(a.Signature), hex.EncodeToString(b.Signature))
+	return diffs
+}
+
+// diffExtensions appends the differences between the extensions a and b to
+// diffs, in the order of a followed by the extensions only in b.
+func diffExtensions(diffs []Difference, field string, a, b []pkix.Extension) []Difference {
+	format := func(ext pkix.Extension) string {
+		s := hex.EncodeToString(ext.Value)
+		if ext.Critical {
+			s += " (critical)"
+		}
+		return s
+	}
+	find := func(exts []pkix.Extension, ext pkix.Extension) (pkix.Extension, bool) {
+		for _, e := range exts {
+			if e.Id.Equal(ext.Id) {
+				return e, true
+			}
+		}
+		return pkix.Extension{}, false
+	}
+
+	for _, x := range a {
+		name := field + " " + x.Id.String()
+		y, ok := find(b, x)
+		switch {
+		case !ok:
+			diffs = append(diffs, Difference{Kind: ExtensionRemoved, Field: name, A: format(x)})
+		case x.Critical != y.Critical || !bytes.Equal(x.Value, y.Value):
+			diffs = append(diffs, Difference{Kind: ExtensionChanged, Field: name, A: format(x), B: format(y)})
+		}
+	}
+	for _, y := range b {
+		if _, ok := find(a, y); !ok {
+			diffs = append(diffs, Difference{Kind: ExtensionAdded, Field: field + " " + y.Id.String(), B: format(y)})
+		}
+	}
+	return diffs
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatSerial(resp *Response) string {
+	if resp.SerialNumber == nil {
+		return ""
+	}
+	return resp.SerialNumber.Text(16)
+}
+
+func formatStatus(status int) string {
+	switch status {
+	case Good:
+		return "good"
+	case Revoked:
+		return "revoked"
+	case Unknown:
+		return "unknown"
+	default:
+		return strconv.Itoa(status)
+	}
+}
+
+func formatCertificate(resp *Response) string {
+	if resp.Certificate == nil {
+		return ""
+	}
+	fingerprint := sha256.Sum256(resp.Certificate.Raw)
+	return resp.Certificate.Subject.String() + " " + hex.EncodeToString(fingerprint[:8])
+}
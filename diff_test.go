@@ -0,0 +1,81 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffResponses(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	a, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ParseResponse(der, nil)
+	if diffs := DiffResponses(a, b); diffs != nil {
+		t.Fatalf("DiffResponses of the same response: got %v", diffs)
+	}
+
+	oid := asn1.ObjectIdentifier{1, 2, 3}
+	b.Status = Revoked
+	b.RevokedAt = a.ThisUpdate
+	b.RevocationReason = KeyCompromise
+	b.NextUpdate = a.NextUpdate.Add(time.Hour)
+	b.Extensions = []pkix.Extension{{Id: oid, Value: []byte{5, 0}}}
+	b.SignatureAlgorithm = x509.ECDSAWithSHA256
+
+	kinds := map[string]DifferenceKind{}
+	for _, d := range DiffResponses(a, b) {
+		kinds[d.Field] = d.Kind
+		if d.Field == "NextUpdate" && d.Shift != time.Hour {
+			t.Errorf("NextUpdate: got shift %v, want 1h", d.Shift)
+		}
+	}
+	want := map[string]DifferenceKind{
+		"Status":             StatusChanged,
+		"RevokedAt":          StatusChanged,
+		"RevocationReason":   StatusChanged,
+		"NextUpdate":         TimeShifted,
+		"Extensions 1.2.3":   ExtensionAdded,
+		"SignatureAlgorithm": SignatureChanged,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("DiffResponses: got %v, want %v", kinds, want)
+	}
+
+	// Extensions are matched by object identifier.
+	a.Extensions = []pkix.Extension{{Id: oid, Value: []byte{5, 0}, Critical: true}, {Id: asn1.ObjectIdentifier{1, 2, 4}}}
+	b = &Response{Status: a.Status, SerialNumber: big.NewInt(1), ThisUpdate: a.ThisUpdate, Extensions: b.Extensions}
+	diffs := DiffResponses(a, b)
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Field+": "+d.Kind.String())
+	}
+	wantFields := []string{
+		"SerialNumber: CertID changed",
+		"IssuerHash: CertID changed",
+		"IssuerNameHash: CertID changed",
+		"IssuerKeyHash: CertID changed",
+		"ProducedAt: time shifted",
+		"NextUpdate: time shifted",
+		"Extensions 1.2.3: extension changed",
+		"Extensions 1.2.4: extension removed",
+		"ResponderKeyHash: responder changed",
+		"SignatureAlgorithm: signature changed",
+		"Signature: signature changed",
+	}
+	if !reflect.DeepEqual(got, wantFields) {
+		t.Errorf("DiffResponses:\ngot  %q\nwant %q", got, wantFields)
+	}
+	for _, d := range diffs {
+		if d.Field == "NextUpdate" && (d.Shift != 0 || d.B != "") {
+			t.Errorf("NextUpdate: got %+v for a missing time", d)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ocsp
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// responseLevelExtensions are the extensions defined by RFC 6960 and RFC 8954
+// only in the responseExtensions of a response. Clients do not look for them
+// in the singleExtensions.
+var responseLevelExtensions = []asn1.ObjectIdentifier{
+	idPKIXOCSPNonce,
+}
+
+// SingleExtension returns the extension with the given object identifier in
+// the singleExtensions of the parsed response, which apply to the status of
+// the certificate.
+func (resp *Response) SingleExtension(oid asn1.ObjectIdentifier) (pkix.Extension, bool) {
+	return findExtension(resp.Extensions, oid)
+}
+
+// ResponseExtension returns the extension with the given object identifier in
+// the responseExtensions of the parsed response, which apply to the whole
+// response, like the nonce.
+func (resp *Response) ResponseExtension(oid asn1.ObjectIdentifier) (pkix.Extension, bool) {
+	return findExtension(resp.ResponseExtensions, oid)
+}
+
+// AddSingleExtension adds ext to the ExtraExtensions of the template resp,
+// replacing any extension with the same object identifier. It returns an
+// error for extensions that belong in the responseExtensions, like the nonce;
+// use AddResponseExtension for those.
+func (resp *Response) AddSingleExtension(ext pkix.Extension) error {
+	for _, oid := range responseLevelExtensions {
+		if ext.Id.Equal(oid) {
+			return fmt.Errorf("ocsp: extension %v is a response extension", ext.Id)
+		}
+	}
+	resp.ExtraExtensions = replaceExtension(resp.ExtraExtensions, ext)
+	return nil
+}
+
+// AddResponseExtension adds ext to the ResponseExtraExtensions of the template
+// resp, replacing any extension with the same object identifier.
+func (resp *Response) AddResponseExtension(ext pkix.Extension) {
+	resp.ResponseExtraExtensions = replaceExtension(resp.ResponseExtraExtensions, ext)
+}
+
+func findExtension(extensions []pkix.Extension, oid asn1.ObjectIdentifier) (pkix.Extension, bool) {
+	for _, ext := range extensions {
+		if ext.Id.Equal(oid) {
+			return ext, true
+		}
+	}
+	return pkix.Extension{}, false
+}
@@ -0,0 +1,62 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestResponseExtensionGetters(t *testing.T) {
+	// This response has the nonce in its singleExtensions.
+	der, _ := hex.DecodeString(ocspResponseWithExtensionHex)
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, ok := resp.SingleExtension(ocspExtensionOID)
+	expected, _ := hex.DecodeString(ocspExtensionValueHex)
+	if !ok || !bytes.Equal(ext.Value, expected) {
+		t.Errorf("SingleExtension: got %x, %v, want %x, true", ext.Value, ok, expected)
+	}
+	if _, ok := resp.ResponseExtension(ocspExtensionOID); ok {
+		t.Error("ResponseExtension: got true, want false")
+	}
+}
+
+func TestAddExtensions(t *testing.T) {
+	responder, key := newTestResponder(t, "responder")
+	singleOID := asn1.ObjectIdentifier{1, 2, 3, 4}
+	template := Response{Status: Good, SerialNumber: big.NewInt(1), ThisUpdate: time.Now().Truncate(time.Second)}
+
+	nonce := pkix.Extension{Id: ocspExtensionOID, Value: []byte{4, 1, 1}}
+	if err := template.AddSingleExtension(nonce); err == nil {
+		t.Error("AddSingleExtension: expected error for the nonce")
+	}
+	template.AddResponseExtension(pkix.Extension{Id: ocspExtensionOID, Value: []byte{4, 1, 0}})
+	template.AddResponseExtension(nonce)
+	if err := template.AddSingleExtension(pkix.Extension{Id: singleOID, Value: []byte{5, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(template.ExtraExtensions) != 1 || len(template.ResponseExtraExtensions) != 1 {
+		t.Fatalf("got extensions %v and response extensions %v", template.ExtraExtensions, template.ResponseExtraExtensions)
+	}
+
+	der, err := CreateResponse(responder, responder, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ext, ok := resp.ResponseExtension(ocspExtensionOID); !ok || !bytes.Equal(ext.Value, nonce.Value) {
+		t.Errorf("ResponseExtension: got %x, %v, want %x, true", ext.Value, ok, nonce.Value)
+	}
+	if _, ok := resp.SingleExtension(singleOID); !ok {
+		t.Error("SingleExtension: got false, want true")
+	}
+}
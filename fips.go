@@ -0,0 +1,76 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrNotFIPSApproved is matched by the errors returned in FIPS mode when
+// signing or verifying with an algorithm or key that is not FIPS-approved.
+var ErrNotFIPSApproved = errors.New("ocsp: not FIPS-approved")
+
+var fipsMode atomic.Bool
+
+// SetFIPSMode enables or disables the FIPS mode. In FIPS mode, responses can
+// only be signed and verified with FIPS-approved signature algorithms: RSA
+// PKCS #1 v1.5 and PSS with keys of at least 2048 bits and ECDSA with the NIST
+// curves, both with SHA-2 hashes, and registered algorithms marked as
+// FIPSApproved. Responses and certificates signed with MD5 or SHA-1 are
+// rejected.
+//
+// The FIPS mode is enabled by default when the Go FIPS 140-3 module is
+// enabled, or with the boringcrypto toolchain.
+func SetFIPSMode(enabled bool) {
+	fipsMode.Store(enabled)
+}
+
+// FIPSMode reports whether the FIPS mode is enabled.
+func FIPSMode() bool {
+	return fipsMode.Load()
+}
+
+// checkFIPSSignature returns an error if the FIPS mode is enabled and algo or
+// pub are not FIPS-approved. The public key of registered algorithms is not
+// checked, and pub can be nil to skip the key check.
+func checkFIPSSignature(algo x509.SignatureAlgorithm, pub crypto.PublicKey) error {
+	if !FIPSMode() {
+		return nil
+	}
+	if details, ok := lookupSignatureAlgorithm(algo); ok {
+		if !details.FIPSApproved {
+			return fmt.Errorf("%w: signature algorithm %v", ErrNotFIPSApproved, algo)
+		}
+		return nil
+	}
+
+	switch algo {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+	default:
+		return fmt.Errorf("%w: signature algorithm %v", ErrNotFIPSApproved, algo)
+	}
+
+	switch pub := pub.(type) {
+	case nil:
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return fmt.Errorf("%w: %d-bit RSA key", ErrNotFIPSApproved, pub.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return fmt.Errorf("%w: ECDSA curve %s", ErrNotFIPSApproved, pub.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("%w: public key type %T", ErrNotFIPSApproved, pub)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build boringcrypto
+
+package ocsp
+
+import "crypto/boring"
+
+func init() {
+	if boring.Enabled() {
+		SetFIPSMode(true)
+	}
+}
@@ -0,0 +1,124 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func enableFIPSMode(t *testing.T) {
+	t.Helper()
+	enabled := FIPSMode()
+	SetFIPSMode(true)
+	t.Cleanup(func() { SetFIPSMode(enabled) })
+}
+
+func TestCheckFIPSSignature(t *testing.T) {
+	enableFIPSMode(t)
+
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsa1024 := &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 1023), E: 65537}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		algo x509.SignatureAlgorithm
+		pub  crypto.PublicKey
+		ok   bool
+	}{
+		{"RSA SHA-256", x509.SHA256WithRSA, &rsa2048.PublicKey, true},
+		{"RSA PSS SHA-384", x509.SHA384WithRSAPSS, &rsa2048.PublicKey, true},
+		{"ECDSA SHA-256", x509.ECDSAWithSHA256, &p256.PublicKey, true},
+		{"no key", x509.ECDSAWithSHA512, nil, true},
+		{"RSA SHA-1", x509.SHA1WithRSA, &rsa2048.PublicKey, false},
+		{"RSA MD5", x509.MD5WithRSA, &rsa2048.PublicKey, false},
+		{"ECDSA SHA-1", x509.ECDSAWithSHA1, &p256.PublicKey, false},
+		{"RSA 1024", x509.SHA256WithRSA, rsa1024, false},
+		{"unknown key", x509.SHA256WithRSA, "key", false},
+		{"unknown algorithm", x509.UnknownSignatureAlgorithm, &p256.PublicKey, false},
+		{"registered not approved", x509.PureEd25519, ed25519Pub, false},
+		{"Ed25519 key", x509.SHA256WithRSA, ed25519Pub, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkFIPSSignature(tc.algo, tc.pub)
+			if tc.ok && err != nil {
+				t.Errorf("checkFIPSSignature: %v", err)
+			} else if !tc.ok && !errors.Is(err, ErrNotFIPSApproved) {
+				t.Errorf("checkFIPSSignature: got %v, want ErrNotFIPSApproved", err)
+			}
+		})
+	}
+
+	SetFIPSMode(false)
+	if err := checkFIPSSignature(x509.MD5WithRSA, rsa1024); err != nil {
+		t.Errorf("checkFIPSSignature without FIPS mode: %v", err)
+	}
+}
+
+func TestFIPSModeResponses(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}
+
+	template.SignatureAlgorithm = x509.ECDSAWithSHA1
+	sha1Response, err := CreateResponse(responder, responder, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enableFIPSMode(t)
+	if _, err := CreateResponse(responder, responder, template, key); !errors.Is(err, ErrNotFIPSApproved) {
+		t.Errorf("CreateResponse with SHA-1: got %v, want ErrNotFIPSApproved", err)
+	}
+	if _, err := ParseResponse(sha1Response, responder); err == nil || !strings.Contains(err.Error(), ErrNotFIPSApproved.Error()) {
+		t.Errorf("ParseResponse with SHA-1: got %v, want a FIPS error", err)
+	}
+	resp, err := ParseResponseWithOptions(sha1Response, nil, nil, &ParseOptions{SkipSignatureVerification: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(key.Public()); !errors.Is(err, ErrNotFIPSApproved) {
+		t.Errorf("CheckSignatureFromKey with SHA-1: got %v, want ErrNotFIPSApproved", err)
+	}
+
+	template.SignatureAlgorithm = x509.ECDSAWithSHA256
+	der, err := CreateResponse(responder, responder, template, key)
+	if err != nil {
+		t.Fatalf("CreateResponse with SHA-256: %v", err)
+	}
+	if _, err := ParseResponse(der, responder); err != nil {
+		t.Errorf("ParseResponse with SHA-256: %v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SignatureAlgorithm = 0
+	if _, err := CreateResponse(responder, responder, template, edKey); !errors.Is(err, ErrNotFIPSApproved) {
+		t.Errorf("CreateResponse with a registered algorithm: got %v, want ErrNotFIPSApproved", err)
+	}
+}
@@ -0,0 +1,199 @@
+package ocsp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ReadDERMessage reads a DER-encoded OCSP request or response from r, for
+// transports without framing of their own, like raw TCP. As DER is
+// self-delimiting, the messages are sent one after the other, as is. It
+// reads exactly one message, and returns io.EOF if r ends before it. If
+// maxSize is positive, larger messages are rejected before reading them.
+func ReadDERMessage(r io.Reader, maxSize int) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:1]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, header[1:]); err != nil {
+		return nil, noEOF(err)
+	}
+	if header[0] != 0x30 {
+		return nil, ParseError("OCSP message is not a SEQUENCE")
+	}
+
+	prefix := header[:]
+	length := int(header[1])
+	if length&0x80 != 0 {
+		// The long form, with the number of length bytes. The indefinite
+		// length, with zero length bytes, is not allowed in DER.
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return nil, ParseError("invalid length of OCSP message")
+		}
+		prefix = make([]byte, 2+n)
+		copy(prefix, header[:])
+		if _, err := io.ReadFull(r, prefix[2:]); err != nil {
+			return nil, noEOF(err)
+		}
+		var l uint64
+		for _, c := range prefix[2:] {
+			l = l<<8 | uint64(c)
+		}
+		if prefix[2] == 0 || l < 0x80 {
+			return nil, ParseError("non-minimal length of OCSP message")
+		}
+		if l > math.MaxInt32-uint64(len(prefix)) {
+			return nil, ParseError("OCSP message is too large")
+		}
+		length = int(l)
+	}
+	if err := checkLimit("MaxSize", len(prefix)+length, maxSize); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, len(prefix)+length)
+	copy(msg, prefix)
+	if _, err := io.ReadFull(r, msg[len(prefix):]); err != nil {
+		return nil, noEOF(err)
+	}
+	return msg, nil
+}
+
+// noEOF returns io.ErrUnexpectedEOF instead of io.EOF, for streams ending in
+// the middle of a message.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// maxCoAPBlockNum is the maximum block number of a CoAP Block1 or Block2
+// option, which has 20 bits for it.
+const maxCoAPBlockNum = 1<<20 - 1
+
+// CoAPBlock is a block of an OCSP request or response sent with CoAP
+// block-wise transfers, as the payload of a message with a Block1 or Block2
+// option. See RFC 7959.
+type CoAPBlock struct {
+	// Num is the number of the block, starting at zero.
+	Num int
+	// More indicates that more blocks follow.
+	More bool
+	// Size is the block size, a power of two from 16 to 1024 bytes. All the
+	// blocks but the last one have Size bytes of payload.
+	Size int
+	// Payload is the data in the block.
+	Payload []byte
+}
+
+// coapSZX returns the size exponent of the block size.
+func coapSZX(size int) (int, bool) {
+	for szx := 0; szx <= 6; szx++ {
+		if 16<<szx == size {
+			return szx, true
+		}
+	}
+	return 0, false
+}
+
+// Option returns the value of the Block1 or Block2 option of the block, as a
+// minimal big-endian unsigned integer. See RFC 7959, section 2.2.
+func (b *CoAPBlock) Option() ([]byte, error) {
+	szx, ok := coapSZX(b.Size)
+	if !ok {
+		return nil, fmt.Errorf("ocsp: invalid CoAP block size %d", b.Size)
+	}
+	if b.Num < 0 || b.Num > maxCoAPBlockNum {
+		return nil, fmt.Errorf("ocsp: invalid CoAP block number %d", b.Num)
+	}
+	v := uint32(b.Num)<<4 | uint32(szx)
+	if b.More {
+		v |= 1 << 3
+	}
+	var opt []byte
+	for ; v > 0; v >>= 8 {
+		opt = append([]byte{byte(v)}, opt...)
+	}
+	return opt, nil
+}
+
+// ParseCoAPBlockOption parses the value of a Block1 or Block2 option, and
+// returns the block it describes, without payload.
+func ParseCoAPBlockOption(opt []byte) (CoAPBlock, error) {
+	if len(opt) > 3 {
+		return CoAPBlock{}, ParseError("invalid CoAP block option")
+	}
+	var v uint32
+	for _, c := range opt {
+		v = v<<8 | uint32(c)
+	}
+	szx := int(v & 7)
+	if szx == 7 {
+		return CoAPBlock{}, ParseError("reserved CoAP block size")
+	}
+	return CoAPBlock{
+		Num:  int(v >> 4),
+		More: v&(1<<3) != 0,
+		Size: 16 << szx,
+	}, nil
+}
+
+// SplitCoAPBlocks splits the DER-encoded OCSP request or response der into
+// blocks of the given size, to send it with CoAP block-wise transfers. The
+// payloads of the blocks are subslices of der. Constrained clients should
+// prefer small sizes, and servers should use the size requested by the
+// client, as described in RFC 7959, section 2.4.
+func SplitCoAPBlocks(der []byte, size int) ([]CoAPBlock, error) {
+	if _, ok := coapSZX(size); !ok {
+		return nil, fmt.Errorf("ocsp: invalid CoAP block size %d", size)
+	}
+	n := max(1, (len(der)+size-1)/size)
+	if n-1 > maxCoAPBlockNum {
+		return nil, errors.New("ocsp: message is too large for CoAP block-wise transfers")
+	}
+	blocks := make([]CoAPBlock, n)
+	for i := range blocks {
+		end := min(len(der), (i+1)*size)
+		blocks[i] = CoAPBlock{
+			Num:     i,
+			More:    i < n-1,
+			Size:    size,
+			Payload: der[i*size : end : end],
+		}
+	}
+	return blocks, nil
+}
+
+// JoinCoAPBlocks reassembles the DER-encoded OCSP request or response sent in
+// blocks, which must be in order. The block size may decrease between blocks,
+// as allowed by RFC 7959, as long as each block starts where the previous one
+// ended. If maxSize is positive, larger messages are rejected.
+func JoinCoAPBlocks(blocks []CoAPBlock, maxSize int) ([]byte, error) {
+	var der []byte
+	for i, b := range blocks {
+		if _, ok := coapSZX(b.Size); !ok {
+			return nil, ParseError("invalid CoAP block size")
+		}
+		last := i == len(blocks)-1
+		switch {
+		case b.Num*b.Size != len(der):
+			return nil, ParseError("CoAP block out of order")
+		case b.More == last:
+			return nil, ParseError("invalid CoAP block sequence")
+		case len(b.Payload) > b.Size, !last && len(b.Payload) != b.Size:
+			return nil, ParseError("invalid CoAP block payload size")
+		}
+		if err := checkLimit("MaxSize", len(der)+len(b.Payload), maxSize); err != nil {
+			return nil, err
+		}
+		der = append(der, b.Payload...)
+	}
+	if len(der) == 0 {
+		return nil, ParseError("empty CoAP block-wise transfer")
+	}
+	return der, nil
+}
@@ -0,0 +1,126 @@
+package ocsp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadDERMessage(t *testing.T) {
+	resp, _ := hex.DecodeString(ocspResponseHex)
+	req, _ := hex.DecodeString(ocspRequestHex)
+	stream := bytes.NewReader(append(append([]byte(nil), req...), resp...))
+	for _, want := range [][]byte{req, resp} {
+		got, err := ReadDERMessage(stream, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadDERMessage: got %x, want %x", got, want)
+		}
+	}
+	if _, err := ReadDERMessage(stream, 0); err != io.EOF {
+		t.Errorf("ReadDERMessage: got %v at the end of the stream, want io.EOF", err)
+	}
+
+	if _, err := ReadDERMessage(bytes.NewReader(resp), len(resp)-1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ReadDERMessage: got %v, want ErrLimitExceeded", err)
+	}
+	if _, err := ReadDERMessage(bytes.NewReader(resp[:len(resp)-1]), 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadDERMessage: got %v, want io.ErrUnexpectedEOF", err)
+	}
+	for _, bad := range []string{"3180", "0400", "3085", "30810f", "3082007f"} {
+		b, _ := hex.DecodeString(bad)
+		var parseErr ParseError
+		if _, err := ReadDERMessage(bytes.NewReader(b), 0); !errors.As(err, &parseErr) {
+			t.Errorf("ReadDERMessage(%s): got %v, want ParseError", bad, err)
+		}
+	}
+}
+
+func TestCoAPBlocks(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	blocks, err := SplitCoAPBlocks(der, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (len(der) + 63) / 64; len(blocks) != want {
+		t.Fatalf("SplitCoAPBlocks: got %d blocks, want %d", len(blocks), want)
+	}
+	for i := range blocks {
+		opt, err := blocks[i].Option()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseCoAPBlockOption(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Num != i || b.More != (i < len(blocks)-1) || b.Size != 64 {
+			t.Errorf("block %d: got option %+v", i, b)
+		}
+	}
+	got, err := JoinCoAPBlocks(blocks, len(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, der) {
+		t.Error("JoinCoAPBlocks did not return the original message")
+	}
+	if _, err := JoinCoAPBlocks(blocks, len(der)-1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JoinCoAPBlocks: got %v, want ErrLimitExceeded", err)
+	}
+	if _, err := JoinCoAPBlocks(blocks[:len(blocks)-1], 0); err == nil {
+		t.Error("JoinCoAPBlocks didn't fail without the last block")
+	}
+	if _, err := JoinCoAPBlocks(append([]CoAPBlock{blocks[1]}, blocks[0]), 0); err == nil {
+		t.Error("JoinCoAPBlocks didn't fail with blocks out of order")
+	}
+
+	// The block size can decrease after the first block.
+	first, err := SplitCoAPBlocks(der[:64], 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := SplitCoAPBlocks(der[64:], 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first[0].More = true
+	for i := range rest {
+		rest[i].Num += 2
+	}
+	if got, err := JoinCoAPBlocks(append(first, rest...), 0); err != nil || !bytes.Equal(got, der) {
+		t.Errorf("JoinCoAPBlocks: got %v with a smaller block size", err)
+	}
+
+	// Option values, from RFC 7959, section 2.2.
+	for _, tc := range []struct {
+		block CoAPBlock
+		opt   string
+	}{
+		{CoAPBlock{Num: 0, Size: 16}, ""},
+		{CoAPBlock{Num: 0, More: true, Size: 1024}, "0e"},
+		{CoAPBlock{Num: 1, Size: 128}, "13"},
+		{CoAPBlock{Num: 4096, More: true, Size: 64}, "01000a"},
+	} {
+		opt, err := tc.block.Option()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(opt) != tc.opt {
+			t.Errorf("Option(%+v): got %x, want %s", tc.block, opt, tc.opt)
+		}
+	}
+	if _, err := (&CoAPBlock{Size: 2048}).Option(); err == nil {
+		t.Error("Option didn't fail with an invalid size")
+	}
+	for _, bad := range []string{"07", "00000000"} {
+		b, _ := hex.DecodeString(bad)
+		if _, err := ParseCoAPBlockOption(b); err == nil {
+			t.Errorf("ParseCoAPBlockOption(%s) didn't fail", bad)
+		}
+	}
+}
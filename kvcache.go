@@ -0,0 +1,125 @@
+package ocsp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// KeyValueStore is the interface of the external key-value stores, like Redis
+// or memcached, used by NewKVCache. For example, a Redis client from
+// github.com/redis/go-redis can be adapted as:
+//
+//	type redisStore struct{ c *redis.Client }
+//
+//	func (s redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+//		b, err := s.c.Get(ctx, key).Bytes()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, ocsp.ErrCacheMiss
+//		}
+//		return b, err
+//	}
+//
+//	func (s redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//		return s.c.Set(ctx, key, value, ttl).Err()
+//	}
+//
+//	func (s redisStore) Delete(ctx context.Context, key string) error {
+//		return s.c.Del(ctx, key).Err()
+//	}
+type KeyValueStore interface {
+	// Get returns the value stored for key, or ErrCacheMiss if there is
+	// none.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value for key. If ttl is positive, the value expires after
+	// it.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value stored for key.
+	Delete(ctx context.Context, key string) error
+}
+
+type kvCache struct {
+	store  KeyValueStore
+	prefix string
+	now    func() time.Time
+}
+
+// NewKVCache returns a Cache backed by an external key-value store, so
+// several responders can share the same cache. Entries are stored with
+// MarshalCacheEntry, under the hex-encoded key prefixed with prefix, and they
+// expire at their NextUpdate time.
+func NewKVCache(store KeyValueStore, prefix string) Cache {
+	return &kvCache{
+		store:  store,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+func (c *kvCache) key(key CertIDKey) string {
+	return c.prefix + hex.EncodeToString(key[:])
+}
+
+func (c *kvCache) Get(ctx context.Context, key CertIDKey) (CacheEntry, error) {
+	b, err := c.store.Get(ctx, c.key(key))
+	if err != nil {
+		return CacheEntry{}, err
+	}
+	return UnmarshalCacheEntry(b)
+}
+
+// Put stores entry in the key-value store. Entries past their NextUpdate time,
+// or not strictly fresher than the stored one, are not stored. The stored
+// entry is read first, so concurrent writers of the same key can still
+// replace a fresher entry.
+func (c *kvCache) Put(ctx context.Context, key CertIDKey, entry CacheEntry) error {
+	var ttl time.Duration
+	if !entry.NextUpdate.IsZero() {
+		if ttl = entry.NextUpdate.Sub(c.now()); ttl <= 0 {
+			return nil
+		}
+	}
+	switch old, err := c.Get(ctx, key); {
+	case err == nil:
+		if !entry.fresherThan(&old) {
+			return nil
+		}
+	case !errors.Is(err, ErrCacheMiss):
+		return err
+	}
+	return c.store.Set(ctx, c.key(key), MarshalCacheEntry(entry), ttl)
+}
+
+func (c *kvCache) Delete(ctx context.Context, key CertIDKey) error {
+	return c.store.Delete(ctx, c.key(key))
+}
+
+const (
+	cacheEntryVersion    = 1
+	cacheEntryHeaderSize = 1 + 8 + 8
+)
+
+// MarshalCacheEntry serializes entry to be stored in an external store. The
+// encoding is a version byte, ThisUpdate and NextUpdate as big-endian Unix
+// nanoseconds, or zero if not set, and the DER-encoded response.
+func MarshalCacheEntry(entry CacheEntry) []byte {
+	b := make([]byte, cacheEntryHeaderSize, cacheEntryHeaderSize+len(entry.Response))
+	b[0] = cacheEntryVersion
+	binary.BigEndian.PutUint64(b[1:], uint64(unixNano(entry.ThisUpdate)))
+	binary.BigEndian.PutUint64(b[9:], uint64(unixNano(entry.NextUpdate)))
+	return append(b, entry.Response...)
+}
+
+// UnmarshalCacheEntry parses an entry serialized with MarshalCacheEntry.
+func UnmarshalCacheEntry(b []byte) (CacheEntry, error) {
+	if len(b) < cacheEntryHeaderSize || b[0] != cacheEntryVersion {
+		return CacheEntry{}, errors.New("ocsp: invalid cache entry")
+	}
+	return CacheEntry{
+		Response:   b[cacheEntryHeaderSize:],
+		ThisUpdate: fromUnixNano(int64(binary.BigEndian.Uint64(b[1:]))),
+		NextUpdate: fromUnixNano(int64(binary.BigEndian.Uint64(b[9:]))),
+	}, nil
+}
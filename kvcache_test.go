@@ -0,0 +1,135 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _ Cache = (*ShardedCache)(nil)
+
+type fakeKeyValueStore struct {
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func newFakeKeyValueStore() *fakeKeyValueStore {
+	return &fakeKeyValueStore{
+		values: make(map[string][]byte),
+		ttls:   make(map[string]time.Duration),
+	}
+}
+
+func (s *fakeKeyValueStore) Get(_ context.Context, key string) ([]byte, error) {
+	if v, ok := s.values[key]; ok {
+		return v, nil
+	}
+	return nil, ErrCacheMiss
+}
+
+func (s *fakeKeyValueStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *fakeKeyValueStore) Delete(_ context.Context, key string) error {
+	delete(s.values, key)
+	delete(s.ttls, key)
+	return nil
+}
+
+func TestKVCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newFakeKeyValueStore()
+	c := NewKVCache(store, "ocsp:")
+	c.(*kvCache).now = func() time.Time { return now }
+
+	entry := CacheEntry{Response: []byte{1, 2, 3}, ThisUpdate: now, NextUpdate: now.Add(time.Hour)}
+	if err := c.Put(ctx, testCacheKey(1), entry); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get(ctx, testCacheKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Response, entry.Response) || !got.ThisUpdate.Equal(entry.ThisUpdate) || !got.NextUpdate.Equal(entry.NextUpdate) {
+		t.Errorf("Get: got %v, want %v", got, entry)
+	}
+	for k, ttl := range store.ttls {
+		if !strings.HasPrefix(k, "ocsp:") || len(k) != len("ocsp:")+64 {
+			t.Errorf("unexpected store key %q", k)
+		}
+		if ttl != time.Hour {
+			t.Errorf("ttl: got %v, want %v", ttl, time.Hour)
+		}
+	}
+
+	// Older entries do not replace the stored one, fresher ones do.
+	if err := c.Put(ctx, testCacheKey(1), CacheEntry{Response: []byte{4}, ThisUpdate: now.Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get(ctx, testCacheKey(1)); err != nil || !bytes.Equal(got.Response, entry.Response) {
+		t.Errorf("Get after replaying an older entry: got %v, %v", got, err)
+	}
+	if err := c.Put(ctx, testCacheKey(1), CacheEntry{Response: []byte{5}, ThisUpdate: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get(ctx, testCacheKey(1)); err != nil || !bytes.Equal(got.Response, []byte{5}) {
+		t.Errorf("Get after storing a fresher entry: got %v, %v", got, err)
+	}
+
+	if err := c.Put(ctx, testCacheKey(2), CacheEntry{Response: []byte{1}, ThisUpdate: now}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get(ctx, testCacheKey(2)); err != nil || !got.NextUpdate.IsZero() {
+		t.Errorf("Get without NextUpdate: got %v, %v", got, err)
+	}
+	if err := c.Put(ctx, testCacheKey(3), CacheEntry{Response: []byte{1}, NextUpdate: now}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, testCacheKey(3)); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get expired entry: got %v, want ErrCacheMiss", err)
+	}
+
+	if err := c.Delete(ctx, testCacheKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, testCacheKey(1)); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get deleted entry: got %v, want ErrCacheMiss", err)
+	}
+
+	for k := range store.values {
+		store.values[k] = []byte{2}
+	}
+	if _, err := c.Get(ctx, testCacheKey(2)); err == nil || errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get invalid entry: got %v, want error", err)
+	}
+}
+
+func TestMarshalCacheEntry(t *testing.T) {
+	entries := []CacheEntry{
+		{},
+		{Response: []byte{0x30, 0x03, 0x0a, 0x01, 0x00}},
+		{Response: []byte{1}, ThisUpdate: time.Unix(1, 2).UTC(), NextUpdate: time.Unix(3, 4).UTC()},
+	}
+	for _, entry := range entries {
+		got, err := UnmarshalCacheEntry(MarshalCacheEntry(entry))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Response, entry.Response) || !got.ThisUpdate.Equal(entry.ThisUpdate) || !got.NextUpdate.Equal(entry.NextUpdate) {
+			t.Errorf("UnmarshalCacheEntry: got %v, want %v", got, entry)
+		}
+	}
+
+	for _, b := range [][]byte{nil, {1}, append([]byte{2}, make([]byte, 16)...)} {
+		if _, err := UnmarshalCacheEntry(b); err == nil {
+			t.Errorf("UnmarshalCacheEntry(%x) didn't fail", b)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package ocsp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// LoaderFunc is a read-only Cache that obtains entries by calling the
+// function, for example, reading them from a Store or a database, or signing
+// them on demand. It can be used as the last layer of a LayeredCache. Put
+// and Delete do nothing.
+type LoaderFunc func(ctx context.Context, key CertIDKey) (CacheEntry, error)
+
+// Get calls f.
+func (f LoaderFunc) Get(ctx context.Context, key CertIDKey) (CacheEntry, error) {
+	return f(ctx, key)
+}
+
+// Put does nothing.
+func (f LoaderFunc) Put(context.Context, CertIDKey, CacheEntry) error {
+	return nil
+}
+
+// Delete does nothing.
+func (f LoaderFunc) Delete(context.Context, CertIDKey) error {
+	return nil
+}
+
+// LayeredCache is a Cache that consults an ordered list of caches, for
+// example, a ShardedCache, then a shared cache from NewKVCache, then a
+// LoaderFunc signing responses on demand. Entries found in a layer are
+// stored in the layers before it, so they are found faster next time.
+type LayeredCache struct {
+	layers []Cache
+	hits   []atomic.Int64
+	misses atomic.Int64
+}
+
+// NewLayeredCache returns a LayeredCache consulting the given caches in
+// order.
+func NewLayeredCache(layers ...Cache) *LayeredCache {
+	return &LayeredCache{
+		layers: layers,
+		hits:   make([]atomic.Int64, len(layers)),
+	}
+}
+
+// Get returns the entry from the first layer that has it, and stores it in
+// the previous layers. Layers returning errors are skipped. If no layer has
+// the entry, the returned error matches ErrCacheMiss, and it also wraps the
+// errors of the failed layers, if any.
+func (c *LayeredCache) Get(ctx context.Context, key CertIDKey) (CacheEntry, error) {
+	errs := []error{ErrCacheMiss}
+	for i, layer := range c.layers {
+		entry, err := layer.Get(ctx, key)
+		if err != nil {
+			if !errors.Is(err, ErrCacheMiss) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		c.hits[i].Add(1)
+		for _, upper := range c.layers[:i] {
+			// A failure to promote the entry does not prevent serving
+			// it.
+			_ = upper.Put(ctx, key, entry)
+		}
+		return entry, nil
+	}
+	c.misses.Add(1)
+	if len(errs) == 1 {
+		return CacheEntry{}, ErrCacheMiss
+	}
+	return CacheEntry{}, errors.Join(errs...)
+}
+
+// Put stores entry in all the layers. It returns the errors of the layers
+// that failed, if any.
+func (c *LayeredCache) Put(ctx context.Context, key CertIDKey, entry CacheEntry) error {
+	var errs []error
+	for _, layer := range c.layers {
+		if err := layer.Put(ctx, key, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Delete removes the entry from all the layers. It returns the errors of the
+// layers that failed, if any.
+func (c *LayeredCache) Delete(ctx context.Context, key CertIDKey) error {
+	var errs []error
+	for _, layer := range c.layers {
+		if err := layer.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LayeredCacheStats are the hit counts of a LayeredCache.
+type LayeredCacheStats struct {
+	// Hits is the number of entries found in each layer.
+	Hits []int64
+	// Misses is the number of entries not found in any layer.
+	Misses int64
+}
+
+// Stats returns the hit counts of the cache since it was created.
+func (c *LayeredCache) Stats() LayeredCacheStats {
+	stats := LayeredCacheStats{
+		Hits:   make([]int64, len(c.hits)),
+		Misses: c.misses.Load(),
+	}
+	for i := range c.hits {
+		stats.Hits[i] = c.hits[i].Load()
+	}
+	return stats
+}
@@ -0,0 +1,115 @@
+package ocsp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// failingCache is a Cache whose operations always fail.
+type failingCache struct{ err error }
+
+func (c failingCache) Get(context.Context, CertIDKey) (CacheEntry, error) {
+	return CacheEntry{}, c.err
+}
+
+func (c failingCache) Put(context.Context, CertIDKey, CacheEntry) error { return c.err }
+
+func (c failingCache) Delete(context.Context, CertIDKey) error { return c.err }
+
+func TestLayeredCache(t *testing.T) {
+	ctx := context.Background()
+	memory := NewShardedCache(1<<20, 1)
+	shared := &mapCache{items: map[CertIDKey]CacheEntry{}}
+	signed := 0
+	loader := LoaderFunc(func(_ context.Context, key CertIDKey) (CacheEntry, error) {
+		if key == testCacheKey(99) {
+			return CacheEntry{}, ErrCacheMiss
+		}
+		signed++
+		return CacheEntry{Response: key[:], NextUpdate: time.Now().Add(time.Hour)}, nil
+	})
+	c := NewLayeredCache(memory, shared, loader)
+
+	// The first Get is served by the loader and promoted to the other layers.
+	entry, err := c.Get(ctx, testCacheKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := testCacheKey(1)
+	if string(entry.Response) != string(key[:]) {
+		t.Errorf("Get returned %x, want %x", entry.Response, key)
+	}
+	if _, err := memory.Get(ctx, key); err != nil {
+		t.Errorf("entry was not promoted to the first layer: %v", err)
+	}
+	if _, err := shared.Get(ctx, key); err != nil {
+		t.Errorf("entry was not promoted to the second layer: %v", err)
+	}
+	if _, err := c.Get(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if signed != 1 {
+		t.Errorf("the loader was called %d times, want 1", signed)
+	}
+
+	// Entries only in the second layer are promoted to the first one.
+	shared.Put(ctx, testCacheKey(2), CacheEntry{Response: []byte("shared")})
+	if entry, err := c.Get(ctx, testCacheKey(2)); err != nil || string(entry.Response) != "shared" {
+		t.Errorf("Get = %q, %v, want the shared entry", entry.Response, err)
+	}
+	if _, err := memory.Get(ctx, testCacheKey(2)); err != nil {
+		t.Errorf("entry was not promoted to the first layer: %v", err)
+	}
+
+	if _, err := c.Get(ctx, testCacheKey(99)); err != ErrCacheMiss {
+		t.Errorf("Get = %v, want ErrCacheMiss", err)
+	}
+
+	want := LayeredCacheStats{Hits: []int64{1, 1, 1}, Misses: 1}
+	if stats := c.Stats(); !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+
+	if err := c.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := memory.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Delete did not remove the entry from the first layer: %v", err)
+	}
+	if _, err := shared.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Delete did not remove the entry from the second layer: %v", err)
+	}
+}
+
+func TestLayeredCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("cache is down")
+	memory := NewShardedCache(1<<20, 1)
+	c := NewLayeredCache(failingCache{errDown}, memory)
+
+	// Failing layers are skipped.
+	if err := memory.Put(ctx, testCacheKey(1), CacheEntry{Response: []byte("memory")}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := c.Get(ctx, testCacheKey(1)); err != nil || string(entry.Response) != "memory" {
+		t.Errorf("Get = %q, %v, want the entry of the second layer", entry.Response, err)
+	}
+
+	_, err := c.Get(ctx, testCacheKey(2))
+	if !errors.Is(err, ErrCacheMiss) || !errors.Is(err, errDown) {
+		t.Errorf("Get = %v, want ErrCacheMiss and the layer error", err)
+	}
+
+	if err := c.Put(ctx, testCacheKey(3), CacheEntry{Response: []byte("put")}); !errors.Is(err, errDown) {
+		t.Errorf("Put = %v, want the layer error", err)
+	}
+	if _, err := memory.Get(ctx, testCacheKey(3)); err != nil {
+		t.Errorf("Put did not store the entry in the working layer: %v", err)
+	}
+	if err := c.Delete(ctx, testCacheKey(3)); !errors.Is(err, errDown) {
+		t.Errorf("Delete = %v, want the layer error", err)
+	}
+}
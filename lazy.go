@@ -0,0 +1,101 @@
+package ocsp
+
+import (
+	"encoding/asn1"
+	"math/big"
+	"time"
+)
+
+// The lazy* types only describe the fields read by ParseResponseLazy. The
+// remaining fields of each SEQUENCE, like the signature, the certificates and
+// the extensions, are skipped by encoding/asn1 without decoding them.
+type lazyResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type lazyResponse struct {
+	Status   asn1.Enumerated
+	Response lazyResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type lazyBasicResponse struct {
+	TBSResponseData lazyResponseData
+}
+
+type lazyResponseData struct {
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []lazySingleResponse
+}
+
+type lazyCertID struct {
+	HashAlgorithm asn1.RawValue
+	NameHash      asn1.RawValue
+	IssuerKeyHash asn1.RawValue
+	SerialNumber  *big.Int
+}
+
+type lazySingleResponse struct {
+	CertID     lazyCertID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag   `asn1:"tag:2,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+// ParseResponseLazy parses only the status, serial number and times of an
+// OCSP response containing a single certificate status. It does not verify
+// the signature, nor parse the embedded certificates, the responder ID or the
+// extensions, so it is several times faster than ParseResponse. It is meant
+// for processing large numbers of archived responses, for example, to compute
+// statistics; responses used to make trust decisions must be parsed with
+// ParseResponse.
+//
+// Only the Status, SerialNumber, ProducedAt, ThisUpdate, NextUpdate,
+// RevokedAt and RevocationReason fields of the returned response are set.
+func ParseResponseLazy(der []byte) (*Response, error) {
+	var resp lazyResponse
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("trailing data in OCSP response")
+	}
+	if status := ResponseStatus(resp.Status); status != Success {
+		return nil, ResponseError{status}
+	}
+	if !resp.Response.ResponseType.Equal(idPKIXOCSPBasic) {
+		return nil, ParseError("bad OCSP response type")
+	}
+
+	var basicResp lazyBasicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basicResp); err != nil {
+		return nil, err
+	}
+	if len(basicResp.TBSResponseData.Responses) != 1 {
+		return nil, ParseError("OCSP response contains bad number of responses")
+	}
+
+	singleResp := basicResp.TBSResponseData.Responses[0]
+	ret := &Response{
+		SerialNumber: singleResp.CertID.SerialNumber,
+		ProducedAt:   basicResp.TBSResponseData.ProducedAt,
+		ThisUpdate:   singleResp.ThisUpdate,
+		NextUpdate:   singleResp.NextUpdate,
+	}
+	switch {
+	case bool(singleResp.Good):
+		ret.Status = Good
+	case bool(singleResp.Unknown):
+		ret.Status = Unknown
+	default:
+		ret.Status = Revoked
+		ret.RevokedAt = singleResp.Revoked.RevocationTime
+		ret.RevocationReason = int(singleResp.Revoked.Reason)
+	}
+	return ret, nil
+}
@@ -0,0 +1,105 @@
+package ocsp
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseResponseLazy(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	now := time.Now().Truncate(time.Second)
+	for _, template := range []Response{
+		{Status: Good, SerialNumber: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour), Certificate: responder},
+		{Status: Revoked, SerialNumber: big.NewInt(2), ThisUpdate: now, RevokedAt: now.Add(-time.Hour), RevocationReason: KeyCompromise},
+		{Status: Unknown, SerialNumber: big.NewInt(3), ThisUpdate: now},
+	} {
+		der, err := CreateResponse(responder, responder, template, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseResponseLazy(der)
+		if err != nil {
+			t.Fatalf("ParseResponseLazy: %v", err)
+		}
+		if got.Status != want.Status ||
+			got.SerialNumber.Cmp(want.SerialNumber) != 0 ||
+			!got.ProducedAt.Equal(want.ProducedAt) ||
+			!got.ThisUpdate.Equal(want.ThisUpdate) ||
+			!got.NextUpdate.Equal(want.NextUpdate) ||
+			!got.RevokedAt.Equal(want.RevokedAt) ||
+			got.RevocationReason != want.RevocationReason {
+			t.Errorf("ParseResponseLazy = %+v, want %+v", got, want)
+		}
+		if got.Certificate != nil || got.Raw != nil || got.Signature != nil {
+			t.Error("ParseResponseLazy set fields other than the status, serial number and times")
+		}
+	}
+
+	der, _ := hex.DecodeString(ocspResponseHex)
+	if _, err := ParseResponseLazy(der); err != nil {
+		t.Errorf("ParseResponseLazy: %v", err)
+	}
+	if _, err := ParseResponseLazy(append(der, 0)); err == nil {
+		t.Error("ParseResponseLazy with trailing data: expected an error")
+	}
+	der, _ = hex.DecodeString(errorResponseHex)
+	if _, err := ParseResponseLazy(der); err == nil {
+		t.Error("ParseResponseLazy with an error response: expected an error")
+	}
+	der, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponseLazy(der); err != ParseError("OCSP response contains bad number of responses") {
+		t.Errorf("ParseResponseLazy with multiple responses: got %v, want bad number of responses", err)
+	}
+}
+
+func benchmarkParseResponse(b *testing.B, parse func(der []byte) error) {
+	responder, key := newTestResponder(b, "Responder")
+	der, err := CreateResponse(responder, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		NextUpdate:   time.Now().Add(time.Hour).Truncate(time.Second),
+		Certificate:  responder,
+	}, key)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := parse(der); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseResponse(b *testing.B) {
+	benchmarkParseResponse(b, func(der []byte) error {
+		_, err := ParseResponse(der, nil)
+		return err
+	})
+}
+
+func BenchmarkParseResponseSkipSignatureVerification(b *testing.B) {
+	benchmarkParseResponse(b, func(der []byte) error {
+		_, err := ParseResponseWithOptions(der, nil, nil, &ParseOptions{SkipSignatureVerification: true})
+		return err
+	})
+}
+
+func BenchmarkParseResponseLazy(b *testing.B) {
+	benchmarkParseResponse(b, func(der []byte) error {
+		_, err := ParseResponseLazy(der)
+		return err
+	})
+}
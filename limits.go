@@ -0,0 +1,326 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is matched by the LimitError returned when parsing input
+// that exceeds one of the configured Limits.
+var ErrLimitExceeded = errors.New("ocsp: limit exceeded")
+
+// LimitError is returned when parsing input that exceeds one of the configured
+// Limits. It matches ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	// Limit is the name of the exceeded field in Limits.
+	Limit string
+	// Value is the size or count found in the input.
+	Value int
+	// Max is the configured limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("ocsp: limit exceeded: %s is %d, maximum is %d", e.Limit, e.Value, e.Max)
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Limits bounds the resources used to parse untrusted OCSP requests and
+// responses. A zero value in any of the fields means no limit.
+type Limits struct {
+	// MaxSize is the maximum size in bytes of the DER input. It is checked
+	// before decoding anything, so it also bounds the decoding cost. The
+	// other limits are checked on the raw DER elements before they are
+	// decoded.
+	MaxSize int
+	// MaxResponses is the maximum number of SingleResponses in a response,
+	// or of Requests in a request.
+	MaxResponses int
+	// MaxCertificates is the maximum number of certificates embedded in a
+	// response.
+	MaxCertificates int
+	// MaxExtensions is the maximum number of extensions in each extension
+	// list, that is, the response or request extensions, and the extensions
+	// of each SingleResponse.
+	MaxExtensions int
+	// MaxExtensionSize is the maximum size in bytes of the value of an
+	// extension.
+	MaxExtensionSize int
+}
+
+// DefaultLimits are generous limits suitable for parsing responses and
+// requests from untrusted sources.
+var DefaultLimits = Limits{
+	MaxSize:          64 * 1024,
+	MaxResponses:     256,
+	MaxCertificates:  8,
+	MaxExtensions:    16,
+	MaxExtensionSize: 4 * 1024,
+}
+
+func checkLimit(name string, value, max int) error {
+	if max > 0 && value > max {
+		return &LimitError{Limit: name, Value: value, Max: max}
+	}
+	return nil
+}
+
+func (l Limits) checkSize(der []byte) error {
+	return checkLimit("MaxSize", len(der), l.MaxSize)
+}
+
+// counted reports whether any of the limits on the number or size of the
+// elements is set.
+func (l Limits) counted() bool {
+	return l.MaxResponses > 0 || l.MaxCertificates > 0 || l.MaxExtensions > 0 || l.MaxExtensionSize > 0
+}
+
+// derIterator walks a list of DER elements without decoding them. It stops at
+// the end of the list or at the first malformed element, which is left for
+// the decoder to report.
+type derIterator struct {
+	s    *scanner
+	rest []byte
+}
+
+func (it *derIterator) next(v *asn1.RawValue) bool {
+	if len(it.rest) == 0 {
+		return false
+	}
+	rest, err := asn1.Unmarshal(it.rest, v)
+	if err != nil {
+		it.rest = nil
+		return false
+	}
+	it.rest = rest
+	if it.s != nil {
+		it.s.examined(v)
+	}
+	return true
+}
+
+// scanner checks the limits on the raw DER elements of an input, and counts
+// them in stats, before the input is decoded. This way the number of
+// elements decoded is bounded by the limits and not only by MaxSize.
+type scanner struct {
+	limits Limits
+	stats  *ParseStats
+	// base is the scanned input, which starts at offset in the parsed
+	// input.
+	base   []byte
+	offset int
+}
+
+// examined records in stats that the input was examined up to the end of v,
+// which is part of s.base.
+func (s *scanner) examined(v *asn1.RawValue) {
+	end := s.offset + cap(s.base) - cap(v.FullBytes) + len(v.FullBytes)
+	s.stats.Bytes = max(s.stats.Bytes, end)
+}
+
+// scanList counts the elements of the DER list b in n, checking their
+// number against max. If f is not nil, it is called for each element.
+func (s *scanner) scanList(b []byte, name string, max int, n *int, f func(*asn1.RawValue) error) error {
+	it := derIterator{s: s, rest: b}
+	var v asn1.RawValue
+	for count := 1; it.next(&v); count++ {
+		*n++
+		if err := checkLimit(name, count, max); err != nil {
+			return err
+		}
+		if f != nil {
+			if err := f(&v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanExtensions scans the contents of an explicitly tagged list of
+// extensions.
+func (s *scanner) scanExtensions(b []byte) error {
+	var exts asn1.RawValue
+	if _, err := asn1.Unmarshal(b, &exts); err != nil {
+		return nil
+	}
+	return s.scanList(exts.Bytes, "MaxExtensions", s.limits.MaxExtensions, &s.stats.Extensions, func(ext *asn1.RawValue) error {
+		// The value is the last element of the extension.
+		it := derIterator{s: s, rest: ext.Bytes}
+		var v asn1.RawValue
+		size := 0
+		for it.next(&v) {
+			size = len(v.Bytes)
+		}
+		return checkLimit("MaxExtensionSize", size, s.limits.MaxExtensionSize)
+	})
+}
+
+// scanResponse scans a BasicOCSPResponse.
+func (s *scanner) scanResponse(der []byte) error {
+	var basic asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &basic); err != nil {
+		return nil
+	}
+	it := derIterator{s: s, rest: basic.Bytes}
+	var v asn1.RawValue
+	for first := true; it.next(&v); first = false {
+		switch {
+		case first:
+			if err := s.scanResponseData(v.Bytes); err != nil {
+				return err
+			}
+		case v.Class == asn1.ClassContextSpecific && v.Tag == 0:
+			var certs asn1.RawValue
+			if _, err := asn1.Unmarshal(v.Bytes, &certs); err != nil {
+				return nil
+			}
+			return s.scanList(certs.Bytes, "MaxCertificates", s.limits.MaxCertificates, &s.stats.Certificates, nil)
+		}
+	}
+	return nil
+}
+
+// scanResponseData scans the contents of a ResponseData. The responses are
+// the only universal SEQUENCE in it, and they are followed by the optional
+// [1] response extensions.
+func (s *scanner) scanResponseData(b []byte) error {
+	it := derIterator{s: s, rest: b}
+	var v asn1.RawValue
+	responses := false
+	for it.next(&v) {
+		switch {
+		case !responses && v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence:
+			responses = true
+			if err := s.scanList(v.Bytes, "MaxResponses", s.limits.MaxResponses, &s.stats.Responses, s.scanSingleResponse); err != nil {
+				return err
+			}
+		case responses && v.Class == asn1.ClassContextSpecific && v.Tag == 1:
+			return s.scanExtensions(v.Bytes)
+		}
+	}
+	return nil
+}
+
+// scanSingleResponse scans a SingleResponse. Its extensions are the [1]
+// element after thisUpdate, the [1] before it being the revoked status.
+func (s *scanner) scanSingleResponse(resp *asn1.RawValue) error {
+	it := derIterator{s: s, rest: resp.Bytes}
+	var v asn1.RawValue
+	thisUpdate := false
+	for it.next(&v) {
+		switch {
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagGeneralizedTime:
+			thisUpdate = true
+		case thisUpdate && v.Class == asn1.ClassContextSpecific && v.Tag == 1:
+			return s.scanExtensions(v.Bytes)
+		}
+	}
+	return nil
+}
+
+// scanRequest scans an OCSPRequest. The requests are the only universal
+// SEQUENCE in the TBSRequest, and the extensions are its [2] element.
+func (s *scanner) scanRequest(der []byte) error {
+	var req, tbs asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &req); err != nil {
+		return nil
+	}
+	if _, err := asn1.Unmarshal(req.Bytes, &tbs); err != nil {
+		return nil
+	}
+	it := derIterator{s: s, rest: tbs.Bytes}
+	var v asn1.RawValue
+	for it.next(&v) {
+		switch {
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence:
+			if err := s.scanList(v.Bytes, "MaxResponses", s.limits.MaxResponses, &s.stats.Responses, nil); err != nil {
+				return err
+			}
+		case v.Class == asn1.ClassContextSpecific && v.Tag == 2:
+			return s.scanExtensions(v.Bytes)
+		}
+	}
+	return nil
+}
+
+// newScanner returns a scanner of der, which starts at offset in the parsed
+// input, or nil if no limits need to be checked and stats is nil.
+func (l Limits) newScanner(der []byte, offset int, stats *ParseStats) *scanner {
+	if stats == nil {
+		if !l.counted() {
+			return nil
+		}
+		stats = &ParseStats{}
+	}
+	return &scanner{limits: l, stats: stats, base: der, offset: offset}
+}
+
+// scanResponse checks the limits on a BasicOCSPResponse, which starts at
+// offset in the parsed input, before it is decoded. Its elements are counted
+// in stats, if not nil.
+func (l Limits) scanResponse(der []byte, offset int, stats *ParseStats) error {
+	if s := l.newScanner(der, offset, stats); s != nil {
+		return s.scanResponse(der)
+	}
+	return nil
+}
+
+// scanRequest checks the limits on an OCSPRequest before it is decoded,
+// counting its elements in stats if not nil.
+func (l Limits) scanRequest(der []byte, stats *ParseStats) error {
+	if s := l.newScanner(der, 0, stats); s != nil {
+		return s.scanRequest(der)
+	}
+	return nil
+}
+
+// ParseStats reports the work done to parse an input, as returned by
+// ParseResponseBounded and ParseRequestBounded. The elements are counted
+// before they are decoded, so when parsing fails, the stats include the
+// elements examined up to the failure.
+type ParseStats struct {
+	// Bytes is the length of the prefix of the input examined, up to the end
+	// of the last element examined. It is the size of the input if parsing
+	// succeeds, and zero if the input was rejected by MaxSize or its outer
+	// structure could not be decoded.
+	Bytes int
+	// Responses is the number of SingleResponses, or of Requests in a
+	// request, examined.
+	Responses int
+	// Certificates is the number of embedded certificates examined.
+	Certificates int
+	// Extensions is the total number of extensions examined, in all the
+	// extension lists.
+	Extensions int
+}
+
+// examined records that the first n bytes of the input were examined.
+func (s *ParseStats) examined(n int) {
+	if s != nil {
+		s.Bytes = max(s.Bytes, n)
+	}
+}
+
+// ParseResponseBounded is like ParseResponse, but it enforces limits and
+// reports the work done, including when parsing fails. It is intended for
+// fuzzers and services parsing untrusted input with strict budgets.
+func ParseResponseBounded(der []byte, issuer *x509.Certificate, limits Limits) (*Response, *ParseStats, error) {
+	stats := &ParseStats{}
+	resp, err := parseResponse(der, nil, nil, "certificate", issuer, &ParseOptions{Limits: limits, stats: stats})
+	return resp, stats, err
+}
+
+// ParseRequestBounded is like ParseRequest, but it enforces limits and
+// reports the work done, including when parsing fails.
+func ParseRequestBounded(der []byte, limits Limits) (*Request, *ParseStats, error) {
+	stats := &ParseStats{}
+	req, err := ParseRequestWithOptions(der, &ParseOptions{Limits: limits, stats: stats})
+	return req, stats, err
+}
@@ -0,0 +1,179 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseResponseLimits(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	der, err := CreateResponse(responder, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		Certificate:  responder,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3}, Value: make([]byte, 100)},
+			{Id: asn1.ObjectIdentifier{1, 2, 4}, Value: []byte{5, 0}},
+		},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiResp, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		der    []byte
+		limits Limits
+		limit  string
+	}{
+		{"size", der, Limits{MaxSize: len(der) - 1}, "MaxSize"},
+		{"responses", multiResp, Limits{MaxResponses: 4}, "MaxResponses"},
+		{"extensions", der, Limits{MaxExtensions: 1}, "MaxExtensions"},
+		{"extension size", der, Limits{MaxExtensionSize: 99}, "MaxExtensionSize"},
+		{"default limits", der, DefaultLimits, ""},
+		{"exact limits", der, Limits{MaxSize: len(der), MaxResponses: 1, MaxCertificates: 1, MaxExtensions: 2, MaxExtensionSize: 100}, ""},
+		{"multiple responses within limits", multiResp, Limits{MaxResponses: 5}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+			_, err := ParseResponseWithOptions(tc.der, cert, nil, &ParseOptions{Limits: tc.limits})
+			if tc.limit == "" {
+				if err != nil {
+					t.Fatalf("ParseResponseWithOptions: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("ParseResponseWithOptions: got %v, want ErrLimitExceeded", err)
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != tc.limit {
+				t.Errorf("ParseResponseWithOptions: got %v, want %s to be exceeded", err, tc.limit)
+			}
+		})
+	}
+}
+
+func TestParseResponseLimitsCertificates(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	der, err := CreateResponse(responder, responder, Response{
+		Status:           Good,
+		SerialNumber:     big.NewInt(1),
+		ThisUpdate:       time.Now().Truncate(time.Second),
+		Certificate:      responder,
+		CertificateChain: []*x509.Certificate{responder, responder},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponseWithOptions(der, nil, nil, &ParseOptions{Limits: Limits{MaxCertificates: 3}}); err != nil {
+		t.Errorf("ParseResponseWithOptions: %v", err)
+	}
+	if _, err := ParseResponseWithOptions(der, nil, nil, &ParseOptions{Limits: Limits{MaxCertificates: 2}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseResponseWithOptions: got %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestParseRequestLimits(t *testing.T) {
+	der, _ := hex.DecodeString(ocspRequestHex)
+	if _, err := ParseRequestWithOptions(der, &ParseOptions{Limits: DefaultLimits}); err != nil {
+		t.Fatalf("ParseRequestWithOptions: %v", err)
+	}
+	if _, err := ParseRequestWithOptions(der, &ParseOptions{Limits: Limits{MaxSize: len(der) - 1}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseRequestWithOptions: got %v, want ErrLimitExceeded", err)
+	}
+
+	var req ocspRequest
+	if _, err := asn1.Unmarshal(der, &req); err != nil {
+		t.Fatal(err)
+	}
+	req.TBSRequest.Raw = nil
+	req.TBSRequest.RequestList = append(req.TBSRequest.RequestList, req.TBSRequest.RequestList[0])
+	req.TBSRequest.RequestExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 3}, Value: make([]byte, 10)}}
+	der, err := asn1.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, limits := range []Limits{{MaxResponses: 1}, {MaxExtensionSize: 9}} {
+		var limitErr *LimitError
+		if _, err := ParseRequestWithOptions(der, &ParseOptions{Limits: limits}); !errors.As(err, &limitErr) {
+			t.Errorf("ParseRequestWithOptions(%+v): got %v, want ErrLimitExceeded", limits, err)
+		}
+	}
+	// The limits are checked before decoding, so they are reported even if
+	// the request is not supported.
+	_, err = ParseRequestWithOptions(der, &ParseOptions{Limits: Limits{MaxResponses: 2, MaxExtensions: 1, MaxExtensionSize: 10}})
+	if errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseRequestWithOptions: %v", err)
+	}
+}
+
+func TestParseBounded(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	want, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, stats, err := ParseResponseBounded(der, nil, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCerts := 0
+	if want.Certificate != nil {
+		wantCerts = 1 + len(want.CertificateChain)
+	}
+	wantStats := ParseStats{
+		Bytes:        len(der),
+		Responses:    1,
+		Certificates: wantCerts,
+		Extensions:   len(want.Extensions) + len(want.ResponseExtensions),
+	}
+	if resp.Status != want.Status || *stats != wantStats {
+		t.Errorf("ParseResponseBounded: got status %d and %+v, want %d and %+v", resp.Status, *stats, want.Status, wantStats)
+	}
+
+	if _, stats, err = ParseResponseBounded(der, nil, Limits{MaxSize: len(der) - 1}); !errors.Is(err, ErrLimitExceeded) || stats.Bytes != 0 {
+		t.Errorf("ParseResponseBounded: got %v and %+v, want limit error before decoding", err, *stats)
+	}
+
+	// The work is reported for responses that fail to parse.
+	multi, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The elements after the exceeded limit are not examined.
+	if _, stats, err = ParseResponseBounded(multi, nil, Limits{MaxResponses: 2}); !errors.Is(err, ErrLimitExceeded) || stats.Responses != 3 ||
+		stats.Bytes == 0 || stats.Bytes >= len(multi) {
+		t.Errorf("ParseResponseBounded: got %v and %+v", err, *stats)
+	}
+	// Up to the failure when the input is malformed.
+	truncated := append([]byte(nil), multi...)
+	if _, stats, err = ParseResponseBounded(truncated[:len(truncated)-1], nil, DefaultLimits); err == nil || stats.Bytes != 0 {
+		t.Errorf("ParseResponseBounded: got %v and %+v for a truncated response", err, *stats)
+	}
+	errResp, _ := hex.DecodeString(errorResponseHex)
+	if _, stats, err = ParseResponseBounded(errResp, nil, DefaultLimits); err == nil || stats.Bytes != len(errResp) {
+		t.Errorf("ParseResponseBounded: got %v and %+v for an error response", err, *stats)
+	}
+
+	der, _ = hex.DecodeString(ocspRequestHex)
+	req, stats, err := ParseRequestBounded(der, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.SerialNumber == nil || *stats != (ParseStats{Bytes: len(der), Responses: 1}) {
+		t.Errorf("ParseRequestBounded: got %+v", *stats)
+	}
+}
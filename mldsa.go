@@ -0,0 +1,103 @@
+//go:build mldsa
+
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// The signature algorithms of the ML-DSA parameter sets defined in FIPS 204.
+// These are not defined by crypto/x509, and are only available after calling
+// RegisterMLDSA.
+const (
+	MLDSA44 x509.SignatureAlgorithm = 0x1000 + iota
+	MLDSA65
+	MLDSA87
+)
+
+// The ML-DSA OIDs defined in the NIST Computer Security Objects Register and
+// used in draft-ietf-lamps-dilithium-certificates.
+//
+// The OIDs used by pre-standard pilots, like the Open Quantum Safe arcs under
+// 1.3.6.1.4.1.2.267, are not registered, not even as parse-only aliases. They
+// identify the CRYSTALS-Dilithium submissions to the NIST competition, whose
+// signatures are not ML-DSA signatures, as FIPS 204 changed the signing
+// algorithm, so the MLDSA implementations could not verify them.
+var (
+	oidSignatureMLDSA44 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+	oidSignatureMLDSA65 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+	oidSignatureMLDSA87 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+)
+
+// MLDSA is the interface implemented by ML-DSA providers. It allows the
+// implementation to come from an external module or from crypto/mldsa.
+//
+// Signatures are created and verified over the raw message with an empty
+// context string. ML-DSA private keys used to sign responses must implement
+// crypto.Signer and sign the message directly when opts.HashFunc returns zero.
+type MLDSA interface {
+	// NewPublicKey returns the public key of the given parameter set from its
+	// raw encoding.
+	NewPublicKey(algo x509.SignatureAlgorithm, encoding []byte) (crypto.PublicKey, error)
+	// Algorithm returns the parameter set of pub, or
+	// x509.UnknownSignatureAlgorithm if pub is not an ML-DSA public key.
+	Algorithm(pub crypto.PublicKey) x509.SignatureAlgorithm
+	// Verify checks that signature is a valid signature of message made by
+	// the private key corresponding to pub.
+	Verify(pub crypto.PublicKey, message, signature []byte) error
+}
+
+// RegisterMLDSA registers the ML-DSA signature algorithms using the given
+// implementation. It is meant to be called from init functions.
+func RegisterMLDSA(impl MLDSA) error {
+	if impl == nil {
+		return errors.New("ocsp: ML-DSA implementation cannot be nil")
+	}
+
+	for _, a := range []struct {
+		algo x509.SignatureAlgorithm
+		oid  asn1.ObjectIdentifier
+	}{
+		{MLDSA44, oidSignatureMLDSA44},
+		{MLDSA65, oidSignatureMLDSA65},
+		{MLDSA87, oidSignatureMLDSA87},
+	} {
+		algo, oid := a.algo, a.oid
+		if err := RegisterSignatureAlgorithm(SignatureAlgorithmDetails{
+			Algorithm:    algo,
+			OID:          oid,
+			FIPSApproved: true,
+			Verify: func(pub crypto.PublicKey, signed, signature []byte) error {
+				if impl.Algorithm(pub) != algo {
+					return errors.New("ocsp: public key does not match the ML-DSA parameter set")
+				}
+				return impl.Verify(pub, signed, signature)
+			},
+			MatchesPublicKey: func(pub crypto.PublicKey) bool {
+				return impl.Algorithm(pub) == algo
+			},
+			ParsePublicKey: func(spki []byte) (crypto.PublicKey, error) {
+				var publicKeyInfo struct {
+					Algorithm pkix.AlgorithmIdentifier
+					PublicKey asn1.BitString
+				}
+				if rest, err := asn1.Unmarshal(spki, &publicKeyInfo); err != nil {
+					return nil, err
+				} else if len(rest) != 0 {
+					return nil, errors.New("ocsp: trailing data after ML-DSA public key")
+				}
+				if !publicKeyInfo.Algorithm.Algorithm.Equal(oid) || len(publicKeyInfo.Algorithm.Parameters.FullBytes) != 0 {
+					return nil, errors.New("ocsp: public key does not match the ML-DSA parameter set")
+				}
+				return impl.NewPublicKey(algo, publicKeyInfo.PublicKey.RightAlign())
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
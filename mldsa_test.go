@@ -0,0 +1,131 @@
+//go:build mldsa && go1.27
+
+package ocsp
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type stdlibMLDSA struct{}
+
+func (stdlibMLDSA) params(algo x509.SignatureAlgorithm) (mldsa.Parameters, bool) {
+	switch algo {
+	case MLDSA44:
+		return mldsa.MLDSA44(), true
+	case MLDSA65:
+		return mldsa.MLDSA65(), true
+	case MLDSA87:
+		return mldsa.MLDSA87(), true
+	default:
+		return mldsa.Parameters{}, false
+	}
+}
+
+func (m stdlibMLDSA) NewPublicKey(algo x509.SignatureAlgorithm, encoding []byte) (crypto.PublicKey, error) {
+	params, ok := m.params(algo)
+	if !ok {
+		return nil, errors.New("unknown ML-DSA parameter set")
+	}
+	return mldsa.NewPublicKey(params, encoding)
+}
+
+func (m stdlibMLDSA) Algorithm(pub crypto.PublicKey) x509.SignatureAlgorithm {
+	key, ok := pub.(*mldsa.PublicKey)
+	if !ok {
+		return x509.UnknownSignatureAlgorithm
+	}
+	for _, algo := range []x509.SignatureAlgorithm{MLDSA44, MLDSA65, MLDSA87} {
+		if params, _ := m.params(algo); params == key.Parameters() {
+			return algo
+		}
+	}
+	return x509.UnknownSignatureAlgorithm
+}
+
+func (stdlibMLDSA) Verify(pub crypto.PublicKey, message, signature []byte) error {
+	key, ok := pub.(*mldsa.PublicKey)
+	if !ok {
+		return errors.New("not an ML-DSA key")
+	}
+	return mldsa.Verify(key, message, signature, nil)
+}
+
+func init() {
+	if err := RegisterMLDSA(stdlibMLDSA{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestOCSPResponseMLDSA(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		algo   x509.SignatureAlgorithm
+		oid    asn1.ObjectIdentifier
+		params mldsa.Parameters
+	}{
+		{MLDSA44, oidSignatureMLDSA44, mldsa.MLDSA44()},
+		{MLDSA65, oidSignatureMLDSA65, mldsa.MLDSA65()},
+		{MLDSA87, oidSignatureMLDSA87, mldsa.MLDSA87()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.params.String(), func(t *testing.T) {
+			priv, err := mldsa.GenerateKey(tc.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spki, err := asn1.Marshal(struct {
+				Algorithm pkix.AlgorithmIdentifier
+				PublicKey asn1.BitString
+			}{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: tc.oid},
+				PublicKey: asn1.BitString{Bytes: priv.PublicKey().Bytes(), BitLength: 8 * len(priv.PublicKey().Bytes())},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			responder := &x509.Certificate{
+				RawSubject:              []byte{0x30, 0},
+				RawSubjectPublicKeyInfo: spki,
+			}
+
+			der, err := CreateResponse(issuer, responder, Response{
+				Status:       Good,
+				SerialNumber: big.NewInt(1),
+				ThisUpdate:   time.Now().Truncate(time.Second),
+			}, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := ParseResponse(der, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.SignatureAlgorithm != tc.algo {
+				t.Errorf("resp.SignatureAlgorithm: got %v, want %v", resp.SignatureAlgorithm, tc.algo)
+			}
+			if err := resp.CheckSignatureFromKey(priv.Public()); err != nil {
+				t.Errorf("CheckSignatureFromKey: %v", err)
+			}
+			if err := resp.CheckSignatureFrom(responder); err != nil {
+				t.Errorf("CheckSignatureFrom: %v", err)
+			}
+			if err := resp.CheckSignatureFromKey(issuer.PublicKey); err == nil {
+				t.Error("CheckSignatureFromKey didn't fail with the wrong key")
+			}
+		})
+	}
+}
@@ -20,15 +20,26 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"math/big"
 	"strconv"
+	"sync"
 	"time"
 )
 
 var idPKIXOCSPBasic = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 48, 1, 1})
 
+var idPKIXOCSPNonce = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 48, 1, 2})
+
+var idPKIXOCSPResponse = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 48, 1, 4})
+
+var idPKIXOCSPNoCheck = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 48, 1, 5})
+
+var idPETLSFeature = asn1.ObjectIdentifier([]int{1, 3, 6, 1, 5, 5, 7, 1, 24})
+
 // ResponseStatus contains the result of an OCSP request. See
 // https://tools.ietf.org/html/rfc6960#section-2.3
 type ResponseStatus int
@@ -78,6 +89,7 @@ func (r ResponseError) Error() string {
 // response. See RFC 2560, section 4.2.
 
 type certID struct {
+	Raw           asn1.RawContent
 	HashAlgorithm pkix.AlgorithmIdentifier
 	NameHash      []byte
 	IssuerKeyHash []byte
@@ -91,8 +103,9 @@ type ocspRequest struct {
 }
 
 type tbsRequest struct {
-	Version           int              `asn1:"explicit,tag:0,default:0,optional"`
-	RequestorName     pkix.RDNSequence `asn1:"explicit,tag:1,optional"`
+	Raw               asn1.RawContent
+	Version           int           `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName     asn1.RawValue `asn1:"explicit,tag:1,optional"`
 	RequestList       []request
 	RequestExtensions []pkix.Extension `asn1:"explicit,tag:2,optional"`
 }
@@ -119,6 +132,15 @@ type basicResponse struct {
 	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
 }
 
+// rawBasicResponse is a basicResponse with the already signed
+// TBSResponseData kept as raw DER, used to re-encode parsed responses.
+type rawBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
 type responseData struct {
 	Raw                asn1.RawContent
 	Version            int `asn1:"optional,default:0,explicit,tag:0"`
@@ -129,6 +151,7 @@ type responseData struct {
 }
 
 type singleResponse struct {
+	Raw              asn1.RawContent
 	CertID           certID
 	Good             asn1.Flag        `asn1:"tag:0,optional"`
 	Revoked          revokedInfo      `asn1:"tag:1,optional"`
@@ -165,14 +188,91 @@ var (
 	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
 	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
 
+	oidSHA3_256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 8}
+	oidSHA3_384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 9}
+	oidSHA3_512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 10}
+
 	oidMGF1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
 )
 
 var hashOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
-	crypto.SHA1:   oidSHA1,
-	crypto.SHA256: oidSHA256,
-	crypto.SHA384: oidSHA384,
-	crypto.SHA512: oidSHA512,
+	crypto.SHA1:     oidSHA1,
+	crypto.SHA256:   oidSHA256,
+	crypto.SHA384:   oidSHA384,
+	crypto.SHA512:   oidSHA512,
+	crypto.SHA3_256: oidSHA3_256,
+	crypto.SHA3_384: oidSHA3_384,
+	crypto.SHA3_512: oidSHA3_512,
+}
+
+// hashFuncs contains the implementations of hash functions that cannot be
+// registered with crypto.RegisterHash, like SM3.
+var hashFuncs = map[crypto.Hash]func() hash.Hash{}
+
+// hashOIDsMu protects hashOIDs and hashFuncs from concurrent registrations.
+var hashOIDsMu sync.RWMutex
+
+// hashPools contains a *sync.Pool of hash.Hash values for each hash function,
+// used to reuse hash states when creating responses.
+var hashPools sync.Map
+
+// getHash returns a hash.Hash calculating the given hash function, reusing a
+// previous one returned with putHash if possible.
+func getHash(h crypto.Hash) (hash.Hash, bool) {
+	if p, ok := hashPools.Load(h); ok {
+		if v := p.(*sync.Pool).Get(); v != nil {
+			hh := v.(hash.Hash)
+			hh.Reset()
+			return hh, true
+		}
+	}
+	return newHash(h)
+}
+
+// putHash returns hh, calculating the given hash function, to the pool so it
+// can be reused by getHash.
+func putHash(h crypto.Hash, hh hash.Hash) {
+	p, _ := hashPools.LoadOrStore(h, new(sync.Pool))
+	p.(*sync.Pool).Put(hh)
+}
+
+// newHash returns a new hash.Hash calculating the given hash function, and
+// false if the hash function is not available.
+func newHash(h crypto.Hash) (hash.Hash, bool) {
+	hashOIDsMu.RLock()
+	f := hashFuncs[h]
+	hashOIDsMu.RUnlock()
+	if f != nil {
+		return f(), true
+	}
+	if !h.Available() {
+		return nil, false
+	}
+	return h.New(), true
+}
+
+// RegisterHash registers the object identifier of a hash function so it can be
+// used in the CertID of OCSP requests and responses. The hash implementation
+// itself must be registered with crypto.RegisterHash. RegisterHash returns an
+// error if the hash function or the OID are already registered. It is meant to
+// be called from init functions.
+func RegisterHash(hash crypto.Hash, oid asn1.ObjectIdentifier) error {
+	if hash == crypto.Hash(0) || len(oid) == 0 {
+		return errors.New("ocsp: invalid hash function or OID")
+	}
+
+	hashOIDsMu.Lock()
+	defer hashOIDsMu.Unlock()
+	if _, ok := hashOIDs[hash]; ok {
+		return fmt.Errorf("ocsp: hash function %v is already registered", hash)
+	}
+	for h, o := range hashOIDs {
+		if o.Equal(oid) {
+			return fmt.Errorf("ocsp: OID %v is already registered for hash function %v", oid, h)
+		}
+	}
+	hashOIDs[hash] = oid
+	return nil
 }
 
 // TODO(rlb): This is also from crypto/x509, so same comment as AGL's below
@@ -260,6 +360,19 @@ func signingParamsForPublicKey(pub interface{}, requestedSigAlgo x509.SignatureA
 		err = errors.New("x509: only RSA and ECDSA keys supported")
 	}
 
+	if err != nil && requestedSigAlgo == 0 {
+		if details, ok := lookupSignatureAlgorithmByKey(pub); ok {
+			return details.Hash, details.algorithmIdentifier(), nil
+		}
+	}
+
+	if details, ok := lookupSignatureAlgorithm(requestedSigAlgo); ok {
+		if details.MatchesPublicKey != nil && !details.MatchesPublicKey(pub) {
+			return nil, pkix.AlgorithmIdentifier{}, errors.New("x509: requested SignatureAlgorithm does not match private key type")
+		}
+		return details.Hash, details.algorithmIdentifier(), nil
+	}
+
 	if err != nil {
 		return
 	}
@@ -301,55 +414,208 @@ func signingParamsForPublicKey(pub interface{}, requestedSigAlgo x509.SignatureA
 
 // TODO(agl): this is taken from crypto/x509 and so should probably be exported
 // from crypto/x509 or crypto/x509/pkix.
-func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) x509.SignatureAlgorithm {
+//
+// Unlike crypto/x509, RSA PSS parameters with a salt length different from the
+// hash length are accepted, and the salt length is returned alongside the
+// signature algorithm. The salt length is zero for other algorithms.
+func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) (x509.SignatureAlgorithm, int) {
 	if !ai.Algorithm.Equal(oidSignatureRSAPSS) {
 		for _, details := range signatureAlgorithmDetails {
 			if ai.Algorithm.Equal(details.oid) {
-				return details.algo
+				return details.algo, 0
 			}
 		}
-		return x509.UnknownSignatureAlgorithm
+		if details, ok := lookupSignatureAlgorithmByOID(ai.Algorithm); ok {
+			return details.Algorithm, 0
+		}
+		return x509.UnknownSignatureAlgorithm, 0
 	}
 
 	// RSA PSS is special because it encodes important parameters
 	// in the parameters.
 	var params pssParameters
 	if _, err := asn1.Unmarshal(ai.Parameters.FullBytes, &params); err != nil {
-		return x509.UnknownSignatureAlgorithm
+		return x509.UnknownSignatureAlgorithm, 0
 	}
 
 	var mgf1HashFunc pkix.AlgorithmIdentifier
 	if _, err := asn1.Unmarshal(params.MGF.Parameters.FullBytes, &mgf1HashFunc); err != nil {
-		return x509.UnknownSignatureAlgorithm
+		return x509.UnknownSignatureAlgorithm, 0
 	}
 
 	// PSS is greatly overburdened with options. This code forces them into
 	// three buckets by requiring that the MGF1 hash function always matches the
-	// message hash function (as recommended in RFC 3447, Section 8.1), that the
-	// salt length matches the hash length, and that the trailer field has the
-	// default value.
+	// message hash function (as recommended in RFC 3447, Section 8.1), and that
+	// the trailer field has the default value.
 	if (len(params.Hash.Parameters.FullBytes) != 0 && !bytes.Equal(params.Hash.Parameters.FullBytes, asn1.NullBytes)) ||
 		!params.MGF.Algorithm.Equal(oidMGF1) ||
 		!mgf1HashFunc.Algorithm.Equal(params.Hash.Algorithm) ||
 		(len(mgf1HashFunc.Parameters.FullBytes) != 0 && !bytes.Equal(mgf1HashFunc.Parameters.FullBytes, asn1.NullBytes)) ||
-		params.TrailerField != 1 {
-		return x509.UnknownSignatureAlgorithm
+		params.TrailerField != 1 || params.SaltLength < 0 {
+		return x509.UnknownSignatureAlgorithm, 0
 	}
 
 	switch {
-	case params.Hash.Algorithm.Equal(oidSHA256) && params.SaltLength == 32:
-		return x509.SHA256WithRSAPSS
-	case params.Hash.Algorithm.Equal(oidSHA384) && params.SaltLength == 48:
-		return x509.SHA384WithRSAPSS
-	case params.Hash.Algorithm.Equal(oidSHA512) && params.SaltLength == 64:
-		return x509.SHA512WithRSAPSS
+	case params.Hash.Algorithm.Equal(oidSHA256):
+		return x509.SHA256WithRSAPSS, params.SaltLength
+	case params.Hash.Algorithm.Equal(oidSHA384):
+		return x509.SHA384WithRSAPSS, params.SaltLength
+	case params.Hash.Algorithm.Equal(oidSHA512):
+		return x509.SHA512WithRSAPSS, params.SaltLength
+	}
+
+	return x509.UnknownSignatureAlgorithm, 0
+}
+
+// marshalPSSParameters returns the RSA PSS parameters for the given hash and
+// salt length, using MGF1 with the same hash.
+func marshalPSSParameters(hash crypto.Hash, saltLength int) (asn1.RawValue, error) {
+	hashOID := getOIDFromHashAlgorithm(hash)
+	if hashOID == nil {
+		return asn1.RawValue{}, x509.ErrUnsupportedAlgorithm
+	}
+	hashAI := pkix.AlgorithmIdentifier{
+		Algorithm:  hashOID,
+		Parameters: asn1.NullRawValue,
+	}
+	mgf1Params, err := asn1.Marshal(hashAI)
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	der, err := asn1.Marshal(pssParameters{
+		Hash: hashAI,
+		MGF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidMGF1,
+			Parameters: asn1.RawValue{FullBytes: mgf1Params},
+		},
+		SaltLength:   saltLength,
+		TrailerField: 1,
+	})
+	if err != nil {
+		return asn1.RawValue{}, err
 	}
+	return asn1.RawValue{FullBytes: der}, nil
+}
 
-	return x509.UnknownSignatureAlgorithm
+// isCustomPSSSaltLength returns whether saltLength is an explicit RSA PSS salt
+// length that differs from the length of the hash used by algo.
+func isCustomPSSSaltLength(algo x509.SignatureAlgorithm, saltLength int) bool {
+	if saltLength == 0 {
+		return false
+	}
+	for _, details := range signatureAlgorithmDetails {
+		if details.algo == algo {
+			return details.isRSAPSS && saltLength != details.hash.Size()
+		}
+	}
+	return false
+}
+
+// checkCertificateSignature verifies that the signature on cert is valid
+// from parent. Certificates signed with RSA PSS salt lengths not supported by
+// crypto/x509 are verified using the parameters in the certificate.
+func checkCertificateSignature(cert, parent *x509.Certificate) error {
+	if cert.SignatureAlgorithm != x509.UnknownSignatureAlgorithm {
+		if err := checkFIPSSignature(cert.SignatureAlgorithm, parent.PublicKey); err != nil {
+			return err
+		}
+		return verificationError(parent.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+	}
+
+	var c struct {
+		TBSCertificate     asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.Raw, &c); err != nil {
+		return err
+	}
+	algo, saltLength := getSignatureAlgorithmFromAI(c.SignatureAlgorithm)
+	if details, ok := lookupSignatureAlgorithm(algo); ok {
+		if err := checkFIPSSignature(algo, nil); err != nil {
+			return err
+		}
+		pub, err := details.publicKey(parent)
+		if err != nil {
+			return err
+		}
+		return verificationError(details.Verify(pub, cert.RawTBSCertificate, cert.Signature))
+	}
+	return verificationError(checkSignature(algo, cert.RawTBSCertificate, cert.Signature, parent.PublicKey, saltLength))
+}
+
+// checkSignature verifies that signature is a valid signature over signed from
+// publicKey. It follows the rules of x509.Certificate.CheckSignature. The
+// saltLength is only used by RSA PSS signatures, if zero the salt length is
+// assumed to be equal to the hash length.
+func checkSignature(algo x509.SignatureAlgorithm, signed, signature []byte, publicKey crypto.PublicKey, saltLength int) error {
+	if err := checkFIPSSignature(algo, publicKey); err != nil {
+		return err
+	}
+	if details, ok := lookupSignatureAlgorithm(algo); ok {
+		return details.Verify(publicKey, signed, signature)
+	}
+
+	var (
+		hashType   crypto.Hash
+		pubKeyAlgo x509.PublicKeyAlgorithm
+		isRSAPSS   bool
+		found      bool
+	)
+	for _, details := range signatureAlgorithmDetails {
+		if details.algo == algo {
+			hashType = details.hash
+			pubKeyAlgo = details.pubKeyAlgo
+			isRSAPSS = details.isRSAPSS
+			found = true
+			break
+		}
+	}
+	if !found {
+		return x509.ErrUnsupportedAlgorithm
+	}
+
+	switch hashType {
+	case crypto.Hash(0):
+		return x509.ErrUnsupportedAlgorithm
+	case crypto.MD5:
+		return x509.InsecureAlgorithmError(algo)
+	}
+	if !hashType.Available() {
+		return x509.ErrUnsupportedAlgorithm
+	}
+	h := hashType.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		if pubKeyAlgo != x509.RSA {
+			return fmt.Errorf("x509: signature algorithm specifies an %s public key, but have public key of type %T", pubKeyAlgo, pub)
+		}
+		if isRSAPSS {
+			if saltLength == 0 {
+				saltLength = rsa.PSSSaltLengthEqualsHash
+			}
+			return rsa.VerifyPSS(pub, hashType, digest, signature, &rsa.PSSOptions{SaltLength: saltLength})
+		}
+		return rsa.VerifyPKCS1v15(pub, hashType, digest, signature)
+	case *ecdsa.PublicKey:
+		if pubKeyAlgo != x509.ECDSA {
+			return fmt.Errorf("x509: signature algorithm specifies an %s public key, but have public key of type %T", pubKeyAlgo, pub)
+		}
+		if !ecdsa.VerifyASN1(pub, digest, signature) {
+			return errors.New("x509: ECDSA verification failure")
+		}
+		return nil
+	}
+	return x509.ErrUnsupportedAlgorithm
 }
 
 // TODO(rlb): This is not taken from crypto/x509, but it's of the same general form.
 func getHashAlgorithmFromOID(target asn1.ObjectIdentifier) crypto.Hash {
+	hashOIDsMu.RLock()
+	defer hashOIDsMu.RUnlock()
 	for hash, oid := range hashOIDs {
 		if oid.Equal(target) {
 			return hash
@@ -359,12 +625,28 @@ func getHashAlgorithmFromOID(target asn1.ObjectIdentifier) crypto.Hash {
 }
 
 func getOIDFromHashAlgorithm(target crypto.Hash) asn1.ObjectIdentifier {
-	for hash, oid := range hashOIDs {
-		if hash == target {
-			return oid
-		}
+	hashOIDsMu.RLock()
+	defer hashOIDsMu.RUnlock()
+	return hashOIDs[target]
+}
+
+// getHashAlgorithmIdentifier returns the AlgorithmIdentifier of the given hash
+// used in a CertID. SHA-1 and SHA-2 identifiers use NULL parameters, while the
+// parameters are absent for other hash functions, as described in RFC 8702.
+func getHashAlgorithmIdentifier(target crypto.Hash) (pkix.AlgorithmIdentifier, bool) {
+	oid := getOIDFromHashAlgorithm(target)
+	if oid == nil {
+		return pkix.AlgorithmIdentifier{}, false
+	}
+	switch target {
+	case crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512:
+		return pkix.AlgorithmIdentifier{
+			Algorithm:  oid,
+			Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
+		}, true
+	default:
+		return pkix.AlgorithmIdentifier{Algorithm: oid}, true
 	}
-	return nil
 }
 
 // This is the exposed reflection of the internal OCSP structures.
@@ -405,27 +687,62 @@ type Request struct {
 	IssuerKeyHash  []byte
 	SerialNumber   *big.Int
 	Extensions     []pkix.Extension
+
+	// RawRequestorName optionally contains the DER-encoded directory name
+	// identifying the requestor. When marshaling, it takes precedence over
+	// RequestorName.
+	RawRequestorName []byte
+	// RequestorName contains the parsed value of RawRequestorName. It is nil
+	// if the request does not identify the requestor.
+	RequestorName *pkix.Name
+
+	// AcceptableResponses contains the response types accepted by the
+	// client, from the AcceptableResponses extension. See RFC 6960, section
+	// 4.4.3. When parsing, it is empty if the extension is absent or
+	// malformed, as the extension is only advisory. When marshaling, the
+	// extension is added if it is not empty, replacing any
+	// AcceptableResponses extension in Extensions.
+	AcceptableResponses []asn1.ObjectIdentifier
+
+	// Nonce contains the value of the nonce extension of the request. See
+	// RFC 8954. When parsing, it is nil if the extension is absent or is not
+	// a valid OCTET STRING, as sent by some clients, whose raw value remains
+	// available in Extensions. When marshaling, the extension is added if
+	// Nonce is not empty, replacing any nonce extension in Extensions.
+	Nonce []byte
+
+	// Version is the version of the parsed request, 0 for v1, the only one
+	// defined by RFC 6960. It is ignored when marshaling.
+	Version int
 }
 
-// Marshal marshals the OCSP request to ASN.1 DER encoded form.
+// Marshal marshals the OCSP request to ASN.1 DER encoded form, including its
+// Extensions.
 func (req *Request) Marshal() ([]byte, error) {
-	hashAlg := getOIDFromHashAlgorithm(req.HashAlgorithm)
-	if hashAlg == nil {
-		return nil, errors.New("unknown hash algorithm")
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	hashAlg, _ := getHashAlgorithmIdentifier(req.HashAlgorithm)
+	requestorName, err := req.marshalRequestorName()
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := req.marshalExtensions()
+	if err != nil {
+		return nil, err
 	}
 	return asn1.Marshal(ocspRequest{
 		tbsRequest{
-			Version: 0,
+			Version:           0,
+			RequestorName:     requestorName,
+			RequestExtensions: extensions,
 			RequestList: []request{
 				{
 					Cert: certID{
-						pkix.AlgorithmIdentifier{
-							Algorithm:  hashAlg,
-							Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
-						},
-						req.IssuerNameHash,
-						req.IssuerKeyHash,
-						req.SerialNumber,
+						HashAlgorithm: hashAlg,
+						NameHash:      req.IssuerNameHash,
+						IssuerKeyHash: req.IssuerKeyHash,
+						SerialNumber:  req.SerialNumber,
 					},
 				},
 			},
@@ -433,32 +750,166 @@ func (req *Request) Marshal() ([]byte, error) {
 	})
 }
 
+// CertID identifies a certificate by the hashes of its issuer and its serial
+// number. See RFC 6960, section 4.1.1.
+type CertID struct {
+	HashAlgorithm  crypto.Hash
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// CertID returns the CertID of the certificate the request is for.
+func (req *Request) CertID() *CertID {
+	return &CertID{
+		HashAlgorithm:  req.HashAlgorithm,
+		IssuerNameHash: req.IssuerNameHash,
+		IssuerKeyHash:  req.IssuerKeyHash,
+		SerialNumber:   req.SerialNumber,
+	}
+}
+
+// marshalExtensions returns the request extensions, adding the nonce and
+// AcceptableResponses extensions if needed.
+func (req *Request) marshalExtensions() ([]pkix.Extension, error) {
+	extensions, err := withNonce(req.Extensions, req.Nonce)
+	if err != nil || len(req.AcceptableResponses) == 0 {
+		return extensions, err
+	}
+	value, err := asn1.Marshal(req.AcceptableResponses)
+	if err != nil {
+		return nil, err
+	}
+	return replaceExtension(extensions, pkix.Extension{Id: idPKIXOCSPResponse, Value: value}), nil
+}
+
+// withNonce returns extensions with the nonce extension set to nonce, if
+// nonce is not empty.
+func withNonce(extensions []pkix.Extension, nonce []byte) ([]pkix.Extension, error) {
+	if len(nonce) == 0 {
+		return extensions, nil
+	}
+	value, err := asn1.Marshal(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return replaceExtension(extensions, pkix.Extension{Id: idPKIXOCSPNonce, Value: value}), nil
+}
+
+// replaceExtension returns a copy of extensions without the extensions with
+// the object identifier of ext, and with ext at the end.
+func replaceExtension(extensions []pkix.Extension, ext pkix.Extension) []pkix.Extension {
+	ret := make([]pkix.Extension, 0, len(extensions)+1)
+	for _, e := range extensions {
+		if !e.Id.Equal(ext.Id) {
+			ret = append(ret, e)
+		}
+	}
+	return append(ret, ext)
+}
+
+// AcceptsResponseType reports whether the client accepts responses of the
+// given type. It returns true if the request does not have the
+// AcceptableResponses extension.
+func (req *Request) AcceptsResponseType(responseType asn1.ObjectIdentifier) bool {
+	if len(req.AcceptableResponses) == 0 {
+		return true
+	}
+	for _, oid := range req.AcceptableResponses {
+		if oid.Equal(responseType) {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsBasicResponse reports whether the client accepts basic responses,
+// the only type created by CreateResponse. Responders should reply with an
+// error response, like UnauthorizedErrorResponse, to requests that do not
+// accept them.
+func (req *Request) AcceptsBasicResponse() bool {
+	return req.AcceptsResponseType(idPKIXOCSPBasic)
+}
+
+// marshalRequestorName returns the requestorName field of the request, a
+// directoryName GeneralName with an explicit tag. The explicit tag is encoded
+// here because encoding/asn1 does not add it to RawValue fields.
+func (req *Request) marshalRequestorName() (asn1.RawValue, error) {
+	rawName := req.RawRequestorName
+	if len(rawName) == 0 {
+		if req.RequestorName == nil {
+			return asn1.RawValue{}, nil
+		}
+		var err error
+		if rawName, err = asn1.Marshal(req.RequestorName.ToRDNSequence()); err != nil {
+			return asn1.RawValue{}, err
+		}
+	}
+	generalName, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: rawName})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	fullBytes, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: generalName})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{FullBytes: fullBytes}, nil
+}
+
 // Response represents an OCSP response containing a single SingleResponse. See
 // RFC 6960.
 type Response struct {
 	Raw []byte
 
+	// Version is the version of the parsed response, 0 for v1, the only one
+	// defined by RFC 6960. It is ignored when creating responses.
+	Version int
+
 	// Status is one of {Good, Revoked, Unknown}
 	Status                                        int
 	SerialNumber                                  *big.Int
 	ProducedAt, ThisUpdate, NextUpdate, RevokedAt time.Time
 	RevocationReason                              int
 	Certificate                                   *x509.Certificate
+	// CertificateChain contains the certificates embedded in the response
+	// after Certificate, usually the intermediates between the responder
+	// certificate and the issuer. When creating responses, they are embedded
+	// after Certificate, which is then required.
+	CertificateChain []*x509.Certificate
 	// TBSResponseData contains the raw bytes of the signed response. If
 	// Certificate is nil then this can be used to verify Signature.
 	TBSResponseData    []byte
 	Signature          []byte
 	SignatureAlgorithm x509.SignatureAlgorithm
 
+	// PSSSaltLength is the salt length of RSA PSS signatures. It is populated
+	// when parsing responses signed with RSA PSS. When creating responses with
+	// an RSA PSS SignatureAlgorithm, it sets the salt length to use. If zero,
+	// the salt length is equal to the hash length.
+	PSSSaltLength int
+
 	// IssuerHash is the hash used to compute the IssuerNameHash and IssuerKeyHash.
-	// Valid values are crypto.SHA1, crypto.SHA256, crypto.SHA384, and crypto.SHA512.
+	// Valid values are crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512,
+	// crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512, and any hash
+	// registered with RegisterHash. The implementation of the hash function
+	// must be linked into the binary.
 	// If zero, the default is crypto.SHA1.
 	IssuerHash crypto.Hash
 
+	// IssuerNameHash and IssuerKeyHash contain the hashes of the issuer name
+	// and public key in the CertID, calculated with IssuerHash. They are
+	// populated when parsing. When creating responses, if both are set they
+	// are used instead of calculating them from the issuer certificate.
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+
 	// RawResponderName optionally contains the DER-encoded subject of the
 	// responder certificate. Exactly one of RawResponderName and
 	// ResponderKeyHash is set.
 	RawResponderName []byte
+	// ResponderName contains the parsed value of RawResponderName. It is nil
+	// if the responder is identified by ResponderKeyHash.
+	ResponderName *pkix.Name
 	// ResponderKeyHash optionally contains the SHA-1 hash of the
 	// responder's public key. Exactly one of RawResponderName and
 	// ResponderKeyHash is set.
@@ -490,6 +941,33 @@ type Response struct {
 	// other fields. The ResponseExtraExtensions field is not populated when
 	// parsing certificates, see ResponseExtensions.
 	ResponseExtraExtensions []pkix.Extension
+
+	// Nonce contains the value of the nonce response extension, echoing the
+	// one of the request. See RFC 8954. When parsing, it is nil if the
+	// extension is absent or is not a valid OCTET STRING, as produced by some
+	// old responders. When marshaling, the extension is added to the
+	// responseExtensions if Nonce is not empty, replacing any nonce extension
+	// in ResponseExtraExtensions.
+	Nonce []byte
+
+	// RawSingleResponse contains the DER-encoded SingleResponse of the
+	// certificate status. It is populated when parsing and can be used to key
+	// caches or to archive the exact signed structure of each certificate.
+	RawSingleResponse []byte
+	// RawCertID contains the DER-encoded CertID of the certificate status. It
+	// is populated when parsing.
+	RawCertID []byte
+
+	// Duplicates contains the other statuses matching the same certificate
+	// in the response, when parsed with the DuplicateAll policy.
+	Duplicates []SingleResponse
+
+	// rawSignatureAlgorithm, rawSignature and rawCertificates keep the parsed
+	// values of the basicResponse fields, so Marshal can reproduce the
+	// original encoding.
+	rawSignatureAlgorithm pkix.AlgorithmIdentifier
+	rawSignature          asn1.BitString
+	rawCertificates       []asn1.RawValue
 }
 
 // These are pre-serialized error responses for the various non-success codes
@@ -510,7 +988,346 @@ var (
 // signature. That signature is checked by ParseResponse and only
 // resp.Certificate remains to be validated.
 func (resp *Response) CheckSignatureFrom(issuer *x509.Certificate) error {
-	return issuer.CheckSignature(resp.SignatureAlgorithm, resp.TBSResponseData, resp.Signature)
+	if err := checkFIPSSignature(resp.SignatureAlgorithm, issuer.PublicKey); err != nil {
+		return err
+	}
+	if details, ok := lookupSignatureAlgorithm(resp.SignatureAlgorithm); ok {
+		pub, err := details.publicKey(issuer)
+		if err != nil {
+			return err
+		}
+		return verificationError(details.Verify(pub, resp.TBSResponseData, resp.Signature))
+	}
+	if isCustomPSSSaltLength(resp.SignatureAlgorithm, resp.PSSSaltLength) {
+		return verificationError(checkSignature(resp.SignatureAlgorithm, resp.TBSResponseData, resp.Signature, issuer.PublicKey, resp.PSSSaltLength))
+	}
+	return verificationError(issuer.CheckSignature(resp.SignatureAlgorithm, resp.TBSResponseData, resp.Signature))
+}
+
+// CheckSignatureFromKey checks that the signature in resp is a valid signature
+// made by the private key corresponding to pub. It can be used instead of
+// CheckSignatureFrom when only the responder public key is known.
+func (resp *Response) CheckSignatureFromKey(pub crypto.PublicKey) error {
+	return verificationError(checkSignature(resp.SignatureAlgorithm, resp.TBSResponseData, resp.Signature, pub, resp.PSSSaltLength))
+}
+
+// ResponderIDString returns a printable representation of the responder ID of
+// resp: the string form of ResponderName, or the hex-encoded ResponderKeyHash
+// prefixed by "keyHash:". It is not named String because that would make
+// Response a fmt.Stringer, and printing a response with %v would then only
+// show its responder ID.
+func (resp *Response) ResponderIDString() string {
+	switch {
+	case resp.ResponderName != nil:
+		return resp.ResponderName.String()
+	case len(resp.ResponderKeyHash) > 0:
+		return "keyHash:" + hex.EncodeToString(resp.ResponderKeyHash)
+	default:
+		return ""
+	}
+}
+
+// FindResponder returns the certificate in certs that matches the responder ID
+// of resp and that created its signature. Certificates are matched by comparing
+// RawResponderName with their DER-encoded subject, or ResponderKeyHash with the
+// SHA-1 hash of their public key.
+func (resp *Response) FindResponder(certs []*x509.Certificate) (*x509.Certificate, error) {
+	var sigErr error
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		switch {
+		case len(resp.RawResponderName) > 0:
+			if !bytes.Equal(resp.RawResponderName, cert.RawSubject) {
+				continue
+			}
+		case len(resp.ResponderKeyHash) > 0:
+			keyHash, err := publicKeyHash(cert, crypto.SHA1)
+			if err != nil || !bytes.Equal(resp.ResponderKeyHash, keyHash) {
+				continue
+			}
+		default:
+			return nil, errors.New("ocsp: response does not contain a responder ID")
+		}
+		if err := resp.CheckSignatureFrom(cert); err != nil {
+			sigErr = err
+			continue
+		}
+		return cert, nil
+	}
+	if sigErr != nil {
+		return nil, &parseSignatureError{msg: "bad OCSP signature: ", err: sigErr}
+	}
+	return nil, errors.New("ocsp: no certificate matching the responder ID")
+}
+
+// Marshal returns the DER encoding of a parsed OCSP response. The signed
+// TBSResponseData, the signature and the embedded certificates are re-encoded
+// as they were parsed, so the result matches resp.Raw byte-for-byte.
+//
+// Marshal cannot sign, so it returns an error if resp was not obtained from
+// ParseResponse or ParseResponseForCert. To create a new response based on a
+// parsed one use Template and CreateResponse.
+func (resp *Response) Marshal() ([]byte, error) {
+	if len(resp.TBSResponseData) == 0 || len(resp.rawSignature.Bytes) == 0 {
+		return nil, errors.New("ocsp: cannot marshal a response that has not been parsed")
+	}
+
+	responseDER, err := asn1.Marshal(rawBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: resp.TBSResponseData},
+		SignatureAlgorithm: resp.rawSignatureAlgorithm,
+		Signature:          resp.rawSignature,
+		Certificates:       resp.rawCertificates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(responseASN1{
+		Status: asn1.Enumerated(Success),
+		Response: responseBytes{
+			ResponseType: idPKIXOCSPBasic,
+			Response:     responseDER,
+		},
+	})
+}
+
+// Template returns a copy of a parsed response that can be used as the
+// template argument of CreateResponse. The parsed Extensions and
+// ResponseExtensions are copied to ExtraExtensions and
+// ResponseExtraExtensions, and the fields describing the original signature
+// are cleared.
+func (resp *Response) Template() Response {
+	template := Response{
+		Status:                  resp.Status,
+		SerialNumber:            resp.SerialNumber,
+		ProducedAt:              resp.ProducedAt,
+		ThisUpdate:              resp.ThisUpdate,
+		NextUpdate:              resp.NextUpdate,
+		RevokedAt:               resp.RevokedAt,
+		RevocationReason:        resp.RevocationReason,
+		Certificate:             resp.Certificate,
+		CertificateChain:        resp.CertificateChain,
+		SignatureAlgorithm:      resp.SignatureAlgorithm,
+		PSSSaltLength:           resp.PSSSaltLength,
+		IssuerHash:              resp.IssuerHash,
+		IssuerNameHash:          resp.IssuerNameHash,
+		IssuerKeyHash:           resp.IssuerKeyHash,
+		ExtraExtensions:         resp.ExtraExtensions,
+		ResponseExtraExtensions: resp.ResponseExtraExtensions,
+		Nonce:                   resp.Nonce,
+	}
+	if template.ExtraExtensions == nil {
+		template.ExtraExtensions = resp.Extensions
+	}
+	if template.ResponseExtraExtensions == nil {
+		template.ResponseExtraExtensions = resp.ResponseExtensions
+	}
+	return template
+}
+
+// HasNoCheck reports whether cert contains the id-pkix-ocsp-nocheck extension.
+// As described in RFC 6960, section 4.2.2.2.1, clients can trust a responder
+// certificate with this extension for its lifetime, without checking its
+// revocation status.
+func HasNoCheck(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(idPKIXOCSPNoCheck) {
+			return true
+		}
+	}
+	return false
+}
+
+// tlsFeatureStatusRequest is the TLS extension number of status_request.
+const tlsFeatureStatusRequest = 5
+
+// MustStaple reports whether cert contains the TLS Feature extension defined
+// in RFC 7633 with the status_request feature, also known as OCSP Must-Staple.
+// TLS clients should reject such a certificate if it is not presented with a
+// valid OCSP response, regardless of their soft-fail policy.
+func MustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPETLSFeature) {
+			continue
+		}
+		var features []int
+		if rest, err := asn1.Unmarshal(ext.Value, &features); err != nil || len(rest) != 0 {
+			return false
+		}
+		for _, f := range features {
+			if f == tlsFeatureStatusRequest {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ResponderRevocationCheckRequired reports whether the revocation status of
+// the responder certificate embedded in resp should be checked. It is false if
+// the response does not embed a certificate, as it must then be signed by the
+// issuer, or if the certificate contains the id-pkix-ocsp-nocheck extension.
+// Clients with a stricter policy may check the responder certificate anyway.
+func (resp *Response) ResponderRevocationCheckRequired() bool {
+	return resp.Certificate != nil && !HasNoCheck(resp.Certificate)
+}
+
+// HasNextUpdate reports whether the response includes a nextUpdate time. RFC
+// 6960, Section 2.4 allows responders to omit it to indicate that newer
+// revocation information is available all the time, in which case NextUpdate
+// is the zero time.
+func (resp *Response) HasNextUpdate() bool {
+	return !resp.NextUpdate.IsZero()
+}
+
+// ValidAt reports whether t is within the validity interval of the response,
+// that is, not before ThisUpdate and before NextUpdate. A response without
+// NextUpdate is valid at any time after ThisUpdate.
+func (resp *Response) ValidAt(t time.Time) bool {
+	if t.Before(resp.ThisUpdate) {
+		return false
+	}
+	return !resp.Expired(t)
+}
+
+// Expired reports whether the NextUpdate time of the response is not after
+// now. A response without NextUpdate never expires.
+func (resp *Response) Expired(now time.Time) bool {
+	return resp.HasNextUpdate() && !now.Before(resp.NextUpdate)
+}
+
+// TTL returns the time remaining until NextUpdate. It returns zero if the
+// response is expired or does not have a NextUpdate time.
+func (resp *Response) TTL(now time.Time) time.Duration {
+	if !resp.HasNextUpdate() || resp.Expired(now) {
+		return 0
+	}
+	return resp.NextUpdate.Sub(now)
+}
+
+// CompareFreshness compares two responses for the same certificate and returns
+// +1 if a is fresher than b, -1 if b is fresher than a, and 0 if both are
+// equally fresh. The response with the later ThisUpdate is fresher; if both
+// have the same ThisUpdate, the one with the later ProducedAt is fresher.
+//
+// Caches should only replace a response with one that is strictly fresher, so
+// an older response replayed by a responder or an attacker does not replace a
+// newer one. The caches in this package do so. An error is returned if the
+// responses are not for the same certificate, or if they use different CertID
+// hash algorithms, as their issuers cannot be compared then.
+func CompareFreshness(a, b *Response) (int, error) {
+	if a.IssuerHash != b.IssuerHash {
+		return 0, errors.New("ocsp: responses use different CertID hash algorithms")
+	}
+	if !sameCertID(a, b) {
+		return 0, errors.New("ocsp: responses are not for the same certificate")
+	}
+	if c := compareTime(a.ThisUpdate, b.ThisUpdate); c != 0 {
+		return c, nil
+	}
+	return compareTime(a.ProducedAt, b.ProducedAt), nil
+}
+
+// sameCertID reports whether a and b, using the same hash algorithm, are for
+// the same certificate.
+func sameCertID(a, b *Response) bool {
+	if a.SerialNumber == nil || b.SerialNumber == nil || a.SerialNumber.Cmp(b.SerialNumber) != 0 {
+		return false
+	}
+	return bytes.Equal(a.IssuerNameHash, b.IssuerNameHash) && bytes.Equal(a.IssuerKeyHash, b.IssuerKeyHash)
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.After(b):
+		return 1
+	case a.Before(b):
+		return -1
+	default:
+		return 0
+	}
+}
+
+// VerifyChain verifies the responder certificate embedded in resp by building
+// one or more chains to opts.Roots. Additional certificates embedded in the
+// response, in CertificateChain, are used as intermediates, together with
+// opts.Intermediates. If opts.KeyUsages is empty, the responder certificate is
+// required to have the OCSPSigning extended key usage.
+//
+// VerifyChain does not check the signature of the response, which is checked
+// with the embedded certificate by ParseResponse.
+func (resp *Response) VerifyChain(opts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	if resp.Certificate == nil {
+		return nil, errors.New("ocsp: response does not contain a responder certificate")
+	}
+
+	if len(resp.CertificateChain) > 0 {
+		if opts.Intermediates == nil {
+			opts.Intermediates = x509.NewCertPool()
+		} else {
+			opts.Intermediates = opts.Intermediates.Clone()
+		}
+		for _, cert := range resp.CertificateChain {
+			opts.Intermediates.AddCert(cert)
+		}
+	}
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+	}
+
+	return resp.Certificate.Verify(opts)
+}
+
+// Verify checks the signature of a response parsed with
+// ParseOptions.SkipSignatureVerification. If the response contains a
+// certificate, the response signature is checked with it, and the certificate
+// signature is checked with issuer, if not nil. Otherwise the response
+// signature is checked with issuer, which is then required.
+func (resp *Response) Verify(issuer *x509.Certificate) error {
+	if resp.Certificate == nil && issuer == nil {
+		return errors.New("ocsp: issuer certificate is required to verify a response without a responder certificate")
+	}
+	return resp.verify(issuer)
+}
+
+// verify performs the signature checks done by ParseResponseForCert.
+func (resp *Response) verify(issuer *x509.Certificate) error {
+	if resp.Certificate != nil {
+		if err := resp.CheckSignatureFrom(resp.Certificate); err != nil {
+			return &parseSignatureError{msg: "bad signature on embedded certificate: ", err: err}
+		}
+
+		if issuer != nil {
+			if err := resp.checkChainSignatures(issuer); err != nil {
+				return &parseSignatureError{msg: "bad OCSP signature: ", err: err}
+			}
+		}
+	} else if issuer != nil {
+		if err := resp.CheckSignatureFrom(issuer); err != nil {
+			return &parseSignatureError{msg: "bad OCSP signature: ", err: err}
+		}
+	}
+	return nil
+}
+
+// checkChainSignatures checks that the responder certificate is signed by
+// issuer, either directly or through the certificates in CertificateChain,
+// each one signing the previous one. It only checks the signatures, use
+// VerifyChain to validate the chain.
+func (resp *Response) checkChainSignatures(issuer *x509.Certificate) error {
+	cert := resp.Certificate
+	for _, next := range resp.CertificateChain {
+		if checkCertificateSignature(cert, issuer) == nil {
+			return nil
+		}
+		if err := checkCertificateSignature(cert, next); err != nil {
+			return err
+		}
+		cert = next
+	}
+	return checkCertificateSignature(cert, issuer)
 }
 
 // ParseError results from an invalid OCSP response.
@@ -524,6 +1341,22 @@ func (p ParseError) Error() string {
 // requests for a single certificate. Signed requests are not supported.
 // If a request includes a signature, it will result in a ParseError.
 func ParseRequest(der []byte) (*Request, error) {
+	return ParseRequestWithOptions(der, nil)
+}
+
+// ParseRequestWithOptions is like ParseRequest, but it takes options to
+// configure the parsing. Only opts.Limits and opts.StrictVersion apply to
+// requests. If opts is nil, it behaves like ParseRequest.
+func ParseRequestWithOptions(der []byte, opts *ParseOptions) (*Request, error) {
+	limits := opts.limits()
+	if err := limits.checkSize(der); err != nil {
+		return nil, err
+	}
+	stats := opts.parseStats()
+	if err := limits.scanRequest(der, stats); err != nil {
+		return nil, err
+	}
+
 	var req ocspRequest
 	rest, err := asn1.Unmarshal(der, &req)
 	if err != nil {
@@ -532,6 +1365,7 @@ func ParseRequest(der []byte) (*Request, error) {
 	if len(rest) > 0 {
 		return nil, ParseError("trailing data in OCSP request")
 	}
+	stats.examined(len(der))
 
 	if len(req.TBSRequest.RequestList) == 0 {
 		return nil, ParseError("OCSP request contains no request body")
@@ -543,13 +1377,57 @@ func ParseRequest(der []byte) (*Request, error) {
 		return nil, ParseError("OCSP request uses unknown hash function")
 	}
 
-	return &Request{
+	if opts.strictVersion() {
+		if err := checkVersion(req.TBSRequest.Version, req.TBSRequest.Raw); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := &Request{
 		HashAlgorithm:  hashFunc,
 		IssuerNameHash: innerRequest.Cert.NameHash,
 		IssuerKeyHash:  innerRequest.Cert.IssuerKeyHash,
 		SerialNumber:   innerRequest.Cert.SerialNumber,
 		Extensions:     req.TBSRequest.RequestExtensions,
-	}, nil
+		Version:        req.TBSRequest.Version,
+	}
+
+	for _, ext := range ret.Extensions {
+		switch {
+		case ext.Id.Equal(idPKIXOCSPResponse):
+			var acceptable []asn1.ObjectIdentifier
+			if rest, err := asn1.Unmarshal(ext.Value, &acceptable); err == nil && len(rest) == 0 {
+				ret.AcceptableResponses = acceptable
+			}
+		case ext.Id.Equal(idPKIXOCSPNonce):
+			var nonce []byte
+			if rest, err := asn1.Unmarshal(ext.Value, &nonce); err == nil && len(rest) == 0 {
+				ret.Nonce = nonce
+			}
+		}
+	}
+
+	// The requestorName is an explicitly tagged GeneralName, of which only
+	// the directoryName form is supported. encoding/asn1 keeps the explicit
+	// tag of RawValue fields.
+	if rawRequestorName := req.TBSRequest.RequestorName; len(rawRequestorName.FullBytes) > 0 {
+		var generalName asn1.RawValue
+		if rest, err := asn1.Unmarshal(rawRequestorName.Bytes, &generalName); err != nil || len(rest) != 0 {
+			return nil, ParseError("invalid requestor name")
+		}
+		if generalName.Class != asn1.ClassContextSpecific || generalName.Tag != 4 || !generalName.IsCompound {
+			return nil, ParseError("unsupported requestor name")
+		}
+		var rdn pkix.RDNSequence
+		if rest, err := asn1.Unmarshal(generalName.Bytes, &rdn); err != nil || len(rest) != 0 {
+			return nil, ParseError("invalid requestor name")
+		}
+		ret.RawRequestorName = generalName.Bytes
+		ret.RequestorName = new(pkix.Name)
+		ret.RequestorName.FillFromRDNSequence(&rdn)
+	}
+
+	return ret, nil
 }
 
 // ParseResponse parses an OCSP response in DER form. The response must contain
@@ -574,9 +1452,201 @@ func ParseResponse(der []byte, issuer *x509.Certificate) (*Response, error) {
 // ParseResponseForCert acts identically to ParseResponse, except it supports
 // parsing responses that contain multiple statuses. If the response contains
 // multiple statuses and cert is not nil, then ParseResponseForCert will return
-// the first status which contains a matching serial, otherwise it will return an
-// error. If cert is nil, then the first status in the response will be returned.
+// the first status which contains a matching serial, otherwise it will return
+// an error. If several statuses match and issuer is not nil, the ones whose
+// issuer key hash matches issuer are preferred. If cert is nil, then the first
+// status in the response will be returned.
 func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response, error) {
+	return ParseResponseWithOptions(der, cert, issuer, nil)
+}
+
+// ParseOptions contains options for ParseResponseWithOptions and
+// ParseRequestWithOptions.
+type ParseOptions struct {
+	// SkipSignatureVerification disables the verification of the response
+	// signature and of the embedded certificate. It is useful when the
+	// response is only inspected or served again as is. The signatures can be
+	// verified later with Response.Verify.
+	SkipSignatureVerification bool
+
+	// Limits bounds the resources used to parse the input. The zero value
+	// sets no limits; use DefaultLimits when parsing untrusted input.
+	Limits Limits
+
+	// Validation restricts the algorithms and keys accepted in the response.
+	// It is checked even if SkipSignatureVerification is set.
+	Validation ValidationOptions
+
+	// CriticalExtensionHandler is called with each critical singleExtension
+	// of the response, which this package does not support. If it returns
+	// nil, the caller handles the extension and the response is accepted;
+	// otherwise parsing fails with an error wrapping the returned one. If
+	// nil, responses with critical single extensions are rejected.
+	CriticalExtensionHandler func(ext pkix.Extension) error
+
+	// StrictVersion rejects responses and requests with a version other
+	// than v1, or with the default version explicitly encoded, which is not
+	// allowed by DER but produced by some responders.
+	StrictVersion bool
+
+	// Duplicates sets how a response with several statuses matching the
+	// requested certificate is parsed. The default is DuplicateFirst.
+	Duplicates DuplicatePolicy
+
+	// stats, if not nil, receives the work done by the parser.
+	stats *ParseStats
+}
+
+func (opts *ParseOptions) parseStats() *ParseStats {
+	if opts == nil {
+		return nil
+	}
+	return opts.stats
+}
+
+// DuplicatePolicy sets how a response with several statuses matching the
+// same certificate is parsed. Such responses are not allowed by RFC 6960, but
+// they are produced by some misbehaving responders.
+type DuplicatePolicy int
+
+const (
+	// DuplicateFirst selects the first matching status.
+	DuplicateFirst DuplicatePolicy = iota
+	// DuplicateError rejects the response.
+	DuplicateError
+	// DuplicateFreshest selects the matching status with the latest
+	// ThisUpdate, or the first one of those with the same ThisUpdate.
+	DuplicateFreshest
+	// DuplicateAll selects the first matching status, and returns the other
+	// ones in Response.Duplicates.
+	DuplicateAll
+)
+
+func (opts *ParseOptions) duplicates() DuplicatePolicy {
+	if opts == nil {
+		return DuplicateFirst
+	}
+	return opts.Duplicates
+}
+
+func (opts *ParseOptions) strictVersion() bool {
+	return opts != nil && opts.StrictVersion
+}
+
+func (opts *ParseOptions) skipSignatureVerification() bool {
+	return opts != nil && opts.SkipSignatureVerification
+}
+
+func (opts *ParseOptions) limits() Limits {
+	if opts == nil {
+		return Limits{}
+	}
+	return opts.Limits
+}
+
+// checkVersion returns an error if version is not v1, or if it is explicitly
+// encoded in the DER-encoded structure der, which starts with the version.
+func checkVersion(version int, der []byte) error {
+	if version != 0 {
+		return ParseError("unsupported version " + strconv.Itoa(version))
+	}
+	var v struct {
+		Version asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	}
+	if _, err := asn1.Unmarshal(der, &v); err != nil {
+		return err
+	}
+	if len(v.Version.FullBytes) > 0 {
+		return ParseError("explicitly encoded default version")
+	}
+	return nil
+}
+
+// checkCriticalExtension returns an error unless the critical extension ext
+// is accepted by the CriticalExtensionHandler.
+func (opts *ParseOptions) checkCriticalExtension(ext pkix.Extension) error {
+	if opts == nil || opts.CriticalExtensionHandler == nil {
+		return ParseError("unsupported critical extension")
+	}
+	if err := opts.CriticalExtensionHandler(ext); err != nil {
+		return fmt.Errorf("ocsp: unsupported critical extension %v: %w", ext.Id, err)
+	}
+	return nil
+}
+
+func (opts *ParseOptions) validation() *ValidationOptions {
+	if opts == nil {
+		return &ValidationOptions{}
+	}
+	return &opts.Validation
+}
+
+// ParseResponseWithOptions is like ParseResponseForCert, but it takes options
+// to configure the parsing. If opts is nil, it behaves like
+// ParseResponseForCert.
+func ParseResponseWithOptions(der []byte, cert, issuer *x509.Certificate, opts *ParseOptions) (*Response, error) {
+	var match, prefer func(*certID) bool
+	if cert != nil {
+		match = func(id *certID) bool {
+			return cert.SerialNumber.Cmp(id.SerialNumber) == 0
+		}
+		if issuer != nil {
+			prefer = issuerKeyMatcher(issuer, cert.SerialNumber)
+		}
+	}
+	return parseResponse(der, match, prefer, "certificate", issuer, opts)
+}
+
+// ParseResponseForCertID is like ParseResponseForCert, but it returns the
+// status matching all the fields of id, for callers that do not have the
+// certificate.
+func ParseResponseForCertID(der []byte, id *CertID, issuer *x509.Certificate) (*Response, error) {
+	match, err := certIDMatcher(id)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(der, match, nil, "CertID", issuer, nil)
+}
+
+// certIDMatcher returns a function reporting whether a parsed CertID is equal
+// to id.
+func certIDMatcher(id *CertID) (func(*certID) bool, error) {
+	if id == nil || id.SerialNumber == nil {
+		return nil, errors.New("ocsp: CertID with a serial number is required")
+	}
+	oid := getOIDFromHashAlgorithm(id.HashAlgorithm)
+	if oid == nil {
+		return nil, x509.ErrUnsupportedAlgorithm
+	}
+	return func(c *certID) bool {
+		return c.HashAlgorithm.Algorithm.Equal(oid) &&
+			bytes.Equal(c.NameHash, id.IssuerNameHash) &&
+			bytes.Equal(c.IssuerKeyHash, id.IssuerKeyHash) &&
+			id.SerialNumber.Cmp(c.SerialNumber) == 0
+	}, nil
+}
+
+// ParseResponseForSerial is like ParseResponseForCert, but it returns the
+// status for the given serial number, for callers that do not have the
+// certificate.
+func ParseResponseForSerial(der []byte, serial *big.Int, issuer *x509.Certificate) (*Response, error) {
+	if serial == nil {
+		return nil, errors.New("ocsp: serial number is required")
+	}
+	return parseResponse(der, func(id *certID) bool {
+		return serial.Cmp(id.SerialNumber) == 0
+	}, nil, "serial number", issuer, nil)
+}
+
+// parseResponse parses the status selected by match, or the only status in
+// the response if match is nil. If several statuses match and prefer is not
+// nil, the ones selected by prefer are used, if any. The matched value is
+// described by what in errors.
+func parseResponse(der []byte, match, prefer func(*certID) bool, what string, issuer *x509.Certificate, opts *ParseOptions) (*Response, error) {
+	limits := opts.limits()
+	if err := limits.checkSize(der); err != nil {
+		return nil, err
+	}
 	var resp responseASN1
 	rest, err := asn1.Unmarshal(der, &resp)
 	if err != nil {
@@ -585,6 +1655,11 @@ func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response
 	if len(rest) > 0 {
 		return nil, ParseError("trailing data in OCSP response")
 	}
+	// The BasicOCSPResponse, if any, is at the end of the input, which was
+	// examined up to it.
+	basicDER := resp.Response.Response
+	stats := opts.parseStats()
+	stats.examined(len(der) - len(basicDER))
 
 	if status := ResponseStatus(resp.Status); status != Success {
 		return nil, ResponseError{status}
@@ -594,47 +1669,92 @@ func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response
 		return nil, ParseError("bad OCSP response type")
 	}
 
+	if err := limits.scanResponse(basicDER, len(der)-len(basicDER), stats); err != nil {
+		return nil, err
+	}
+
 	var basicResp basicResponse
-	rest, err = asn1.Unmarshal(resp.Response.Response, &basicResp)
+	rest, err = asn1.Unmarshal(basicDER, &basicResp)
 	if err != nil {
 		return nil, err
 	}
 	if len(rest) > 0 {
 		return nil, ParseError("trailing data in OCSP response")
 	}
+	stats.examined(len(der))
+
+	if opts.strictVersion() {
+		if err := checkVersion(basicResp.TBSResponseData.Version, basicResp.TBSResponseData.Raw); err != nil {
+			return nil, err
+		}
+	}
 
-	if n := len(basicResp.TBSResponseData.Responses); n == 0 || cert == nil && n > 1 {
+	if n := len(basicResp.TBSResponseData.Responses); n == 0 || match == nil && n > 1 {
 		return nil, ParseError("OCSP response contains bad number of responses")
 	}
 
 	var singleResp singleResponse
-	if cert == nil {
+	var duplicates []singleResponse
+	if match == nil {
 		singleResp = basicResp.TBSResponseData.Responses[0]
 	} else {
-		match := false
+		var matches []singleResponse
 		for _, resp := range basicResp.TBSResponseData.Responses {
-			if cert.SerialNumber.Cmp(resp.CertID.SerialNumber) == 0 {
-				singleResp = resp
-				match = true
-				break
+			if match(&resp.CertID) {
+				matches = append(matches, resp)
 			}
 		}
-		if !match {
-			return nil, ParseError("no response matching the supplied certificate")
+		if len(matches) == 0 {
+			return nil, ParseError("no response matching the supplied " + what)
+		}
+		if len(matches) > 1 && prefer != nil {
+			var preferred []singleResponse
+			for _, resp := range matches {
+				if prefer(&resp.CertID) {
+					preferred = append(preferred, resp)
+				}
+			}
+			if len(preferred) > 0 {
+				matches = preferred
+			}
+		}
+		singleResp = matches[0]
+		switch opts.duplicates() {
+		case DuplicateError:
+			if len(matches) > 1 {
+				return nil, ParseError("multiple responses matching the supplied " + what)
+			}
+		case DuplicateFreshest:
+			for _, resp := range matches[1:] {
+				if resp.ThisUpdate.After(singleResp.ThisUpdate) {
+					singleResp = resp
+				}
+			}
+		case DuplicateAll:
+			duplicates = matches[1:]
 		}
 	}
 
+	signatureAlgorithm, pssSaltLength := getSignatureAlgorithmFromAI(basicResp.SignatureAlgorithm)
 	ret := &Response{
 		Raw:                der,
+		Version:            basicResp.TBSResponseData.Version,
 		TBSResponseData:    basicResp.TBSResponseData.Raw,
 		Signature:          basicResp.Signature.RightAlign(),
-		SignatureAlgorithm: getSignatureAlgorithmFromAI(basicResp.SignatureAlgorithm),
+		SignatureAlgorithm: signatureAlgorithm,
+		PSSSaltLength:      pssSaltLength,
 		Extensions:         singleResp.SingleExtensions,
 		SerialNumber:       singleResp.CertID.SerialNumber,
 		ProducedAt:         basicResp.TBSResponseData.ProducedAt,
 		ResponseExtensions: basicResp.TBSResponseData.ResponseExtensions,
 		ThisUpdate:         singleResp.ThisUpdate,
 		NextUpdate:         singleResp.NextUpdate,
+		RawSingleResponse:  singleResp.Raw,
+		RawCertID:          singleResp.CertID.Raw,
+
+		rawSignatureAlgorithm: basicResp.SignatureAlgorithm,
+		rawSignature:          basicResp.Signature,
+		rawCertificates:       basicResp.Certificates,
 	}
 
 	// Handle the ResponderID CHOICE tag. ResponderID can be flattened into
@@ -648,6 +1768,8 @@ func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response
 			return nil, ParseError("invalid responder name")
 		}
 		ret.RawResponderName = rawResponderID.Bytes
+		ret.ResponderName = new(pkix.Name)
+		ret.ResponderName.FillFromRDNSequence(&rdn)
 	case 2: // KeyHash
 		if rest, err := asn1.Unmarshal(rawResponderID.Bytes, &ret.ResponderKeyHash); err != nil || len(rest) != 0 {
 			return nil, ParseError("invalid responder key hash")
@@ -657,48 +1779,63 @@ func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response
 	}
 
 	if len(basicResp.Certificates) > 0 {
-		// Responders should only send a single certificate (if they
-		// send any) that connects the responder's certificate to the
-		// original issuer. We accept responses with multiple
-		// certificates due to a number responders sending them[1], but
-		// ignore all but the first.
+		// Responders usually send a single certificate (if they send
+		// any) that connects the responder's certificate to the
+		// original issuer. Some responders send more certificates[1],
+		// the first one is the responder certificate, and the rest are
+		// kept in CertificateChain.
 		//
 		// [1] https://github.com/golang/go/issues/21527
 		ret.Certificate, err = x509.ParseCertificate(basicResp.Certificates[0].FullBytes)
 		if err != nil {
 			return nil, err
 		}
-
-		if err := ret.CheckSignatureFrom(ret.Certificate); err != nil {
-			return nil, ParseError("bad signature on embedded certificate: " + err.Error())
-		}
-
-		if issuer != nil {
-			if err := issuer.CheckSignature(ret.Certificate.SignatureAlgorithm, ret.Certificate.RawTBSCertificate, ret.Certificate.Signature); err != nil {
-				return nil, ParseError("bad OCSP signature: " + err.Error())
+		for _, raw := range basicResp.Certificates[1:] {
+			cert, err := x509.ParseCertificate(raw.FullBytes)
+			if err != nil {
+				return nil, err
 			}
+			ret.CertificateChain = append(ret.CertificateChain, cert)
 		}
-	} else if issuer != nil {
-		if err := ret.CheckSignatureFrom(issuer); err != nil {
-			return nil, ParseError("bad OCSP signature: " + err.Error())
+	}
+
+	if !opts.skipSignatureVerification() {
+		if err := ret.verify(issuer); err != nil {
+			return nil, err
 		}
 	}
 
 	for _, ext := range singleResp.SingleExtensions {
 		if ext.Critical {
-			return nil, ParseError("unsupported critical extension")
+			if err := opts.checkCriticalExtension(ext); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	for h, oid := range hashOIDs {
-		if singleResp.CertID.HashAlgorithm.Algorithm.Equal(oid) {
-			ret.IssuerHash = h
-			break
+	for _, ext := range ret.ResponseExtensions {
+		if ext.Id.Equal(idPKIXOCSPNonce) {
+			var nonce []byte
+			if rest, err := asn1.Unmarshal(ext.Value, &nonce); err == nil && len(rest) == 0 {
+				ret.Nonce = nonce
+			}
 		}
 	}
+
+	for i := range duplicates {
+		ret.Duplicates = append(ret.Duplicates, newSingleResponse(&duplicates[i]))
+	}
+
+	ret.IssuerHash = getHashAlgorithmFromOID(singleResp.CertID.HashAlgorithm.Algorithm)
 	if ret.IssuerHash == 0 {
 		return nil, ParseError("unsupported issuer hash algorithm")
 	}
+	ret.IssuerNameHash = singleResp.CertID.NameHash
+	ret.IssuerKeyHash = singleResp.CertID.IssuerKeyHash
+
+	if err := opts.validation().check(ret, issuer); err != nil {
+		return nil, err
+	}
 
 	switch {
 	case bool(singleResp.Good):
@@ -714,19 +1851,78 @@ func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response
 	return ret, nil
 }
 
+// publicKeyHash returns the hash of the subjectPublicKey bits of cert, as used
+// in the IssuerKeyHash of a CertID and in the KeyHash of a ResponderID.
+func publicKeyHash(cert *x509.Certificate, hash crypto.Hash) ([]byte, error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return nil, err
+	}
+	h, ok := newHash(hash)
+	if !ok {
+		return nil, x509.ErrUnsupportedAlgorithm
+	}
+	h.Write(publicKeyInfo.PublicKey.RightAlign())
+	return h.Sum(nil), nil
+}
+
 // RequestOptions contains options for constructing OCSP requests.
 type RequestOptions struct {
 	// Hash contains the hash function that should be used when
-	// constructing the OCSP request. If zero, SHA-1 will be used.
+	// constructing the OCSP request. If zero, the first available hash in
+	// PreferredHashes is used, and SHA-1 if none is available.
 	Hash crypto.Hash
+
+	// PreferredHashes contains the hash functions to use when constructing
+	// OCSP requests, in order of preference. It is only used if Hash is zero.
+	// The remaining hashes can be used to retry a request if a responder
+	// does not support the first one, see Hashes.
+	PreferredHashes []crypto.Hash
+
+	// RequestorName optionally identifies the requestor, for responders
+	// applying a policy per requestor.
+	RequestorName *pkix.Name
+
+	// AcceptBasicResponseOnly adds the AcceptableResponses extension to the
+	// request, declaring that only basic responses, the only type parsed by
+	// this package, are accepted.
+	AcceptBasicResponseOnly bool
 }
 
 func (opts *RequestOptions) hash() crypto.Hash {
-	if opts == nil || opts.Hash == 0 {
+	return opts.Hashes()[0]
+}
+
+// Hashes returns the hash functions that can be used to construct an OCSP
+// request with opts, in order of preference. The first one is used by
+// CreateRequest. Clients can fall back to the next one when a responder
+// returns an Unauthorized or Malformed error, for example, when
+// PreferredHashes is set to SHA-256 and SHA-1.
+//
+// The returned slice always contains at least one element.
+func (opts *RequestOptions) Hashes() []crypto.Hash {
+	if opts != nil && opts.Hash != 0 {
+		return []crypto.Hash{opts.Hash}
+	}
+	var hashes []crypto.Hash
+	if opts != nil {
+		for _, h := range opts.PreferredHashes {
+			if getOIDFromHashAlgorithm(h) == nil {
+				continue
+			}
+			if _, ok := newHash(h); ok {
+				hashes = append(hashes, h)
+			}
+		}
+	}
+	if len(hashes) == 0 {
 		// SHA-1 is nearly universally used in OCSP.
-		return crypto.SHA1
+		hashes = append(hashes, crypto.SHA1)
 	}
-	return opts.Hash
+	return hashes
 }
 
 // CreateRequest returns a DER-encoded, OCSP request for the status of cert. If
@@ -737,15 +1933,14 @@ func CreateRequest(cert, issuer *x509.Certificate, opts *RequestOptions) ([]byte
 	// OCSP seems to be the only place where these raw hash identifiers are
 	// used. I took the following from
 	// http://msdn.microsoft.com/en-us/library/ff635603.aspx
-	_, ok := hashOIDs[hashFunc]
-	if !ok {
+	if getOIDFromHashAlgorithm(hashFunc) == nil {
 		return nil, x509.ErrUnsupportedAlgorithm
 	}
 
-	if !hashFunc.Available() {
+	h, ok := newHash(hashFunc)
+	if !ok {
 		return nil, x509.ErrUnsupportedAlgorithm
 	}
-	h := opts.hash().New()
 
 	var publicKeyInfo struct {
 		Algorithm pkix.AlgorithmIdentifier
@@ -768,9 +1963,99 @@ func CreateRequest(cert, issuer *x509.Certificate, opts *RequestOptions) ([]byte
 		IssuerKeyHash:  issuerKeyHash,
 		SerialNumber:   cert.SerialNumber,
 	}
+	if opts != nil {
+		req.RequestorName = opts.RequestorName
+		if opts.AcceptBasicResponseOnly {
+			req.AcceptableResponses = []asn1.ObjectIdentifier{idPKIXOCSPBasic}
+		}
+	}
 	return req.Marshal()
 }
 
+// MatchesIssuer reports whether the IssuerNameHash and IssuerKeyHash of req
+// correspond to issuer, using the hash algorithm of the request.
+func (req *Request) MatchesIssuer(issuer *x509.Certificate) bool {
+	keyHash, err := publicKeyHash(issuer, req.HashAlgorithm)
+	if err != nil || !bytes.Equal(keyHash, req.IssuerKeyHash) {
+		return false
+	}
+	h, _ := newHash(req.HashAlgorithm)
+	h.Write(issuer.RawSubject)
+	return bytes.Equal(h.Sum(nil), req.IssuerNameHash)
+}
+
+// signResponseData signs the DER-encoded ResponseData tbs with priv, using
+// requestedSigAlgo if not zero, and returns the signature algorithm and the
+// signature.
+func signResponseData(priv crypto.Signer, requestedSigAlgo x509.SignatureAlgorithm, pssSaltLength int, tbs []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	signerOpts, signatureAlgorithm, err := signingParamsForPublicKey(priv.Public(), requestedSigAlgo)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	if FIPSMode() {
+		algo, _ := getSignatureAlgorithmFromAI(signatureAlgorithm)
+		if err := checkFIPSSignature(algo, priv.Public()); err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+	}
+	if pssOpts, ok := signerOpts.(*rsa.PSSOptions); ok && isCustomPSSSaltLength(requestedSigAlgo, pssSaltLength) {
+		if pssSaltLength < 0 {
+			return pkix.AlgorithmIdentifier{}, nil, errors.New("ocsp: invalid RSA PSS salt length")
+		}
+		signatureAlgorithm.Parameters, err = marshalPSSParameters(pssOpts.Hash, pssSaltLength)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		pssOpts.SaltLength = pssSaltLength
+	}
+
+	var signature []byte
+	if details, ok := lookupSignatureAlgorithmByOID(signatureAlgorithm.Algorithm); ok {
+		signature, err = details.sign(rand.Reader, priv, tbs)
+	} else {
+		responseHash, ok := getHash(signerOpts.HashFunc())
+		if !ok {
+			return pkix.AlgorithmIdentifier{}, nil, x509.ErrUnsupportedAlgorithm
+		}
+		responseHash.Write(tbs)
+		digest := responseHash.Sum(nil)
+		putHash(signerOpts.HashFunc(), responseHash)
+		signature, err = priv.Sign(rand.Reader, digest, signerOpts)
+	}
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	return signatureAlgorithm, signature, nil
+}
+
+// CreateResponseForRequest acts like CreateResponse, but it uses the CertID of
+// req in the response, as RFC 6960 requires the CertID of the response to
+// match the one in the request. If template.SerialNumber is nil, the serial
+// number of the request is used, and if template.Nonce is nil, the nonce of
+// the request is echoed in the response.
+//
+// The issuer certificate is optional. If not nil, CreateResponseForRequest
+// returns an error if req was not created for a certificate issued by issuer.
+// It also returns an error if the serial numbers of the template and the
+// request do not match.
+func CreateResponseForRequest(req *Request, issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer) ([]byte, error) {
+	if issuer != nil && !req.MatchesIssuer(issuer) {
+		return nil, errors.New("ocsp: request issuer does not match the issuer certificate")
+	}
+	if template.SerialNumber == nil {
+		template.SerialNumber = req.SerialNumber
+	} else if req.SerialNumber == nil || template.SerialNumber.Cmp(req.SerialNumber) != 0 {
+		return nil, errors.New("ocsp: request serial number does not match the template serial number")
+	}
+	if template.Nonce == nil {
+		template.Nonce = req.Nonce
+	}
+	template.IssuerHash = req.HashAlgorithm
+	template.IssuerNameHash = req.IssuerNameHash
+	template.IssuerKeyHash = req.IssuerKeyHash
+	return CreateResponse(issuer, responderCert, template, priv)
+}
+
 // CreateResponse returns a DER-encoded OCSP response with the specified contents.
 // The fields in the response are populated as follows:
 //
@@ -778,47 +2063,118 @@ func CreateRequest(cert, issuer *x509.Certificate, opts *RequestOptions) ([]byte
 // certificate itself is provided alongside the OCSP response signature.
 //
 // The issuer cert is used to populate the IssuerNameHash and IssuerKeyHash fields.
+// If template.IssuerNameHash and template.IssuerKeyHash are set, they are used
+// instead, and issuer can be nil.
 //
 // The template is used to populate the SerialNumber, Status, RevokedAt,
 // RevocationReason, ThisUpdate, and NextUpdate fields.
 //
 // If template.IssuerHash is not set, SHA1 will be used.
 //
+// If template.Nonce is set, it is added to the response in the nonce response
+// extension, where clients look for it. See RFC 8954.
+//
+// The template is checked with Response.Validate before signing.
+//
 // The ProducedAt date is automatically set to the current date, to the nearest minute.
 func CreateResponse(issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer) ([]byte, error) {
-	var publicKeyInfo struct {
-		Algorithm pkix.AlgorithmIdentifier
-		PublicKey asn1.BitString
+	return CreateResponseWithOptions(issuer, responderCert, template, priv, nil)
+}
+
+// CreateResponseOptions contains options for CreateResponseWithOptions.
+type CreateResponseOptions struct {
+	// ResponderIDByKey identifies the responder by the SHA-1 hash of its
+	// public key instead of by its subject, which is usually shorter.
+	ResponderIDByKey bool
+	// OmitCertificate does not embed template.Certificate and
+	// template.CertificateChain in the response.
+	// Clients must then verify the response with the issuer certificate.
+	OmitCertificate bool
+	// OmitExtensions does not add template.ExtraExtensions and
+	// template.ResponseExtraExtensions to the response, including the nonce,
+	// if any.
+	OmitExtensions bool
+}
+
+// CompactResponseOptions creates the smallest responses, for constrained
+// environments like TLS handshakes with small record limits.
+var CompactResponseOptions = CreateResponseOptions{
+	ResponderIDByKey: true,
+	OmitCertificate:  true,
+	OmitExtensions:   true,
+}
+
+func (opts *CreateResponseOptions) responderIDByKey() bool {
+	return opts != nil && opts.ResponderIDByKey
+}
+
+func (opts *CreateResponseOptions) omitCertificate() bool {
+	return opts != nil && opts.OmitCertificate
+}
+
+func (opts *CreateResponseOptions) omitExtensions() bool {
+	return opts != nil && opts.OmitExtensions
+}
+
+// CreateResponseWithOptions is like CreateResponse, but it takes options to
+// reduce the size of the response. If opts is nil, it behaves like
+// CreateResponse.
+func CreateResponseWithOptions(issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer, opts *CreateResponseOptions) ([]byte, error) {
+	if opts.omitExtensions() {
+		template.ExtraExtensions = nil
+		template.ResponseExtraExtensions = nil
+		template.Nonce = nil
 	}
-	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+	if opts.omitCertificate() {
+		template.Certificate = nil
+		template.CertificateChain = nil
+	}
+	if template.Certificate == nil && len(template.CertificateChain) > 0 {
+		return nil, errors.New("ocsp: template CertificateChain requires Certificate")
+	}
+	if err := template.Validate(); err != nil {
 		return nil, err
 	}
-
 	if template.IssuerHash == 0 {
 		template.IssuerHash = crypto.SHA1
 	}
-	hashOID := getOIDFromHashAlgorithm(template.IssuerHash)
-	if hashOID == nil {
-		return nil, errors.New("unsupported issuer hash algorithm")
-	}
+	hashAlg, _ := getHashAlgorithmIdentifier(template.IssuerHash)
 
-	if !template.IssuerHash.Available() {
-		return nil, fmt.Errorf("issuer hash algorithm %v not linked into binary", template.IssuerHash)
-	}
-	h := template.IssuerHash.New()
-	h.Write(publicKeyInfo.PublicKey.RightAlign())
-	issuerKeyHash := h.Sum(nil)
+	issuerNameHash, issuerKeyHash := template.IssuerNameHash, template.IssuerKeyHash
+	if len(issuerNameHash) == 0 || len(issuerKeyHash) == 0 {
+		if issuer == nil {
+			return nil, errors.New("ocsp: issuer certificate or issuer hashes are required")
+		}
 
-	h.Reset()
-	h.Write(issuer.RawSubject)
-	issuerNameHash := h.Sum(nil)
+		var publicKeyInfo struct {
+			Algorithm pkix.AlgorithmIdentifier
+			PublicKey asn1.BitString
+		}
+		if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+			return nil, err
+		}
+
+		h, ok := getHash(template.IssuerHash)
+		if !ok {
+			return nil, fmt.Errorf("issuer hash algorithm %v not linked into binary", template.IssuerHash)
+		}
+		// Both hashes share the same backing array.
+		size := h.Size()
+		hashes := make([]byte, 0, 2*size)
+		h.Write(publicKeyInfo.PublicKey.RightAlign())
+		hashes = h.Sum(hashes)
+
+		h.Reset()
+		h.Write(issuer.RawSubject)
+		hashes = h.Sum(hashes)
+		putHash(template.IssuerHash, h)
+
+		issuerKeyHash, issuerNameHash = hashes[:size:size], hashes[size:]
+	}
 
 	innerResponse := singleResponse{
 		CertID: certID{
-			HashAlgorithm: pkix.AlgorithmIdentifier{
-				Algorithm:  hashOID,
-				Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
-			},
+			HashAlgorithm: hashAlg,
 			NameHash:      issuerNameHash,
 			IssuerKeyHash: issuerKeyHash,
 			SerialNumber:  template.SerialNumber,
@@ -846,12 +2202,26 @@ func CreateResponse(issuer, responderCert *x509.Certificate, template Response,
 		IsCompound: true,
 		Bytes:      responderCert.RawSubject,
 	}
+	if opts.responderIDByKey() {
+		keyHash, err := publicKeyHash(responderCert, crypto.SHA1)
+		if err != nil {
+			return nil, err
+		}
+		rawResponderID.Tag = 2 // KeyHash (explicit tag)
+		if rawResponderID.Bytes, err = asn1.Marshal(keyHash); err != nil {
+			return nil, err
+		}
+	}
+	responseExtensions, err := withNonce(template.ResponseExtraExtensions, template.Nonce)
+	if err != nil {
+		return nil, err
+	}
 	tbsResponseData := responseData{
 		Version:            0,
 		RawResponderID:     rawResponderID,
 		ProducedAt:         time.Now().Truncate(time.Minute).UTC(),
 		Responses:          []singleResponse{innerResponse},
-		ResponseExtensions: template.ResponseExtraExtensions,
+		ResponseExtensions: responseExtensions,
 	}
 
 	tbsResponseDataDER, err := asn1.Marshal(tbsResponseData)
@@ -859,20 +2229,15 @@ func CreateResponse(issuer, responderCert *x509.Certificate, template Response,
 		return nil, err
 	}
 
-	signerOpts, signatureAlgorithm, err := signingParamsForPublicKey(priv.Public(), template.SignatureAlgorithm)
-	if err != nil {
-		return nil, err
-	}
-
-	responseHash := signerOpts.HashFunc().New()
-	responseHash.Write(tbsResponseDataDER)
-	signature, err := priv.Sign(rand.Reader, responseHash.Sum(nil), signerOpts)
+	signatureAlgorithm, signature, err := signResponseData(priv, template.SignatureAlgorithm, template.PSSSaltLength, tbsResponseDataDER)
 	if err != nil {
 		return nil, err
 	}
 
-	response := basicResponse{
-		TBSResponseData:    tbsResponseData,
+	// The signed TBSResponseData is reused as is instead of encoding it
+	// again.
+	response := rawBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbsResponseDataDER},
 		SignatureAlgorithm: signatureAlgorithm,
 		Signature: asn1.BitString{
 			Bytes:     signature,
@@ -880,8 +2245,10 @@ func CreateResponse(issuer, responderCert *x509.Certificate, template Response,
 		},
 	}
 	if template.Certificate != nil {
-		response.Certificates = []asn1.RawValue{
-			{FullBytes: template.Certificate.Raw},
+		response.Certificates = make([]asn1.RawValue, 0, 1+len(template.CertificateChain))
+		response.Certificates = append(response.Certificates, asn1.RawValue{FullBytes: template.Certificate.Raw})
+		for _, cert := range template.CertificateChain {
+			response.Certificates = append(response.Certificates, asn1.RawValue{FullBytes: cert.Raw})
 		}
 	}
 	responseDER, err := asn1.Marshal(response)
@@ -7,15 +7,19 @@ package ocsp
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/hex"
 	"encoding/pem"
 	"errors"
+	"hash"
 	"math/big"
 	"reflect"
 	"testing"
@@ -473,6 +477,1664 @@ func TestOCSPDecodeMultiResponseWithoutMatchingCert(t *testing.T) {
 	}
 }
 
+func TestResponseMarshal(t *testing.T) {
+	for _, h := range []string{ocspResponseHex, ocspResponseWithoutCertHex, ocspResponseWithExtensionHex} {
+		responseBytes, _ := hex.DecodeString(h)
+		resp, err := ParseResponse(responseBytes, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		der, err := resp.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(der, responseBytes) {
+			t.Errorf("resp.Marshal(): got %x, want %x", der, responseBytes)
+		}
+	}
+
+	if _, err := (&Response{Status: Good}).Marshal(); err == nil {
+		t.Error("Marshal didn't fail with a response that has not been parsed")
+	}
+}
+
+func TestResponseTemplate(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responseBytes, _ := hex.DecodeString(ocspResponseWithExtensionHex)
+	parsed, err := ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := parsed.Template()
+	template.Certificate = responder
+	template.SignatureAlgorithm = x509.SHA256WithRSA
+	template.NextUpdate = parsed.NextUpdate.Add(time.Hour)
+
+	der, err := CreateResponse(issuer, responder, template, responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.SerialNumber.Cmp(parsed.SerialNumber) != 0 {
+		t.Errorf("resp.SerialNumber: got %x, want %x", resp.SerialNumber, parsed.SerialNumber)
+	}
+	if resp.Status != parsed.Status {
+		t.Errorf("resp.Status: got %d, want %d", resp.Status, parsed.Status)
+	}
+	if !resp.ThisUpdate.Equal(parsed.ThisUpdate) {
+		t.Errorf("resp.ThisUpdate: got %v, want %v", resp.ThisUpdate, parsed.ThisUpdate)
+	}
+	if !resp.NextUpdate.Equal(template.NextUpdate) {
+		t.Errorf("resp.NextUpdate: got %v, want %v", resp.NextUpdate, template.NextUpdate)
+	}
+	if !reflect.DeepEqual(resp.Extensions, parsed.Extensions) {
+		t.Errorf("resp.Extensions: got %v, want %v", resp.Extensions, parsed.Extensions)
+	}
+}
+
+func TestOCSPDecodeRawSingleResponse(t *testing.T) {
+	respBytes, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponseForCert(respBytes, &x509.Certificate{SerialNumber: big.NewInt(3)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(resp.TBSResponseData, resp.RawSingleResponse) {
+		t.Errorf("resp.RawSingleResponse %x not found in TBSResponseData", resp.RawSingleResponse)
+	}
+	if !bytes.Contains(resp.RawSingleResponse, resp.RawCertID) {
+		t.Errorf("resp.RawCertID %x not found in RawSingleResponse", resp.RawCertID)
+	}
+
+	var single singleResponse
+	if rest, err := asn1.Unmarshal(resp.RawSingleResponse, &single); err != nil || len(rest) != 0 {
+		t.Fatalf("asn1.Unmarshal(resp.RawSingleResponse): %v", err)
+	}
+	if single.CertID.SerialNumber.Cmp(resp.SerialNumber) != 0 {
+		t.Errorf("RawSingleResponse serial: got %x, want %x", single.CertID.SerialNumber, resp.SerialNumber)
+	}
+
+	var id certID
+	if rest, err := asn1.Unmarshal(resp.RawCertID, &id); err != nil || len(rest) != 0 {
+		t.Fatalf("asn1.Unmarshal(resp.RawCertID): %v", err)
+	}
+	if id.SerialNumber.Cmp(resp.SerialNumber) != 0 {
+		t.Errorf("RawCertID serial: got %x, want %x", id.SerialNumber, resp.SerialNumber)
+	}
+}
+
+func TestOCSPCheckSignatureFromKey(t *testing.T) {
+	b, _ := pem.Decode([]byte(GTSRoot))
+	issuer, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responseBytes, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(issuer.PublicKey); err != nil {
+		t.Errorf("CheckSignatureFromKey: %v", err)
+	}
+
+	k, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(k.Public()); err == nil {
+		t.Error("CheckSignatureFromKey didn't fail with the wrong key")
+	}
+
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(ek.Public()); err == nil {
+		t.Error("CheckSignatureFromKey didn't fail with a key of the wrong type")
+	}
+
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	ecIssuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateResponse(ecIssuer, &x509.Certificate{RawSubject: []byte{0x30, 0}}, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}, ek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(ek.Public()); err != nil {
+		t.Errorf("CheckSignatureFromKey: %v", err)
+	}
+}
+
+func TestOCSPFindResponder(t *testing.T) {
+	b, _ := pem.Decode([]byte(GTSRoot))
+	issuer, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	other, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The GTS response uses a key hash responder ID.
+	responseBytes, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := resp.FindResponder([]*x509.Certificate{other, nil, issuer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != issuer {
+		t.Errorf("FindResponder: got %v, want %v", got.Subject, issuer.Subject)
+	}
+	if _, err := resp.FindResponder([]*x509.Certificate{other}); err == nil {
+		t.Error("FindResponder didn't fail without a matching certificate")
+	}
+
+	// Responses created by CreateResponse use a name responder ID.
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateResponse(other, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}, responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = resp.FindResponder([]*x509.Certificate{issuer, responder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != responder {
+		t.Errorf("FindResponder: got %v, want %v", got.Subject, responder.Subject)
+	}
+}
+
+func TestOCSPResponderName(t *testing.T) {
+	responseBytes, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ResponderName != nil {
+		t.Errorf("resp.ResponderName: got %v, want nil", resp.ResponderName)
+	}
+	if got, want := resp.ResponderIDString(), "keyHash:8a747faf85cdee95cd3d9cd0e24614f371351d27"; got != want {
+		t.Errorf("resp.ResponderIDString(): got %q, want %q", got, want)
+	}
+
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateResponse(issuer, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}, responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ResponderName == nil {
+		t.Fatal("resp.ResponderName: got nil")
+	}
+	if got, want := resp.ResponderName.String(), responder.Subject.String(); got != want {
+		t.Errorf("resp.ResponderName: got %q, want %q", got, want)
+	}
+	if got, want := resp.ResponderIDString(), responder.Subject.String(); got != want {
+		t.Errorf("resp.ResponderIDString(): got %q, want %q", got, want)
+	}
+}
+
+func TestMarshalPSSParameters(t *testing.T) {
+	for hash, want := range map[crypto.Hash]asn1.RawValue{
+		crypto.SHA256: pssParametersSHA256,
+		crypto.SHA384: pssParametersSHA384,
+		crypto.SHA512: pssParametersSHA512,
+	} {
+		got, err := marshalPSSParameters(hash, hash.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.FullBytes, want.FullBytes) {
+			t.Errorf("marshalPSSParameters(%v): got %x, want %x", hash, got.FullBytes, want.FullBytes)
+		}
+	}
+}
+
+func TestOCSPResponsePSSSaltLength(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-sign the responder certificate using RSA PSS with a salt length
+	// of 20 bytes, not supported by crypto/x509.
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	certTemplate, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certTemplate.SignatureAlgorithm = x509.SHA256WithRSAPSS
+	der, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, responderPrivateKey.Public(), responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customParams, err := marshalPSSParameters(crypto.SHA256, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cert struct {
+		TBSCertificate     asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &cert); err != nil {
+		t.Fatal(err)
+	}
+	cert.TBSCertificate.FullBytes = bytes.Replace(cert.TBSCertificate.FullBytes, pssParametersSHA256.FullBytes, customParams.FullBytes, 1)
+	cert.SignatureAlgorithm.Parameters = customParams
+	digest := sha256.Sum256(cert.TBSCertificate.FullBytes)
+	sig, err := rsa.SignPSS(rand.Reader, responderPrivateKey, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert.Signature = asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)}
+	if der, err = asn1.Marshal(cert); err != nil {
+		t.Fatal(err)
+	}
+	responder, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if responder.SignatureAlgorithm != x509.UnknownSignatureAlgorithm {
+		t.Fatalf("responder.SignatureAlgorithm: got %v, want %v", responder.SignatureAlgorithm, x509.UnknownSignatureAlgorithm)
+	}
+
+	for _, saltLength := range []int{0, 20, 32, 64} {
+		template := Response{
+			Status:             Good,
+			SerialNumber:       big.NewInt(1),
+			ThisUpdate:         time.Now().Truncate(time.Second),
+			Certificate:        responder,
+			SignatureAlgorithm: x509.SHA256WithRSAPSS,
+			PSSSaltLength:      saltLength,
+		}
+		der, err := CreateResponse(issuer, responder, template, responderPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, responder)
+		if err != nil {
+			t.Fatalf("ParseResponse with salt length %d: %v", saltLength, err)
+		}
+
+		want := saltLength
+		if want == 0 {
+			want = crypto.SHA256.Size()
+		}
+		if resp.SignatureAlgorithm != x509.SHA256WithRSAPSS {
+			t.Errorf("resp.SignatureAlgorithm: got %v, want %v", resp.SignatureAlgorithm, x509.SHA256WithRSAPSS)
+		}
+		if resp.PSSSaltLength != want {
+			t.Errorf("resp.PSSSaltLength: got %d, want %d", resp.PSSSaltLength, want)
+		}
+		if err := resp.CheckSignatureFromKey(responderPrivateKey.Public()); err != nil {
+			t.Errorf("CheckSignatureFromKey with salt length %d: %v", saltLength, err)
+		}
+	}
+}
+
+func TestOCSPRequestSHA3(t *testing.T) {
+	for _, hash := range []crypto.Hash{crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512} {
+		req := &Request{
+			HashAlgorithm:  hash,
+			IssuerNameHash: make([]byte, hash.Size()),
+			IssuerKeyHash:  make([]byte, hash.Size()),
+			SerialNumber:   big.NewInt(1),
+		}
+		der, err := req.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedRequest, err := ParseRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decodedRequest.HashAlgorithm != hash {
+			t.Errorf("request.HashAlgorithm: got %v, want %v", decodedRequest.HashAlgorithm, hash)
+		}
+
+		// The hash implementation is not linked into the binary unless
+		// an external package registers it.
+		if hash.Available() {
+			continue
+		}
+		leafCert, _ := hex.DecodeString(leafCertHex)
+		cert, err := x509.ParseCertificate(leafCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CreateRequest(cert, cert, &RequestOptions{Hash: hash}); err == nil {
+			t.Errorf("CreateRequest didn't fail with unavailable hash %v", hash)
+		}
+	}
+}
+
+// restoreHashRegistry restores the registered hash functions at the end of
+// the test.
+func restoreHashRegistry(t *testing.T) {
+	t.Helper()
+	hashOIDsMu.Lock()
+	oids := make(map[crypto.Hash]asn1.ObjectIdentifier, len(hashOIDs))
+	for h, oid := range hashOIDs {
+		oids[h] = oid
+	}
+	funcs := make(map[crypto.Hash]func() hash.Hash, len(hashFuncs))
+	for h, f := range hashFuncs {
+		funcs[h] = f
+	}
+	hashOIDsMu.Unlock()
+	t.Cleanup(func() {
+		hashOIDsMu.Lock()
+		hashOIDs, hashFuncs = oids, funcs
+		hashOIDsMu.Unlock()
+	})
+}
+
+func TestRegisterHash(t *testing.T) {
+	restoreHashRegistry(t)
+	oidSHA224 := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	if getOIDFromHashAlgorithm(crypto.SHA224) == nil {
+		if err := RegisterHash(crypto.SHA224, oidSHA224); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RegisterHash(crypto.SHA224, oidSHA224); err == nil || err.Error() != "ocsp: hash function SHA-224 is already registered" {
+		t.Errorf("RegisterHash with a registered hash: got %v", err)
+	}
+	if err := RegisterHash(crypto.MD5, oidSHA1); err == nil || err.Error() != "ocsp: OID 1.3.14.3.2.26 is already registered for hash function SHA-1" {
+		t.Errorf("RegisterHash with a registered OID: got %v", err)
+	}
+
+	leafCert, _ := hex.DecodeString(leafCertHex)
+	cert, err := x509.ParseCertificate(leafCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateRequest(cert, issuer, &RequestOptions{Hash: crypto.SHA224})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.HashAlgorithm != crypto.SHA224 {
+		t.Errorf("request.HashAlgorithm: got %v, want %v", req.HashAlgorithm, crypto.SHA224)
+	}
+	if len(req.IssuerKeyHash) != crypto.SHA224.Size() {
+		t.Errorf("len(request.IssuerKeyHash): got %d, want %d", len(req.IssuerKeyHash), crypto.SHA224.Size())
+	}
+}
+
+func TestRequestOptionsHashes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *RequestOptions
+		want []crypto.Hash
+	}{
+		{"nil", nil, []crypto.Hash{crypto.SHA1}},
+		{"empty", &RequestOptions{}, []crypto.Hash{crypto.SHA1}},
+		{"hash", &RequestOptions{Hash: crypto.SHA384, PreferredHashes: []crypto.Hash{crypto.SHA256}}, []crypto.Hash{crypto.SHA384}},
+		{"preferred", &RequestOptions{PreferredHashes: []crypto.Hash{crypto.SHA256, crypto.SHA1}}, []crypto.Hash{crypto.SHA256, crypto.SHA1}},
+		{"unsupported", &RequestOptions{PreferredHashes: []crypto.Hash{crypto.MD5, crypto.SHA256}}, []crypto.Hash{crypto.SHA256}},
+		{"none supported", &RequestOptions{PreferredHashes: []crypto.Hash{crypto.MD5}}, []crypto.Hash{crypto.SHA1}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.Hashes(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Hashes(): got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	leafCert, _ := hex.DecodeString(leafCertHex)
+	cert, err := x509.ParseCertificate(leafCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateRequest(cert, issuer, &RequestOptions{PreferredHashes: []crypto.Hash{crypto.SHA256, crypto.SHA1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.HashAlgorithm != crypto.SHA256 {
+		t.Errorf("request.HashAlgorithm: got %v, want %v", req.HashAlgorithm, crypto.SHA256)
+	}
+}
+
+func TestCreateResponseForRequest(t *testing.T) {
+	leafCert, _ := hex.DecodeString(leafCertHex)
+	leaf, err := x509.ParseCertificate(leafCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range []crypto.Hash{crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		der, err := CreateRequest(leaf, issuer, &RequestOptions{Hash: hash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := ParseRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !req.MatchesIssuer(issuer) {
+			t.Errorf("MatchesIssuer: got false, want true")
+		}
+		if req.MatchesIssuer(responder) {
+			t.Errorf("MatchesIssuer: got true, want false")
+		}
+
+		der, err = CreateResponseForRequest(req, issuer, responder, Response{
+			Status:     Good,
+			ThisUpdate: time.Now().Truncate(time.Second),
+		}, responderPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IssuerHash != hash {
+			t.Errorf("resp.IssuerHash: got %v, want %v", resp.IssuerHash, hash)
+		}
+		if resp.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+			t.Errorf("resp.SerialNumber: got %x, want %x", resp.SerialNumber, leaf.SerialNumber)
+		}
+
+		if _, err := CreateResponseForRequest(req, responder, responder, Response{Status: Good}, responderPrivateKey); err == nil {
+			t.Error("CreateResponseForRequest didn't fail with the wrong issuer")
+		}
+		if _, err := CreateResponseForRequest(req, issuer, responder, Response{Status: Good, SerialNumber: big.NewInt(1)}, responderPrivateKey); err == nil {
+			t.Error("CreateResponseForRequest didn't fail with the wrong serial number")
+		}
+	}
+}
+
+func TestCreateResponseWithIssuerHashes(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		IssuerHash:   crypto.SHA256,
+	}
+	der, err := CreateResponse(issuer, responder, template, responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want.IssuerNameHash) != crypto.SHA256.Size() || len(want.IssuerKeyHash) != crypto.SHA256.Size() {
+		t.Fatalf("unexpected issuer hashes %x and %x", want.IssuerNameHash, want.IssuerKeyHash)
+	}
+
+	template.IssuerNameHash = want.IssuerNameHash
+	template.IssuerKeyHash = want.IssuerKeyHash
+	der, err = CreateResponse(nil, responder, template, responderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.RawCertID, want.RawCertID) {
+		t.Errorf("resp.RawCertID: got %x, want %x", resp.RawCertID, want.RawCertID)
+	}
+
+	template.IssuerHash = crypto.SHA1
+	if _, err := CreateResponse(nil, responder, template, responderPrivateKey); err == nil {
+		t.Error("CreateResponse didn't fail with issuer hashes of the wrong length")
+	}
+	template.IssuerNameHash = nil
+	if _, err := CreateResponse(nil, responder, template, responderPrivateKey); err == nil {
+		t.Error("CreateResponse didn't fail without issuer or issuer hashes")
+	}
+}
+
+func BenchmarkCreateResponse(b *testing.B) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		b.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		b.Fatal(err)
+	}
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		NextUpdate:   time.Now().Add(24 * time.Hour).Truncate(time.Second),
+		IssuerHash:   crypto.SHA256,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CreateResponse(issuer, responder, template, priv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newTestResponder returns a self-signed ECDSA P-256 responder certificate and
+// its key.
+func newTestResponder(t testing.TB, commonName string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestHasNoCheck(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, noCheck := range []bool{false, true} {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "Responder"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		}
+		if noCheck {
+			template.ExtraExtensions = []pkix.Extension{
+				{Id: idPKIXOCSPNoCheck, Value: asn1.NullBytes},
+			}
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := HasNoCheck(cert); got != noCheck {
+			t.Errorf("HasNoCheck: got %v, want %v", got, noCheck)
+		}
+
+		der, err = CreateResponse(issuer, cert, Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(1),
+			ThisUpdate:   time.Now().Truncate(time.Second),
+			Certificate:  cert,
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.ResponderRevocationCheckRequired(); got == noCheck {
+			t.Errorf("ResponderRevocationCheckRequired: got %v, want %v", got, !noCheck)
+		}
+	}
+
+	responseBytes, _ := hex.DecodeString(ocspResponseWithoutCertHex)
+	resp, err := ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ResponderRevocationCheckRequired() {
+		t.Error("ResponderRevocationCheckRequired: got true for a response without certificate")
+	}
+}
+
+func TestOCSPResponseVerifyChain(t *testing.T) {
+	newCert := func(template, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.Signer) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	now := time.Now()
+	rootKey, intermediateKey, responderKey := newKey(), newKey(), newKey()
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root := newCert(rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	intermediate := newCert(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, intermediateKey.Public(), rootKey)
+	responderTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Responder"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}
+	responder := newCert(responderTemplate, intermediate, responderKey.Public(), intermediateKey)
+	responderTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	serverResponder := newCert(responderTemplate, intermediate, responderKey.Public(), intermediateKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate)
+
+	parse := func(cert *x509.Certificate) *Response {
+		der, err := CreateResponse(intermediate, cert, Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(10),
+			ThisUpdate:   now.Truncate(time.Second),
+			Certificate:  cert,
+		}, responderKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, intermediate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := parse(responder)
+	chains, err := resp.VerifyChain(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) != 1 || len(chains[0]) != 3 {
+		t.Errorf("VerifyChain: got %v, want one chain of 3 certificates", chains)
+	}
+
+	if _, err := resp.VerifyChain(x509.VerifyOptions{Roots: roots, CurrentTime: now}); err == nil {
+		t.Error("VerifyChain didn't fail without intermediates")
+	}
+	if _, err := resp.VerifyChain(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now.Add(2 * time.Hour)}); err == nil {
+		t.Error("VerifyChain didn't fail with an expired responder")
+	}
+	if _, err := parse(serverResponder).VerifyChain(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now}); err == nil {
+		t.Error("VerifyChain didn't fail without the OCSPSigning extended key usage")
+	}
+
+	responseBytes, _ := hex.DecodeString(ocspResponseWithoutCertHex)
+	resp, err = ParseResponse(responseBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.VerifyChain(x509.VerifyOptions{Roots: roots}); err == nil {
+		t.Error("VerifyChain didn't fail without a responder certificate")
+	}
+}
+
+func TestParseResponseSkipSignatureVerification(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	other, _ := newTestResponder(t, "Other")
+
+	der, err := CreateResponse(responder, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Corrupt the last byte of the signature.
+	bad := append([]byte(nil), der...)
+	bad[len(bad)-1] ^= 0xff
+
+	if _, err := ParseResponse(bad, responder); err == nil {
+		t.Fatal("ParseResponse didn't fail with a bad signature")
+	}
+	resp, err := ParseResponseWithOptions(bad, nil, responder, &ParseOptions{SkipSignatureVerification: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != Good || resp.SerialNumber.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("unexpected response: status %v, serial %v", resp.Status, resp.SerialNumber)
+	}
+	if err := resp.Verify(responder); err == nil {
+		t.Error("Verify didn't fail with a bad signature")
+	} else if parseErr := ParseError(""); !errors.As(err, &parseErr) || !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify: got %v, want a ParseError matching ErrInvalidSignature", err)
+	}
+
+	resp, err = ParseResponseWithOptions(der, nil, other, &ParseOptions{SkipSignatureVerification: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Verify(other); err == nil {
+		t.Error("Verify didn't fail with the wrong issuer")
+	}
+	if err := resp.Verify(nil); err == nil {
+		t.Error("Verify didn't fail without an issuer")
+	}
+	if err := resp.Verify(responder); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	if _, err := ParseResponseWithOptions(der, nil, other, nil); err == nil {
+		t.Error("ParseResponseWithOptions didn't fail with the wrong issuer and nil options")
+	}
+}
+
+func TestOCSPResponseHasNextUpdate(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	thisUpdate := time.Now().Truncate(time.Second)
+
+	for _, nextUpdate := range []time.Time{{}, thisUpdate.Add(time.Hour)} {
+		der, err := CreateResponse(responder, responder, Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(1),
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   nextUpdate,
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := resp.HasNextUpdate(), !nextUpdate.IsZero(); got != want {
+			t.Errorf("HasNextUpdate: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestOCSPResponseFreshness(t *testing.T) {
+	thisUpdate := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextUpdate := thisUpdate.Add(24 * time.Hour)
+	resp := &Response{ThisUpdate: thisUpdate, NextUpdate: nextUpdate}
+	noNextUpdate := &Response{ThisUpdate: thisUpdate}
+
+	tests := []struct {
+		name    string
+		resp    *Response
+		now     time.Time
+		valid   bool
+		expired bool
+		ttl     time.Duration
+	}{
+		{"before thisUpdate", resp, thisUpdate.Add(-time.Second), false, false, 24*time.Hour + time.Second},
+		{"at thisUpdate", resp, thisUpdate, true, false, 24 * time.Hour},
+		{"within window", resp, thisUpdate.Add(time.Hour), true, false, 23 * time.Hour},
+		{"at nextUpdate", resp, nextUpdate, false, true, 0},
+		{"after nextUpdate", resp, nextUpdate.Add(time.Hour), false, true, 0},
+		{"no nextUpdate before thisUpdate", noNextUpdate, thisUpdate.Add(-time.Second), false, false, 0},
+		{"no nextUpdate", noNextUpdate, nextUpdate.Add(time.Hour), true, false, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.resp.ValidAt(tc.now); got != tc.valid {
+				t.Errorf("ValidAt: got %v, want %v", got, tc.valid)
+			}
+			if got := tc.resp.Expired(tc.now); got != tc.expired {
+				t.Errorf("Expired: got %v, want %v", got, tc.expired)
+			}
+			if got := tc.resp.TTL(tc.now); got != tc.ttl {
+				t.Errorf("TTL: got %v, want %v", got, tc.ttl)
+			}
+		})
+	}
+}
+
+func TestCompareFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newResponse := func(serial int64, keyHash byte, thisUpdate, producedAt time.Time) *Response {
+		return &Response{
+			SerialNumber:   big.NewInt(serial),
+			IssuerHash:     crypto.SHA1,
+			IssuerNameHash: bytes.Repeat([]byte{1}, 20),
+			IssuerKeyHash:  bytes.Repeat([]byte{keyHash}, 20),
+			ThisUpdate:     thisUpdate,
+			ProducedAt:     producedAt,
+		}
+	}
+
+	older := newResponse(1, 2, now, now)
+	newer := newResponse(1, 2, now.Add(time.Hour), now.Add(time.Hour))
+	reproduced := newResponse(1, 2, now, now.Add(time.Minute))
+	tests := []struct {
+		name    string
+		a, b    *Response
+		want    int
+		wantErr bool
+	}{
+		{"newer thisUpdate", newer, older, 1, false},
+		{"older thisUpdate", older, newer, -1, false},
+		{"newer producedAt", reproduced, older, 1, false},
+		{"older producedAt", older, reproduced, -1, false},
+		{"equal", older, newResponse(1, 2, now, now), 0, false},
+		{"different serial", older, newResponse(2, 2, now, now), 0, true},
+		{"different issuer", older, newResponse(1, 3, now, now), 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CompareFreshness(tc.a, tc.b)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CompareFreshness: error %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("CompareFreshness: got %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	sha256Response := newResponse(1, 2, now.Add(time.Hour), now)
+	sha256Response.IssuerHash = crypto.SHA256
+	if _, err := CompareFreshness(sha256Response, older); err == nil {
+		t.Error("CompareFreshness with different hashes: expected an error")
+	}
+}
+
+func TestMustStaple(t *testing.T) {
+	mustStaple := func(features ...int) pkix.Extension {
+		value, err := asn1.Marshal(features)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkix.Extension{Id: idPETLSFeature, Value: value}
+	}
+	tests := []struct {
+		name       string
+		extensions []pkix.Extension
+		want       bool
+	}{
+		{"no extension", nil, false},
+		{"status_request", []pkix.Extension{mustStaple(5)}, true},
+		{"multiple features", []pkix.Extension{mustStaple(17, 5)}, true},
+		{"status_request_v2 only", []pkix.Extension{mustStaple(17)}, false},
+		{"malformed", []pkix.Extension{{Id: idPETLSFeature, Value: []byte{0x30, 0x03, 0x02, 0x01}}}, false},
+		{"other extension", []pkix.Extension{{Id: idPKIXOCSPNoCheck, Value: []byte{0x05, 0x00}}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cert := &x509.Certificate{Extensions: tc.extensions}
+			if got := MustStaple(cert); got != tc.want {
+				t.Errorf("MustStaple: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequestorName(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{SerialNumber: big.NewInt(42)}
+
+	der, err := CreateRequest(cert, issuer, &RequestOptions{
+		RequestorName: &pkix.Name{CommonName: "Requestor", Organization: []string{"Acme"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.RequestorName == nil || req.RequestorName.CommonName != "Requestor" ||
+		len(req.RequestorName.Organization) != 1 || req.RequestorName.Organization[0] != "Acme" {
+		t.Fatalf("RequestorName = %v, want CN=Requestor,O=Acme", req.RequestorName)
+	}
+	if req.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("SerialNumber = %v, want %v", req.SerialNumber, cert.SerialNumber)
+	}
+
+	// The raw name takes precedence and is preserved as is.
+	req.RequestorName = &pkix.Name{CommonName: "Ignored"}
+	req.RawRequestorName = issuer.RawSubject
+	der, err = req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.RawRequestorName, issuer.RawSubject) {
+		t.Errorf("RawRequestorName = %x, want %x", req.RawRequestorName, issuer.RawSubject)
+	}
+	if req.RequestorName.String() != issuer.Subject.String() {
+		t.Errorf("RequestorName = %v, want %v", req.RequestorName, issuer.Subject)
+	}
+
+	// Requests without a requestor name are unchanged.
+	expected, _ := hex.DecodeString(ocspRequestHex)
+	req, err = ParseRequest(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.RequestorName != nil || req.RawRequestorName != nil {
+		t.Errorf("RequestorName = %v, want nil", req.RequestorName)
+	}
+	der, err = req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, expected) {
+		t.Errorf("Marshal = %x, want %x", der, expected)
+	}
+}
+
+func TestRequestorNameUnsupported(t *testing.T) {
+	// A requestorName with an rfc822Name GeneralName.
+	email, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, Bytes: []byte("ocsp@example.com")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullBytes, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(ocspRequest{tbsRequest{
+		RequestorName: asn1.RawValue{FullBytes: fullBytes},
+		RequestList: []request{{Cert: certID{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: getOIDFromHashAlgorithm(crypto.SHA1)},
+			NameHash:      make([]byte, 20),
+			IssuerKeyHash: make([]byte, 20),
+			SerialNumber:  big.NewInt(1),
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRequest(der); err != ParseError("unsupported requestor name") {
+		t.Errorf("ParseRequest: got %v, want unsupported requestor name", err)
+	}
+}
+
+func TestAcceptableResponses(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{SerialNumber: big.NewInt(42)}
+
+	der, err := CreateRequest(cert, issuer, &RequestOptions{AcceptBasicResponseOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.AcceptableResponses) != 1 || !req.AcceptableResponses[0].Equal(idPKIXOCSPBasic) {
+		t.Fatalf("AcceptableResponses = %v, want [%v]", req.AcceptableResponses, idPKIXOCSPBasic)
+	}
+	if !req.AcceptsBasicResponse() {
+		t.Error("AcceptsBasicResponse = false, want true")
+	}
+
+	// Re-marshaling a parsed request does not duplicate the extension.
+	other := asn1.ObjectIdentifier{1, 2, 3}
+	req.AcceptableResponses = []asn1.ObjectIdentifier{other}
+	req.Extensions = append(req.Extensions, pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 4}, Value: []byte{5, 0}})
+	der, err = req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Extensions) != 2 {
+		t.Errorf("got %d extensions, want 2", len(req.Extensions))
+	}
+	if len(req.AcceptableResponses) != 1 || !req.AcceptableResponses[0].Equal(other) {
+		t.Errorf("AcceptableResponses = %v, want [%v]", req.AcceptableResponses, other)
+	}
+	if req.AcceptsBasicResponse() {
+		t.Error("AcceptsBasicResponse = true, want false")
+	}
+	if !req.AcceptsResponseType(other) {
+		t.Error("AcceptsResponseType = false, want true")
+	}
+
+	// Requests without the extension accept any response type.
+	der, err = CreateRequest(cert, issuer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.AcceptableResponses != nil || !req.AcceptsBasicResponse() || !req.AcceptsResponseType(other) {
+		t.Errorf("AcceptableResponses = %v, want any response type to be accepted", req.AcceptableResponses)
+	}
+
+	req.Extensions = []pkix.Extension{{Id: idPKIXOCSPResponse, Value: []byte{5, 0}}}
+	der, err = req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req, err = ParseRequest(der); err != nil {
+		t.Fatalf("ParseRequest with an invalid extension: %v", err)
+	}
+	if req.AcceptableResponses != nil || !req.AcceptsBasicResponse() {
+		t.Errorf("ParseRequest with an invalid extension: got AcceptableResponses %v", req.AcceptableResponses)
+	}
+}
+
+func TestParseResponseForCertIDAndSerial(t *testing.T) {
+	der, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ParseResponseForSerial(der, big.NewInt(3), nil)
+	if err != nil {
+		t.Fatalf("ParseResponseForSerial: %v", err)
+	}
+	if resp.SerialNumber.Int64() != 3 {
+		t.Errorf("SerialNumber = %v, want 3", resp.SerialNumber)
+	}
+	if _, err := ParseResponseForSerial(der, big.NewInt(5), nil); err != ParseError("no response matching the supplied serial number") {
+		t.Errorf("ParseResponseForSerial: got %v, want no matching response", err)
+	}
+	if _, err := ParseResponseForSerial(der, nil, nil); err == nil {
+		t.Error("ParseResponseForSerial with a nil serial: expected an error")
+	}
+
+	id := &CertID{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: []byte{1, 2, 3},
+		IssuerKeyHash:  []byte{4, 5, 6},
+		SerialNumber:   big.NewInt(2),
+	}
+	resp, err = ParseResponseForCertID(der, id, nil)
+	if err != nil {
+		t.Fatalf("ParseResponseForCertID: %v", err)
+	}
+	if resp.SerialNumber.Int64() != 2 || !bytes.Equal(resp.IssuerNameHash, id.IssuerNameHash) {
+		t.Errorf("ParseResponseForCertID returned the status of %v", resp.SerialNumber)
+	}
+
+	for name, modify := range map[string]func(*CertID){
+		"hash algorithm": func(id *CertID) { id.HashAlgorithm = crypto.SHA256 },
+		"name hash":      func(id *CertID) { id.IssuerNameHash = []byte{1, 2, 4} },
+		"key hash":       func(id *CertID) { id.IssuerKeyHash = []byte{4, 5} },
+		"serial":         func(id *CertID) { id.SerialNumber = big.NewInt(5) },
+	} {
+		other := *id
+		modify(&other)
+		if _, err := ParseResponseForCertID(der, &other, nil); err != ParseError("no response matching the supplied CertID") {
+			t.Errorf("ParseResponseForCertID with a different %s: got %v, want no matching response", name, err)
+		}
+	}
+	if _, err := ParseResponseForCertID(der, nil, nil); err == nil {
+		t.Error("ParseResponseForCertID with a nil CertID: expected an error")
+	}
+
+	// A responder can check its own output with the CertID of the request.
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqDER, err := CreateRequest(&x509.Certificate{SerialNumber: big.NewInt(7)}, issuer, &RequestOptions{Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(reqDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, key := newTestResponder(t, "Responder")
+	respDER, err := CreateResponseForRequest(req, issuer, responder, Response{
+		Status:     Good,
+		ThisUpdate: time.Now().Truncate(time.Second),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponseForCertID(respDER, req.CertID(), responder); err != nil {
+		t.Errorf("ParseResponseForCertID: %v", err)
+	}
+}
+
+func TestResponseCertificateChain(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	newCert := func(template, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.Signer) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	now := time.Now()
+	rootKey, intermediateKey, responderKey := newKey(), newKey(), newKey()
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root := newCert(rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	intermediate := newCert(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, intermediateKey.Public(), rootKey)
+	responder := newCert(&x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Responder"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, intermediate, responderKey.Public(), intermediateKey)
+
+	template := Response{
+		Status:           Good,
+		SerialNumber:     big.NewInt(10),
+		ThisUpdate:       now.Truncate(time.Second),
+		Certificate:      responder,
+		CertificateChain: []*x509.Certificate{intermediate},
+	}
+	der, err := CreateResponse(root, responder, template, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, root)
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if len(resp.CertificateChain) != 1 || !resp.CertificateChain[0].Equal(intermediate) {
+		t.Errorf("CertificateChain = %v, want the intermediate", resp.CertificateChain)
+	}
+	if got := resp.Template().CertificateChain; len(got) != 1 || !got[0].Equal(intermediate) {
+		t.Errorf("Template().CertificateChain = %v, want the intermediate", got)
+	}
+	marshaled, err := resp.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshaled, der) {
+		t.Error("Marshal does not match the parsed response")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	chains, err := resp.VerifyChain(x509.VerifyOptions{Roots: roots, CurrentTime: now})
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if len(chains) != 1 || len(chains[0]) != 3 {
+		t.Errorf("VerifyChain: got %v, want one chain of 3 certificates", chains)
+	}
+
+	// The responder certificate cannot be linked to the issuer without the
+	// chain.
+	template.CertificateChain = nil
+	der, err = CreateResponse(root, responder, template, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponse(der, root); err == nil {
+		t.Error("ParseResponse without the chain: expected an error")
+	}
+	if _, err := ParseResponse(der, intermediate); err != nil {
+		t.Errorf("ParseResponse with the direct issuer: %v", err)
+	}
+
+	// A chain that does not lead to the issuer is rejected.
+	template.CertificateChain = []*x509.Certificate{responder}
+	der, err = CreateResponse(root, responder, template, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponse(der, root); err == nil {
+		t.Error("ParseResponse with a bad chain: expected an error")
+	}
+
+	template.Certificate = nil
+	template.CertificateChain = []*x509.Certificate{intermediate}
+	if _, err := CreateResponse(root, responder, template, responderKey); err == nil {
+		t.Error("CreateResponse with a chain but no certificate: expected an error")
+	}
+}
+
+func TestParseCriticalExtensionHandler(t *testing.T) {
+	responseBytes, _ := hex.DecodeString(ocspResponseWithCriticalExtensionHex)
+	var handled []asn1.ObjectIdentifier
+	resp, err := ParseResponseWithOptions(responseBytes, nil, nil, &ParseOptions{
+		CriticalExtensionHandler: func(ext pkix.Extension) error {
+			handled = append(handled, ext.Id)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 1 || len(resp.Extensions) != 1 || !resp.Extensions[0].Critical || !handled[0].Equal(resp.Extensions[0].Id) {
+		t.Errorf("CriticalExtensionHandler: got %v for extensions %v", handled, resp.Extensions)
+	}
+
+	errUnknown := errors.New("unknown extension")
+	_, err = ParseResponseWithOptions(responseBytes, nil, nil, &ParseOptions{
+		CriticalExtensionHandler: func(pkix.Extension) error { return errUnknown },
+	})
+	if !errors.Is(err, errUnknown) {
+		t.Errorf("ParseResponseWithOptions: got %v, want %v", err, errUnknown)
+	}
+}
+
+func TestParseStrictVersion(t *testing.T) {
+	strict := &ParseOptions{StrictVersion: true}
+
+	responseBytes, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponseWithOptions(responseBytes, nil, nil, strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Version != 0 {
+		t.Errorf("Version: got %d, want 0", resp.Version)
+	}
+
+	// This response encodes the default version explicitly.
+	responseBytes, _ = hex.DecodeString(ocspResponseWithExtensionHex)
+	if _, err := ParseResponseWithOptions(responseBytes, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponseWithOptions(responseBytes, nil, nil, strict); err == nil {
+		t.Error("ParseResponseWithOptions: expected error for explicit default version")
+	}
+
+	// Insert an explicit version in the TBSRequest of ocspRequestHex.
+	rest := ocspRequestHex[8:]
+	for _, test := range []struct {
+		version string
+		want    int
+	}{
+		{"00", 0},
+		{"01", 1},
+	} {
+		requestBytes, _ := hex.DecodeString("30563054a0030201" + test.version + rest)
+		req, err := ParseRequest(requestBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Version != test.want {
+			t.Errorf("Version: got %d, want %d", req.Version, test.want)
+		}
+		if _, err := ParseRequestWithOptions(requestBytes, strict); err == nil {
+			t.Errorf("ParseRequestWithOptions: expected error for version %s", test.version)
+		}
+	}
+
+	requestBytes, _ := hex.DecodeString(ocspRequestHex)
+	if _, err := ParseRequestWithOptions(requestBytes, strict); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseDuplicates(t *testing.T) {
+	responder, key := newTestResponder(t, "responder")
+	responderID, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: responder.RawSubject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	single := func(serial int64, status int, thisUpdate time.Time) SingleResponse {
+		return SingleResponse{
+			CertID: CertID{
+				HashAlgorithm:  crypto.SHA1,
+				IssuerNameHash: bytes.Repeat([]byte{1}, 20),
+				IssuerKeyHash:  bytes.Repeat([]byte{2}, 20),
+				SerialNumber:   big.NewInt(serial),
+			},
+			Status:     status,
+			ThisUpdate: thisUpdate,
+		}
+	}
+	b := &BasicResponse{
+		RawResponderID: responderID,
+		ProducedAt:     now,
+		Responses: []SingleResponse{
+			single(1, Good, now.Add(-time.Hour)),
+			single(2, Good, now),
+			single(1, Unknown, now),
+			single(1, Good, now.Add(-2*time.Hour)),
+		},
+	}
+	if err := b.Sign(key, 0); err != nil {
+		t.Fatal(err)
+	}
+	der, err := b.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+
+	for _, test := range []struct {
+		policy     DuplicatePolicy
+		status     int
+		thisUpdate time.Time
+		duplicates int
+	}{
+		{DuplicateFirst, Good, now.Add(-time.Hour), 0},
+		{DuplicateFreshest, Unknown, now, 0},
+		{DuplicateAll, Good, now.Add(-time.Hour), 2},
+	} {
+		resp, err := ParseResponseWithOptions(der, cert, responder, &ParseOptions{Duplicates: test.policy})
+		if err != nil {
+			t.Fatalf("policy %d: %v", test.policy, err)
+		}
+		if resp.Status != test.status || !resp.ThisUpdate.Equal(test.thisUpdate) || len(resp.Duplicates) != test.duplicates {
+			t.Errorf("policy %d: got status %d at %v with %d duplicates, want %d at %v with %d duplicates", test.policy,
+				resp.Status, resp.ThisUpdate, len(resp.Duplicates), test.status, test.thisUpdate, test.duplicates)
+		}
+	}
+
+	resp, err := ParseResponseWithOptions(der, cert, responder, &ParseOptions{Duplicates: DuplicateAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Duplicates[0].Status != Unknown || !resp.Duplicates[1].ThisUpdate.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("Duplicates: got %+v", resp.Duplicates)
+	}
+
+	if _, err := ParseResponseWithOptions(der, cert, responder, &ParseOptions{Duplicates: DuplicateError}); err == nil {
+		t.Error("ParseResponseWithOptions: expected error for duplicate statuses")
+	}
+	cert.SerialNumber = big.NewInt(2)
+	if _, err := ParseResponseWithOptions(der, cert, responder, &ParseOptions{Duplicates: DuplicateError}); err != nil {
+		t.Errorf("ParseResponseWithOptions: %v", err)
+	}
+}
+
+func TestNonce(t *testing.T) {
+	issuer, key := newTestResponder(t, "issuer")
+	nonce := []byte("0123456789abcdef")
+	reqDER, err := (&Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: bytes.Repeat([]byte{1}, 20),
+		IssuerKeyHash:  bytes.Repeat([]byte{2}, 20),
+		SerialNumber:   big.NewInt(1),
+		Extensions:     []pkix.Extension{{Id: ocspExtensionOID, Value: []byte{4, 1, 0}}},
+		Nonce:          nonce,
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(reqDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.Nonce, nonce) || len(req.Extensions) != 1 {
+		t.Fatalf("ParseRequest: got nonce %x in extensions %v", req.Nonce, req.Extensions)
+	}
+
+	// Raw nonces, not wrapped in an OCTET STRING, are not rejected.
+	rawDER, err := (&Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: bytes.Repeat([]byte{1}, 20),
+		IssuerKeyHash:  bytes.Repeat([]byte{2}, 20),
+		SerialNumber:   big.NewInt(1),
+		Extensions:     []pkix.Extension{{Id: idPKIXOCSPNonce, Value: nonce}},
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawReq, err := ParseRequest(rawDER)
+	if err != nil {
+		t.Fatalf("ParseRequest with a raw nonce: %v", err)
+	}
+	if rawReq.Nonce != nil || len(rawReq.Extensions) != 1 || !bytes.Equal(rawReq.Extensions[0].Value, nonce) {
+		t.Errorf("ParseRequest with a raw nonce: got nonce %x in extensions %v", rawReq.Nonce, rawReq.Extensions)
+	}
+
+	template := Response{Status: Good, ThisUpdate: time.Now().Truncate(time.Second)}
+	der, err := CreateResponseForRequest(req, nil, issuer, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Nonce, nonce) || len(resp.ResponseExtensions) != 1 || len(resp.Extensions) != 0 {
+		t.Errorf("CreateResponseForRequest: got nonce %x in response extensions %v", resp.Nonce, resp.ResponseExtensions)
+	}
+
+	// The nonce replaces the one in ResponseExtraExtensions.
+	template.Nonce = []byte("fedcba9876543210")
+	template.ResponseExtraExtensions = []pkix.Extension{{Id: ocspExtensionOID, Value: []byte{4, 1, 0}}}
+	der, err = CreateResponseForRequest(req, nil, issuer, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = ParseResponse(der, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Nonce, template.Nonce) || len(resp.ResponseExtensions) != 1 {
+		t.Errorf("CreateResponseForRequest: got nonce %x in response extensions %v", resp.Nonce, resp.ResponseExtensions)
+	}
+
+	der, err = CreateResponseWithOptions(nil, issuer, resp.Template(), key, &CreateResponseOptions{OmitExtensions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = ParseResponse(der, nil); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Nonce != nil || len(resp.ResponseExtensions) != 0 {
+		t.Errorf("CreateResponseWithOptions: got nonce %x with OmitExtensions", resp.Nonce)
+	}
+}
+
 // This OCSP response was taken from GTS's public OCSP responder.
 // To recreate:
 //   $ openssl s_client -tls1 -showcerts -servername golang.org -connect golang.org:443
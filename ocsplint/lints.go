@@ -0,0 +1,212 @@
+package ocsplint
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+)
+
+var (
+	oidExtensionNonce   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	oidExtensionNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+)
+
+// maxNonceSize is the maximum nonce size allowed by RFC 8954.
+const maxNonceSize = 32
+
+// SHA1Signature warns about responses signed with SHA-1 or MD5.
+var SHA1Signature = Lint{
+	Name:        "w_ocsp_sha1_signature",
+	Description: "Responses should not be signed with SHA-1 or weaker hash functions",
+	Severity:    Warning,
+	Check: func(in *Input) []string {
+		if isSHA1(in.Response.SignatureAlgorithm) {
+			return []string{"response is signed with " + in.Response.SignatureAlgorithm.String()}
+		}
+		return nil
+	},
+}
+
+// isSHA1 reports whether algo uses SHA-1 or a weaker hash function.
+func isSHA1(algo x509.SignatureAlgorithm) bool {
+	switch algo {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	}
+	return false
+}
+
+// MissingNextUpdate warns about responses without nextUpdate.
+var MissingNextUpdate = Lint{
+	Name:        "w_ocsp_missing_next_update",
+	Description: "Responses should include nextUpdate so clients and caches know when they expire",
+	Severity:    Warning,
+	Check: func(in *Input) []string {
+		if !in.Response.HasNextUpdate() {
+			return []string{"response does not include nextUpdate"}
+		}
+		return nil
+	},
+}
+
+// NextUpdateBeforeThisUpdate reports responses with nextUpdate before
+// thisUpdate.
+var NextUpdateBeforeThisUpdate = Lint{
+	Name:        "e_ocsp_next_update_before_this_update",
+	Description: "The nextUpdate time must not be before thisUpdate",
+	Severity:    Error,
+	Check: func(in *Input) []string {
+		resp := in.Response
+		if resp.HasNextUpdate() && resp.NextUpdate.Before(resp.ThisUpdate) {
+			return []string{fmt.Sprintf("nextUpdate %s is before thisUpdate %s", resp.NextUpdate, resp.ThisUpdate)}
+		}
+		return nil
+	},
+}
+
+// ProducedAtInFuture reports responses with producedAt after the time they
+// are checked.
+var ProducedAtInFuture = Lint{
+	Name:        "e_ocsp_produced_at_in_future",
+	Description: "The producedAt time must not be in the future",
+	Severity:    Error,
+	Check: func(in *Input) []string {
+		if now := in.now(); in.Response.ProducedAt.After(now) {
+			return []string{fmt.Sprintf("producedAt %s is after %s", in.Response.ProducedAt, now)}
+		}
+		return nil
+	},
+}
+
+// ThisUpdateInFuture reports responses with thisUpdate after the time they
+// are checked.
+var ThisUpdateInFuture = Lint{
+	Name:        "e_ocsp_this_update_in_future",
+	Description: "The thisUpdate time must not be in the future",
+	Severity:    Error,
+	Check: func(in *Input) []string {
+		if now := in.now(); in.Response.ThisUpdate.After(now) {
+			return []string{fmt.Sprintf("thisUpdate %s is after %s", in.Response.ThisUpdate, now)}
+		}
+		return nil
+	},
+}
+
+// NonceSize reports nonces that are empty or larger than the 32 bytes allowed
+// by RFC 8954.
+var NonceSize = Lint{
+	Name:        "e_ocsp_nonce_size",
+	Description: "The nonce must be between 1 and 32 bytes long, as defined in RFC 8954",
+	Severity:    Error,
+	Check: func(in *Input) []string {
+		for _, ext := range in.Response.ResponseExtensions {
+			if !ext.Id.Equal(oidExtensionNonce) {
+				continue
+			}
+			var nonce []byte
+			if rest, err := asn1.Unmarshal(ext.Value, &nonce); err != nil || len(rest) != 0 {
+				return []string{"nonce is not a valid OCTET STRING"}
+			}
+			if len(nonce) == 0 || len(nonce) > maxNonceSize {
+				return []string{fmt.Sprintf("nonce is %d bytes long", len(nonce))}
+			}
+		}
+		return nil
+	},
+}
+
+// delegatedResponder returns the certificate of the responder that signed the
+// response on behalf of the CA, or nil if it was signed by the CA.
+func delegatedResponder(in *Input) *x509.Certificate {
+	cert := in.Response.Certificate
+	if cert == nil {
+		return nil
+	}
+	if in.Issuer != nil {
+		if bytes.Equal(cert.Raw, in.Issuer.Raw) {
+			return nil
+		}
+	} else if cert.IsCA {
+		return nil
+	}
+	return cert
+}
+
+// ResponderMissingOCSPSigning reports delegated responder certificates
+// without the OCSPSigning extended key usage, required by RFC 6960, section
+// 4.2.2.2.
+var ResponderMissingOCSPSigning = Lint{
+	Name:        "e_ocsp_responder_missing_ocsp_signing_eku",
+	Description: "Delegated responder certificates must include the id-kp-OCSPSigning extended key usage",
+	Severity:    Error,
+	Check: func(in *Input) []string {
+		cert := delegatedResponder(in)
+		if cert == nil {
+			return nil
+		}
+		for _, eku := range cert.ExtKeyUsage {
+			if eku == x509.ExtKeyUsageOCSPSigning {
+				return nil
+			}
+		}
+		return []string{"responder certificate " + cert.Subject.String() + " does not include id-kp-OCSPSigning"}
+	},
+}
+
+// ResponderMissingNoCheck warns about delegated responder certificates
+// without the id-pkix-ocsp-nocheck extension, which forces clients to check
+// the revocation status of the responder.
+var ResponderMissingNoCheck = Lint{
+	Name:        "w_ocsp_responder_missing_nocheck",
+	Description: "Delegated responder certificates should include the id-pkix-ocsp-nocheck extension",
+	Severity:    Warning,
+	Check: func(in *Input) []string {
+		cert := delegatedResponder(in)
+		if cert == nil {
+			return nil
+		}
+		for _, ext := range cert.Extensions {
+			if ext.Id.Equal(oidExtensionNoCheck) {
+				return nil
+			}
+		}
+		return []string{"responder certificate " + cert.Subject.String() + " does not include id-pkix-ocsp-nocheck"}
+	},
+}
+
+// MaxValidity returns a lint reporting responses with a validity interval,
+// from thisUpdate to nextUpdate, longer than max.
+func MaxValidity(max time.Duration) Lint {
+	return Lint{
+		Name:        "e_ocsp_validity_too_long",
+		Description: "The validity interval of responses must not be longer than " + max.String(),
+		Severity:    Error,
+		Check: func(in *Input) []string {
+			resp := in.Response
+			if !resp.HasNextUpdate() {
+				return nil
+			}
+			if d := resp.NextUpdate.Sub(resp.ThisUpdate); d > max {
+				return []string{fmt.Sprintf("validity interval is %s, maximum is %s", d, max)}
+			}
+			return nil
+		},
+	}
+}
+
+// DefaultLints returns the lints that check the requirements of RFC 6960 and
+// RFC 8954, and the practices recommended by RFC 5019.
+func DefaultLints() []Lint {
+	return []Lint{
+		SHA1Signature,
+		MissingNextUpdate,
+		NextUpdateBeforeThisUpdate,
+		ProducedAtInFuture,
+		ThisUpdateInFuture,
+		NonceSize,
+		ResponderMissingOCSPSigning,
+		ResponderMissingNoCheck,
+	}
+}
@@ -0,0 +1,132 @@
+package ocsplint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func nonceExtension(t *testing.T, size int) pkix.Extension {
+	t.Helper()
+	value, err := asn1.Marshal(make([]byte, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkix.Extension{Id: oidExtensionNonce, Value: value}
+}
+
+func TestLints(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ca := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "CA"},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	responder := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "Responder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidExtensionNoCheck, Value: []byte{0x05, 0x00}},
+		},
+	})
+	server := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "Server"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	good := func(f func(*ocsp.Response)) *Input {
+		resp := &ocsp.Response{
+			Status:             ocsp.Good,
+			SignatureAlgorithm: x509.ECDSAWithSHA256,
+			ProducedAt:         now,
+			ThisUpdate:         now,
+			NextUpdate:         now.Add(24 * time.Hour),
+		}
+		if f != nil {
+			f(resp)
+		}
+		return &Input{Response: resp, Now: now}
+	}
+
+	tests := []struct {
+		name  string
+		lint  Lint
+		in    *Input
+		wantN int
+	}{
+		{"sha256 signature", SHA1Signature, good(nil), 0},
+		{"sha1 signature", SHA1Signature, good(func(r *ocsp.Response) { r.SignatureAlgorithm = x509.SHA1WithRSA }), 1},
+		{"next update", MissingNextUpdate, good(nil), 0},
+		{"missing next update", MissingNextUpdate, good(func(r *ocsp.Response) { r.NextUpdate = time.Time{} }), 1},
+		{"next update after this update", NextUpdateBeforeThisUpdate, good(nil), 0},
+		{"next update before this update", NextUpdateBeforeThisUpdate, good(func(r *ocsp.Response) { r.NextUpdate = now.Add(-time.Second) }), 1},
+		{"produced at now", ProducedAtInFuture, good(nil), 0},
+		{"produced at in future", ProducedAtInFuture, good(func(r *ocsp.Response) { r.ProducedAt = now.Add(time.Minute) }), 1},
+		{"this update now", ThisUpdateInFuture, good(nil), 0},
+		{"this update in future", ThisUpdateInFuture, good(func(r *ocsp.Response) { r.ThisUpdate = now.Add(time.Minute) }), 1},
+		{"no nonce", NonceSize, good(nil), 0},
+		{"nonce", NonceSize, good(func(r *ocsp.Response) { r.ResponseExtensions = []pkix.Extension{nonceExtension(t, 32)} }), 0},
+		{"empty nonce", NonceSize, good(func(r *ocsp.Response) { r.ResponseExtensions = []pkix.Extension{nonceExtension(t, 0)} }), 1},
+		{"large nonce", NonceSize, good(func(r *ocsp.Response) { r.ResponseExtensions = []pkix.Extension{nonceExtension(t, 33)} }), 1},
+		{"malformed nonce", NonceSize, good(func(r *ocsp.Response) {
+			r.ResponseExtensions = []pkix.Extension{{Id: oidExtensionNonce, Value: []byte{1, 2, 3}}}
+		}), 1},
+		{"validity", MaxValidity(24 * time.Hour), good(nil), 0},
+		{"validity too long", MaxValidity(23 * time.Hour), good(nil), 1},
+		{"validity without next update", MaxValidity(time.Hour), good(func(r *ocsp.Response) { r.NextUpdate = time.Time{} }), 0},
+		{"no responder", ResponderMissingOCSPSigning, good(nil), 0},
+		{"ca responder", ResponderMissingOCSPSigning, good(func(r *ocsp.Response) { r.Certificate = ca }), 0},
+		{"delegated responder", ResponderMissingOCSPSigning, good(func(r *ocsp.Response) { r.Certificate = responder }), 0},
+		{"responder without eku", ResponderMissingOCSPSigning, good(func(r *ocsp.Response) { r.Certificate = server }), 1},
+		{"delegated responder with nocheck", ResponderMissingNoCheck, good(func(r *ocsp.Response) { r.Certificate = responder }), 0},
+		{"delegated responder without nocheck", ResponderMissingNoCheck, good(func(r *ocsp.Response) { r.Certificate = server }), 1},
+		{"ca responder without nocheck", ResponderMissingNoCheck, good(func(r *ocsp.Response) { r.Certificate = ca }), 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.lint.Check(tc.in); len(got) != tc.wantN {
+				t.Errorf("%s: got %q, want %d findings", tc.lint.Name, got, tc.wantN)
+			}
+		})
+	}
+
+	// With the issuer, only the issuer itself is not a delegated responder.
+	in := good(func(r *ocsp.Response) { r.Certificate = ca })
+	in.Issuer = ca
+	if got := ResponderMissingOCSPSigning.Check(in); len(got) != 0 {
+		t.Errorf("issuer as responder: got %q", got)
+	}
+	in.Issuer = responder
+	if got := ResponderMissingOCSPSigning.Check(in); len(got) != 1 {
+		t.Errorf("other CA as responder: got %q, want 1 finding", got)
+	}
+}
@@ -0,0 +1,134 @@
+// Package ocsplint implements checks of OCSP responses against the
+// requirements of RFC 6960, RFC 5019 and common CA policies, in the style of
+// zlint. CAs can use it to lint their responses before deploying them.
+package ocsplint
+
+import (
+	"crypto/x509"
+	"strconv"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+// Severity is the severity of a Finding.
+type Severity int
+
+const (
+	// Notice findings are informational and do not indicate a problem.
+	Notice Severity = iota + 1
+	// Warning findings indicate a practice that is discouraged.
+	Warning
+	// Error findings indicate a violation of a requirement.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Notice:
+		return "notice"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "unknown severity " + strconv.Itoa(int(s))
+	}
+}
+
+// Input is the response examined by the lints.
+type Input struct {
+	// Response is the parsed response. It is required.
+	Response *ocsp.Response
+	// Issuer is the optional certificate of the CA that issued the
+	// certificate the response is for.
+	Issuer *x509.Certificate
+	// Certificate is the optional certificate the response is for. Lints
+	// with exceptions depending on it apply without them if it is nil.
+	Certificate *x509.Certificate
+	// Now is the time the response is checked at. If zero, the current time
+	// is used.
+	Now time.Time
+	// Unissued indicates that the response is for a serial number that does
+	// not correspond to an issued certificate.
+	Unissued bool
+}
+
+func (in *Input) now() time.Time {
+	if in.Now.IsZero() {
+		return time.Now()
+	}
+	return in.Now
+}
+
+// Lint is a single check of a response.
+type Lint struct {
+	// Name uniquely identifies the lint, for example,
+	// "e_ocsp_sha1_signature". The prefix follows the zlint convention of
+	// the severity of its findings.
+	Name string
+	// Description describes what the lint checks.
+	Description string
+	// Severity is the severity of the findings of the lint.
+	Severity Severity
+	// Check returns a description of each problem found in the input, or
+	// nil if there is none.
+	Check func(in *Input) []string
+}
+
+// Finding is a problem found by a lint.
+type Finding struct {
+	Lint     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return f.Severity.String() + ": " + f.Lint + ": " + f.Message
+}
+
+// Report contains the findings of running a set of lints.
+type Report struct {
+	// Profile is the name and version of the profile the lints belong to,
+	// empty if the lints were not run from a profile.
+	Profile  string
+	Findings []Finding
+}
+
+// MaxSeverity returns the highest severity of the findings, or zero if there
+// are none.
+func (r *Report) MaxSeverity() Severity {
+	var max Severity
+	for _, f := range r.Findings {
+		if f.Severity > max {
+			max = f.Severity
+		}
+	}
+	return max
+}
+
+// HasErrors reports whether the report contains findings with the Error
+// severity.
+func (r *Report) HasErrors() bool {
+	return r.MaxSeverity() >= Error
+}
+
+// Passed reports whether the report does not contain errors.
+func (r *Report) Passed() bool {
+	return !r.HasErrors()
+}
+
+// Run runs the given lints on in and returns their findings.
+func Run(in *Input, lints ...Lint) *Report {
+	r := new(Report)
+	for _, l := range lints {
+		for _, msg := range l.Check(in) {
+			r.Findings = append(r.Findings, Finding{
+				Lint:     l.Name,
+				Severity: l.Severity,
+				Message:  msg,
+			})
+		}
+	}
+	return r
+}
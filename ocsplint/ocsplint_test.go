@@ -0,0 +1,62 @@
+package ocsplint
+
+import (
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	in := &Input{
+		Response: &ocsp.Response{
+			ThisUpdate: now.Add(-time.Hour),
+			ProducedAt: now.Add(time.Hour),
+		},
+		Now: now,
+	}
+
+	r := Run(in, DefaultLints()...)
+	want := map[string]Severity{
+		"w_ocsp_missing_next_update":   Warning,
+		"e_ocsp_produced_at_in_future": Error,
+	}
+	if len(r.Findings) != len(want) {
+		t.Fatalf("Run: got findings %v, want %v", r.Findings, want)
+	}
+	for _, f := range r.Findings {
+		if sev, ok := want[f.Lint]; !ok || sev != f.Severity {
+			t.Errorf("Run: unexpected finding %v", f)
+		}
+	}
+	if r.MaxSeverity() != Error || !r.HasErrors() {
+		t.Errorf("MaxSeverity: got %v, want %v", r.MaxSeverity(), Error)
+	}
+
+	r = Run(in, MissingNextUpdate)
+	if r.MaxSeverity() != Warning || r.HasErrors() {
+		t.Errorf("MaxSeverity: got %v, want %v", r.MaxSeverity(), Warning)
+	}
+	if got := r.Findings[0].String(); got != "warning: w_ocsp_missing_next_update: response does not include nextUpdate" {
+		t.Errorf("Finding.String: got %q", got)
+	}
+
+	r = Run(in)
+	if len(r.Findings) != 0 || r.MaxSeverity() != 0 || r.HasErrors() {
+		t.Errorf("Run without lints: got %v", r.Findings)
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	for sev, want := range map[Severity]string{
+		Notice:       "notice",
+		Warning:      "warning",
+		Error:        "error",
+		Severity(10): "unknown severity 10",
+	} {
+		if got := sev.String(); got != want {
+			t.Errorf("String: got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ocsplint
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+// Profile is a named and versioned set of lints, so audits can reference the
+// exact rules a response was checked with.
+type Profile struct {
+	// Name identifies the profile, for example, "cabf-br".
+	Name string
+	// Version is the version of the requirements implemented by the lints.
+	Version string
+	// Lints are the checks of the profile.
+	Lints []Lint
+}
+
+// String returns the name and version of the profile.
+func (p Profile) String() string {
+	return p.Name + "@" + p.Version
+}
+
+// Run runs the lints of the profile on in.
+func (p Profile) Run(in *Input) *Report {
+	r := Run(in, p.Lints...)
+	r.Profile = p.String()
+	return r
+}
+
+// RunProfiles runs each profile on in and returns their reports, in the same
+// order. Responders and linters can use it to check a response against the
+// requirements of several root programs at once.
+func RunProfiles(in *Input, list ...Profile) []*Report {
+	reports := make([]*Report, len(list))
+	for i, p := range list {
+		reports[i] = p.Run(in)
+	}
+	return reports
+}
+
+var profiles struct {
+	sync.RWMutex
+	m map[string]Profile
+}
+
+// RegisterProfile registers a profile so it can be found with LookupProfile.
+// It returns an error if a profile with the same name and version is already
+// registered.
+func RegisterProfile(p Profile) error {
+	profiles.Lock()
+	defer profiles.Unlock()
+	if profiles.m == nil {
+		profiles.m = make(map[string]Profile)
+	}
+	if _, ok := profiles.m[p.String()]; ok {
+		return fmt.Errorf("ocsplint: profile %s is already registered", p)
+	}
+	profiles.m[p.String()] = p
+	return nil
+}
+
+// LookupProfile returns the registered profile with the given name and
+// version.
+func LookupProfile(name, version string) (Profile, bool) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	p, ok := profiles.m[name+"@"+version]
+	return p, ok
+}
+
+// Profiles returns the registered profiles sorted by name and version.
+func Profiles() []Profile {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	list := make([]Profile, 0, len(profiles.m))
+	for _, p := range profiles.m {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].String() < list[j].String()
+	})
+	return list
+}
+
+var (
+	oidExtensionExtendedRevoke = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 9}
+	extendedRevokeTime         = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// validityInterval returns the validity interval of resp as defined in the
+// Baseline Requirements: the difference between thisUpdate and nextUpdate,
+// inclusive.
+func validityInterval(resp *ocsp.Response) time.Duration {
+	return resp.NextUpdate.Sub(resp.ThisUpdate) + time.Second
+}
+
+// BRv2_0_0 is the profile of the OCSP requirements in version 2.0.0 of the
+// CA/Browser Forum Baseline Requirements, sections 4.9.10, 7.1.2.8 and
+// 7.1.3.2. It includes the default lints, with the warnings the Baseline
+// Requirements make mandatory replaced by errors.
+var BRv2_0_0 = newProfile("cabf-br", "2.0.0", "br", Rules{
+	RequireNextUpdate:            true,
+	MinValidity:                  8 * time.Hour,
+	MaxValidity:                  10 * 24 * time.Hour,
+	ForbidSHA1:                   true,
+	AllowSHA1ForSHA1Certificates: true,
+	RequireNoCheck:               true,
+	ForbidGoodForUnissued:        true,
+})
+
+func init() {
+	if err := RegisterProfile(BRv2_0_0); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,110 @@
+package ocsplint
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+func TestBRv2_0_0(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newInput := func(f func(*Input)) *Input {
+		in := &Input{
+			Response: &ocsp.Response{
+				Status:             ocsp.Good,
+				SignatureAlgorithm: x509.ECDSAWithSHA256,
+				ProducedAt:         now,
+				ThisUpdate:         now,
+				NextUpdate:         now.Add(4 * 24 * time.Hour),
+			},
+			Now: now,
+		}
+		if f != nil {
+			f(in)
+		}
+		return in
+	}
+	extendedRevoke := func(in *Input) {
+		in.Unissued = true
+		in.Response.Status = ocsp.Revoked
+		in.Response.RevokedAt = extendedRevokeTime
+		in.Response.RevocationReason = ocsp.CertificateHold
+		in.Response.ResponseExtensions = []pkix.Extension{{Id: oidExtensionExtendedRevoke, Value: []byte{0x05, 0x00}}}
+	}
+
+	tests := []struct {
+		name string
+		in   *Input
+		want []string
+	}{
+		{"ok", newInput(nil), nil},
+		{"missing next update", newInput(func(in *Input) { in.Response.NextUpdate = time.Time{} }), []string{"e_br_ocsp_missing_next_update"}},
+		{"eight hours", newInput(func(in *Input) { in.Response.NextUpdate = now.Add(8*time.Hour - time.Second) }), nil},
+		{"too short", newInput(func(in *Input) { in.Response.NextUpdate = now.Add(8*time.Hour - 2*time.Second) }), []string{"e_br_ocsp_validity_too_short"}},
+		{"ten days", newInput(func(in *Input) { in.Response.NextUpdate = now.Add(10*24*time.Hour - time.Second) }), nil},
+		{"too long", newInput(func(in *Input) { in.Response.NextUpdate = now.Add(10 * 24 * time.Hour) }), []string{"e_br_ocsp_validity_too_long"}},
+		{"sha1", newInput(func(in *Input) { in.Response.SignatureAlgorithm = x509.SHA1WithRSA }), []string{"e_br_ocsp_sha1_signature"}},
+		{"sha1 for sha256 certificate", newInput(func(in *Input) {
+			in.Response.SignatureAlgorithm = x509.SHA1WithRSA
+			in.Certificate = &x509.Certificate{SignatureAlgorithm: x509.SHA256WithRSA}
+		}), []string{"e_br_ocsp_sha1_signature"}},
+		{"sha1 for sha1 certificate", newInput(func(in *Input) {
+			in.Response.SignatureAlgorithm = x509.SHA1WithRSA
+			in.Certificate = &x509.Certificate{SignatureAlgorithm: x509.SHA1WithRSA}
+		}), nil},
+		{"good for unissued", newInput(func(in *Input) { in.Unissued = true }), []string{"e_br_ocsp_good_for_unissued"}},
+		{"unknown for unissued", newInput(func(in *Input) { in.Unissued = true; in.Response.Status = ocsp.Unknown }), nil},
+		{"extended revoke", newInput(extendedRevoke), nil},
+		{"bad extended revoke", newInput(func(in *Input) {
+			extendedRevoke(in)
+			in.Response.RevokedAt = now
+			in.Response.RevocationReason = ocsp.KeyCompromise
+			in.Response.ResponseExtensions = nil
+		}), []string{"e_br_ocsp_good_for_unissued", "e_br_ocsp_good_for_unissued", "e_br_ocsp_good_for_unissued"}},
+		{"in future", newInput(func(in *Input) { in.Now = now.Add(-time.Second) }), []string{"e_ocsp_produced_at_in_future", "e_ocsp_this_update_in_future"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := BRv2_0_0.Run(tc.in)
+			var got []string
+			for _, f := range r.Findings {
+				got = append(got, f.Lint)
+				if f.Severity != Error {
+					t.Errorf("finding %v is not an error", f)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Run: got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Run: got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	p, ok := LookupProfile("cabf-br", "2.0.0")
+	if !ok || p.String() != "cabf-br@2.0.0" || len(p.Lints) != len(BRv2_0_0.Lints) {
+		t.Errorf("LookupProfile: got %v, %v", p, ok)
+	}
+	if _, ok := LookupProfile("cabf-br", "0.0.1"); ok {
+		t.Error("LookupProfile: found missing version")
+	}
+	if err := RegisterProfile(BRv2_0_0); err == nil {
+		t.Error("RegisterProfile didn't fail with a registered profile")
+	}
+
+	found := false
+	for _, p := range Profiles() {
+		found = found || p.String() == BRv2_0_0.String()
+	}
+	if !found {
+		t.Errorf("Profiles: %v does not include %v", Profiles(), BRv2_0_0)
+	}
+}
@@ -0,0 +1,265 @@
+package ocsplint
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+// Rules describes the constraints of a policy on OCSP responses as data, so
+// profiles can be defined, or loaded from configuration, without writing
+// lints. Zero values disable the corresponding checks.
+type Rules struct {
+	// RequireNextUpdate requires responses to include nextUpdate.
+	RequireNextUpdate bool `json:"requireNextUpdate,omitempty"`
+	// MinValidity is the minimum validity interval, from thisUpdate to
+	// nextUpdate inclusive.
+	MinValidity time.Duration `json:"minValidity,omitempty"`
+	// MaxValidity is the maximum validity interval, from thisUpdate to
+	// nextUpdate inclusive.
+	MaxValidity time.Duration `json:"maxValidity,omitempty"`
+	// ForbidSHA1 forbids responses signed with SHA-1.
+	ForbidSHA1 bool `json:"forbidSHA1,omitempty"`
+	// AllowSHA1ForSHA1Certificates exempts from ForbidSHA1 the responses for
+	// certificates signed with SHA-1, as given by Input.Certificate.
+	AllowSHA1ForSHA1Certificates bool `json:"allowSHA1ForSHA1Certificates,omitempty"`
+	// RequireNoCheck requires delegated responder certificates to include
+	// the id-pkix-ocsp-nocheck extension.
+	RequireNoCheck bool `json:"requireNoCheck,omitempty"`
+	// RequireRevocationReason requires revoked responses to disclose a
+	// revocation reason other than unspecified.
+	RequireRevocationReason bool `json:"requireRevocationReason,omitempty"`
+	// AllowedRevocationReasons, if not empty, are the only revocation reasons
+	// allowed in revoked responses.
+	AllowedRevocationReasons []int `json:"allowedRevocationReasons,omitempty"`
+	// ForbidGoodForUnissued forbids the good status in responses for
+	// serial numbers that were not issued, as given by Input.Unissued. Those
+	// may use the extended revoke response described in RFC 6960, section
+	// 2.2, instead.
+	ForbidGoodForUnissued bool `json:"forbidGoodForUnissued,omitempty"`
+}
+
+// Lints returns the lints checking the rules. The name of each lint includes
+// the given prefix, for example, "e_<prefix>_ocsp_validity_too_long".
+func (r Rules) Lints(prefix string) []Lint {
+	name := func(s string) string {
+		return "e_" + prefix + "_ocsp_" + s
+	}
+	var lints []Lint
+	if r.RequireNextUpdate {
+		lints = append(lints, Lint{
+			Name:        name("missing_next_update"),
+			Description: "Responses must include nextUpdate",
+			Severity:    Error,
+			Check:       MissingNextUpdate.Check,
+		})
+	}
+	if min := r.MinValidity; min > 0 {
+		lints = append(lints, Lint{
+			Name:        name("validity_too_short"),
+			Description: "Responses must have a validity interval greater than or equal to " + min.String(),
+			Severity:    Error,
+			Check: func(in *Input) []string {
+				if resp := in.Response; resp.HasNextUpdate() && validityInterval(resp) < min {
+					return []string{fmt.Sprintf("validity interval is %s, minimum is %s", validityInterval(resp), min)}
+				}
+				return nil
+			},
+		})
+	}
+	if max := r.MaxValidity; max > 0 {
+		lints = append(lints, Lint{
+			Name:        name("validity_too_long"),
+			Description: "Responses must have a validity interval less than or equal to " + max.String(),
+			Severity:    Error,
+			Check: func(in *Input) []string {
+				if resp := in.Response; resp.HasNextUpdate() && validityInterval(resp) > max {
+					return []string{fmt.Sprintf("validity interval is %s, maximum is %s", validityInterval(resp), max)}
+				}
+				return nil
+			},
+		})
+	}
+	if r.ForbidSHA1 {
+		l := Lint{
+			Name:        name("sha1_signature"),
+			Description: "Responses must not be signed with SHA-1",
+			Severity:    Error,
+			Check:       SHA1Signature.Check,
+		}
+		if r.AllowSHA1ForSHA1Certificates {
+			l.Description += ", unless the certificate they are for is signed with SHA-1"
+			l.Check = func(in *Input) []string {
+				if in.Certificate != nil && isSHA1(in.Certificate.SignatureAlgorithm) {
+					return nil
+				}
+				return SHA1Signature.Check(in)
+			}
+		}
+		lints = append(lints, l)
+	}
+	if r.RequireNoCheck {
+		lints = append(lints, Lint{
+			Name:        name("responder_missing_nocheck"),
+			Description: "Delegated responder certificates must include the id-pkix-ocsp-nocheck extension",
+			Severity:    Error,
+			Check:       ResponderMissingNoCheck.Check,
+		})
+	}
+	if r.RequireRevocationReason {
+		lints = append(lints, Lint{
+			Name:        name("missing_revocation_reason"),
+			Description: "Revoked responses must disclose the revocation reason",
+			Severity:    Error,
+			Check: func(in *Input) []string {
+				if resp := in.Response; resp.Status == ocsp.Revoked && resp.RevocationReason == ocsp.Unspecified {
+					return []string{"revoked response does not disclose the revocation reason"}
+				}
+				return nil
+			},
+		})
+	}
+	if allowed := r.AllowedRevocationReasons; len(allowed) > 0 {
+		reasons := make([]string, len(allowed))
+		for i, reason := range allowed {
+			reasons[i] = fmt.Sprint(reason)
+		}
+		lints = append(lints, Lint{
+			Name:        name("revocation_reason_not_allowed"),
+			Description: "Revoked responses must use one of the revocation reasons " + strings.Join(reasons, ", "),
+			Severity:    Error,
+			Check: func(in *Input) []string {
+				resp := in.Response
+				if resp.Status != ocsp.Revoked {
+					return nil
+				}
+				for _, reason := range allowed {
+					if resp.RevocationReason == reason {
+						return nil
+					}
+				}
+				return []string{fmt.Sprintf("revocation reason %d is not allowed", resp.RevocationReason)}
+			},
+		})
+	}
+	if r.ForbidGoodForUnissued {
+		lints = append(lints, Lint{
+			Name:        name("good_for_unissued"),
+			Description: "Responses for serial numbers that were not issued must not have the good status; they may use extended revoke as described in RFC 6960, section 2.2",
+			Severity:    Error,
+			Check:       checkUnissued,
+		})
+	}
+	return lints
+}
+
+// checkUnissued checks that a response for an unissued serial number does not
+// have the good status, and that revoked ones are valid extended revoke
+// responses.
+func checkUnissued(in *Input) []string {
+	if !in.Unissued {
+		return nil
+	}
+	resp := in.Response
+	switch resp.Status {
+	case ocsp.Good:
+		return []string{"response for an unissued serial number has the good status"}
+	case ocsp.Revoked:
+		var problems []string
+		if !resp.RevokedAt.Equal(extendedRevokeTime) {
+			problems = append(problems, "extended revoke response does not have a revocation time of 1970-01-01")
+		}
+		if resp.RevocationReason != ocsp.CertificateHold {
+			problems = append(problems, "extended revoke response does not have the certificateHold reason")
+		}
+		found := false
+		for _, ext := range resp.ResponseExtensions {
+			found = found || ext.Id.Equal(oidExtensionExtendedRevoke)
+		}
+		if !found {
+			problems = append(problems, "extended revoke response does not include the extended revoke extension")
+		}
+		return problems
+	}
+	return nil
+}
+
+// NewProfile returns a profile with the lints checking rules, and the default
+// lints not superseded by them. The names of the rule lints are prefixed with
+// the profile name.
+func NewProfile(name, version string, rules Rules) Profile {
+	prefix := strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name)
+	return newProfile(name, version, prefix, rules)
+}
+
+// newProfile is like NewProfile, with the given prefix for the names of the
+// rule lints.
+func newProfile(name, version, prefix string, rules Rules) Profile {
+	var lints []Lint
+	for _, l := range DefaultLints() {
+		switch {
+		case rules.RequireNextUpdate && l.Name == MissingNextUpdate.Name,
+			rules.ForbidSHA1 && l.Name == SHA1Signature.Name,
+			rules.RequireNoCheck && l.Name == ResponderMissingNoCheck.Name:
+			continue
+		}
+		lints = append(lints, l)
+	}
+	return Profile{
+		Name:    name,
+		Version: version,
+		Lints:   append(lints, rules.Lints(prefix)...),
+	}
+}
+
+// The root program profiles. Each one is identified by the version of the
+// policy it implements.
+var (
+	// MozillaV2_9 is the profile of version 2.9 of the Mozilla Root Store
+	// Policy, which restricts the revocation reasons of end-entity TLS
+	// certificates in section 6.1.1.
+	MozillaV2_9 = NewProfile("mozilla", "2.9", Rules{
+		RequireNextUpdate: true,
+		MinValidity:       8 * time.Hour,
+		MaxValidity:       10 * 24 * time.Hour,
+		ForbidSHA1:        true,
+		RequireNoCheck:    true,
+		AllowedRevocationReasons: []int{
+			ocsp.Unspecified,
+			ocsp.KeyCompromise,
+			ocsp.AffiliationChanged,
+			ocsp.Superseded,
+			ocsp.CessationOfOperation,
+			ocsp.PrivilegeWithdrawn,
+		},
+	})
+
+	// AppleV1 is the profile of the Apple Root Certificate Program, which
+	// limits the validity of responses to twelve days and requires the
+	// disclosure of revocation reasons.
+	AppleV1 = NewProfile("apple", "1", Rules{
+		RequireNextUpdate:       true,
+		MaxValidity:             12 * 24 * time.Hour,
+		ForbidSHA1:              true,
+		RequireRevocationReason: true,
+	})
+
+	// ChromeV1_5 is the profile of version 1.5 of the Chrome Root Program
+	// Policy, which requires compliance with the Baseline Requirements. It
+	// has the lints of BRv2_0_0.
+	ChromeV1_5 = Profile{
+		Name:    "chrome",
+		Version: "1.5",
+		Lints:   append([]Lint(nil), BRv2_0_0.Lints...),
+	}
+)
+
+func init() {
+	for _, p := range []Profile{MozillaV2_9, AppleV1, ChromeV1_5} {
+		if err := RegisterProfile(p); err != nil {
+			panic(err)
+		}
+	}
+}
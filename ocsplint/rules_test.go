@@ -0,0 +1,173 @@
+package ocsplint
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+func TestRules(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newInput := func(f func(*ocsp.Response)) *Input {
+		resp := &ocsp.Response{
+			Status:             ocsp.Good,
+			SignatureAlgorithm: x509.ECDSAWithSHA256,
+			ProducedAt:         now,
+			ThisUpdate:         now,
+			NextUpdate:         now.Add(24 * time.Hour),
+		}
+		if f != nil {
+			f(resp)
+		}
+		return &Input{Response: resp, Now: now}
+	}
+	revoked := func(reason int) func(*ocsp.Response) {
+		return func(r *ocsp.Response) {
+			r.Status = ocsp.Revoked
+			r.RevokedAt = now.Add(-time.Hour)
+			r.RevocationReason = reason
+		}
+	}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		in    *Input
+		want  []string
+	}{
+		{"no rules", Rules{}, newInput(nil), nil},
+		{"next update", Rules{RequireNextUpdate: true}, newInput(func(r *ocsp.Response) { r.NextUpdate = time.Time{} }), []string{"e_test_ocsp_missing_next_update"}},
+		{"min validity", Rules{MinValidity: 25 * time.Hour}, newInput(nil), []string{"e_test_ocsp_validity_too_short"}},
+		{"max validity", Rules{MaxValidity: 24 * time.Hour}, newInput(nil), []string{"e_test_ocsp_validity_too_long"}},
+		{"max validity inclusive", Rules{MaxValidity: 24*time.Hour + time.Second}, newInput(nil), nil},
+		{"sha1", Rules{ForbidSHA1: true}, newInput(func(r *ocsp.Response) { r.SignatureAlgorithm = x509.ECDSAWithSHA1 }), []string{"e_test_ocsp_sha1_signature"}},
+		{"sha1 for sha1 certificate", Rules{ForbidSHA1: true, AllowSHA1ForSHA1Certificates: true}, func() *Input {
+			in := newInput(func(r *ocsp.Response) { r.SignatureAlgorithm = x509.ECDSAWithSHA1 })
+			in.Certificate = &x509.Certificate{SignatureAlgorithm: x509.SHA1WithRSA}
+			return in
+		}(), nil},
+		{"sha1 without certificate", Rules{ForbidSHA1: true, AllowSHA1ForSHA1Certificates: true}, newInput(func(r *ocsp.Response) { r.SignatureAlgorithm = x509.ECDSAWithSHA1 }), []string{"e_test_ocsp_sha1_signature"}},
+		{"good for unissued", Rules{ForbidGoodForUnissued: true}, func() *Input {
+			in := newInput(nil)
+			in.Unissued = true
+			return in
+		}(), []string{"e_test_ocsp_good_for_unissued"}},
+		{"revocation reason", Rules{RequireRevocationReason: true}, newInput(revoked(ocsp.Unspecified)), []string{"e_test_ocsp_missing_revocation_reason"}},
+		{"disclosed revocation reason", Rules{RequireRevocationReason: true}, newInput(revoked(ocsp.KeyCompromise)), nil},
+		{"allowed revocation reason", Rules{AllowedRevocationReasons: []int{ocsp.KeyCompromise}}, newInput(revoked(ocsp.KeyCompromise)), nil},
+		{"not allowed revocation reason", Rules{AllowedRevocationReasons: []int{ocsp.KeyCompromise}}, newInput(revoked(ocsp.CertificateHold)), []string{"e_test_ocsp_revocation_reason_not_allowed"}},
+		{"good with allowed reasons", Rules{AllowedRevocationReasons: []int{ocsp.KeyCompromise}}, newInput(nil), nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := Run(tc.in, tc.rules.Lints("test")...)
+			var got []string
+			for _, f := range r.Findings {
+				got = append(got, f.Lint)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Run: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRulesJSON(t *testing.T) {
+	data := []byte(`{"requireNextUpdate":true,"maxValidity":864000000000000,"allowedRevocationReasons":[1,4]}`)
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatal(err)
+	}
+	want := Rules{RequireNextUpdate: true, MaxValidity: 10 * 24 * time.Hour, AllowedRevocationReasons: []int{1, 4}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("json.Unmarshal: got %+v, want %+v", rules, want)
+	}
+}
+
+func TestNewProfile(t *testing.T) {
+	p := NewProfile("my-ca.policy", "3", Rules{RequireNextUpdate: true, ForbidSHA1: true})
+	if p.String() != "my-ca.policy@3" {
+		t.Errorf("String: got %s", p)
+	}
+	names := map[string]bool{}
+	for _, l := range p.Lints {
+		names[l.Name] = true
+	}
+	for _, name := range []string{"e_my_ca_policy_ocsp_missing_next_update", "e_my_ca_policy_ocsp_sha1_signature", NonceSize.Name, ResponderMissingNoCheck.Name} {
+		if !names[name] {
+			t.Errorf("NewProfile: missing lint %s", name)
+		}
+	}
+	for _, name := range []string{MissingNextUpdate.Name, SHA1Signature.Name} {
+		if names[name] {
+			t.Errorf("NewProfile: superseded lint %s is included", name)
+		}
+	}
+}
+
+func TestRootProgramProfiles(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	in := &Input{
+		Response: &ocsp.Response{
+			Status:             ocsp.Revoked,
+			SignatureAlgorithm: x509.ECDSAWithSHA256,
+			ProducedAt:         now,
+			ThisUpdate:         now,
+			NextUpdate:         now.Add(11 * 24 * time.Hour),
+			RevokedAt:          now.Add(-time.Hour),
+			RevocationReason:   ocsp.Unspecified,
+		},
+		Now: now,
+	}
+
+	for _, p := range []Profile{MozillaV2_9, AppleV1, ChromeV1_5} {
+		if got, ok := LookupProfile(p.Name, p.Version); !ok || got.String() != p.String() {
+			t.Errorf("LookupProfile(%s): not registered", p)
+		}
+	}
+
+	// Chrome requires compliance with the Baseline Requirements.
+	if len(ChromeV1_5.Lints) != len(BRv2_0_0.Lints) {
+		t.Fatalf("ChromeV1_5: got %d lints, want the %d of BRv2_0_0", len(ChromeV1_5.Lints), len(BRv2_0_0.Lints))
+	}
+	for i, l := range ChromeV1_5.Lints {
+		if l.Name != BRv2_0_0.Lints[i].Name {
+			t.Errorf("ChromeV1_5: got lint %s, want %s", l.Name, BRv2_0_0.Lints[i].Name)
+		}
+	}
+
+	reports := RunProfiles(in, MozillaV2_9, AppleV1, ChromeV1_5, BRv2_0_0)
+	want := []struct {
+		profile string
+		passed  bool
+	}{
+		{"mozilla@2.9", false},
+		{"apple@1", false},
+		{"chrome@1.5", false},
+		{"cabf-br@2.0.0", false},
+	}
+	for i, r := range reports {
+		if r.Profile != want[i].profile || r.Passed() != want[i].passed {
+			t.Errorf("RunProfiles: got %s passed %v, want %s passed %v", r.Profile, r.Passed(), want[i].profile, want[i].passed)
+		}
+	}
+
+	// Apple allows 11 days, but requires a revocation reason.
+	if got := reports[1].Findings; len(got) != 1 || got[0].Lint != "e_apple_ocsp_missing_revocation_reason" {
+		t.Errorf("apple: got %v", got)
+	}
+	in.Response.RevocationReason = ocsp.KeyCompromise
+	if r := AppleV1.Run(in); !r.Passed() {
+		t.Errorf("apple: got %v", r.Findings)
+	}
+	in.Response.NextUpdate = now.Add(5 * 24 * time.Hour)
+	for _, r := range RunProfiles(in, MozillaV2_9, ChromeV1_5) {
+		if !r.Passed() {
+			t.Errorf("%s: got %v", r.Profile, r.Findings)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package ocsptest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"embed"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+//go:embed keys
+var keys embed.FS
+
+// FixtureTime is the time the responses of the Fixtures are produced at.
+var FixtureTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var idPKIXOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// Fixtures is a complete test PKI with an OCSP request and responses for its
+// leaf certificate. They are generated with fixed RSA keys and times, and
+// signed with deterministic PKCS #1 v1.5 signatures, so NewFixtures always
+// returns the same bytes, which can be stored as golden files.
+type Fixtures struct {
+	// Root is a self-signed root CA certificate.
+	Root    *x509.Certificate
+	RootKey *rsa.PrivateKey
+	// Intermediate is a CA certificate issued by Root.
+	Intermediate    *x509.Certificate
+	IntermediateKey *rsa.PrivateKey
+	// Leaf is an end-entity certificate issued by Intermediate.
+	Leaf    *x509.Certificate
+	LeafKey *rsa.PrivateKey
+	// Responder is a delegated OCSP responder certificate issued by
+	// Intermediate, with the OCSPSigning extended key usage and the
+	// id-pkix-ocsp-nocheck extension.
+	Responder    *x509.Certificate
+	ResponderKey *rsa.PrivateKey
+
+	// Request is the DER-encoded OCSP request for Leaf, using SHA-1.
+	Request []byte
+	// Good, Revoked and Unknown are DER-encoded OCSP responses for Leaf,
+	// signed by Responder. They are produced at FixtureTime and valid for
+	// seven days. The Revoked response has a revocation time one day before
+	// FixtureTime, with the KeyCompromise reason.
+	Good, Revoked, Unknown []byte
+}
+
+// NewFixtures returns the Fixtures.
+func NewFixtures() (*Fixtures, error) {
+	f := &Fixtures{}
+	var err error
+	for name, key := range map[string]**rsa.PrivateKey{
+		"root":         &f.RootKey,
+		"intermediate": &f.IntermediateKey,
+		"leaf":         &f.LeafKey,
+		"responder":    &f.ResponderKey,
+	} {
+		if *key, err = loadKey(name); err != nil {
+			return nil, err
+		}
+	}
+
+	notBefore := FixtureTime.AddDate(-1, 0, 0)
+	if f.Root, err = createCertificate(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "OCSP Test Root CA"},
+		NotBefore:             notBefore,
+		NotAfter:              FixtureTime.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, f.RootKey, f.RootKey); err != nil {
+		return nil, err
+	}
+	if f.Intermediate, err = createCertificate(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "OCSP Test Intermediate CA"},
+		NotBefore:             notBefore,
+		NotAfter:              FixtureTime.AddDate(5, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}, f.Root, f.IntermediateKey, f.RootKey); err != nil {
+		return nil, err
+	}
+	if f.Leaf, err = createCertificate(&x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "leaf.example.com"},
+		DNSNames:     []string{"leaf.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     FixtureTime.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, f.Intermediate, f.LeafKey, f.IntermediateKey); err != nil {
+		return nil, err
+	}
+	if f.Responder, err = createCertificate(&x509.Certificate{
+		SerialNumber:    big.NewInt(4),
+		Subject:         pkix.Name{CommonName: "OCSP Test Responder"},
+		NotBefore:       notBefore,
+		NotAfter:        FixtureTime.AddDate(1, 0, 0),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{{Id: idPKIXOCSPNoCheck, Value: asn1.NullBytes}},
+	}, f.Intermediate, f.ResponderKey, f.IntermediateKey); err != nil {
+		return nil, err
+	}
+
+	if f.Request, err = ocsp.CreateRequest(f.Leaf, f.Intermediate, nil); err != nil {
+		return nil, err
+	}
+	req, err := ocsp.ParseRequest(f.Request)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range []struct {
+		der    *[]byte
+		status int
+	}{
+		{&f.Good, ocsp.Good},
+		{&f.Revoked, ocsp.Revoked},
+		{&f.Unknown, ocsp.Unknown},
+	} {
+		if *r.der, err = f.createResponse(req.CertID(), r.status); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// createResponse returns a response for id with the given status, signed by
+// the responder at FixtureTime.
+func (f *Fixtures) createResponse(id *ocsp.CertID, status int) ([]byte, error) {
+	responderID, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        1, // Name (explicit tag)
+		IsCompound: true,
+		Bytes:      f.Responder.RawSubject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	single := ocsp.SingleResponse{
+		CertID:     *id,
+		Status:     status,
+		ThisUpdate: FixtureTime,
+		NextUpdate: FixtureTime.AddDate(0, 0, 7),
+	}
+	if status == ocsp.Revoked {
+		single.RevokedAt = FixtureTime.AddDate(0, 0, -1)
+		single.RevocationReason = ocsp.KeyCompromise
+	}
+	b := &ocsp.BasicResponse{
+		RawResponderID: responderID,
+		ProducedAt:     FixtureTime,
+		Responses:      []ocsp.SingleResponse{single},
+		Certificates:   []*x509.Certificate{f.Responder},
+	}
+	if err := b.Sign(f.ResponderKey, x509.SHA256WithRSA); err != nil {
+		return nil, err
+	}
+	return b.Marshal()
+}
+
+func loadKey(name string) (*rsa.PrivateKey, error) {
+	data, err := keys.ReadFile("keys/" + name + ".pem")
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("ocsptest: invalid key " + name)
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// createCertificate returns the certificate of template for key, issued by
+// parent with parentKey. If parent is nil, the certificate is self-signed.
+func createCertificate(template, parent *x509.Certificate, key, parentKey crypto.Signer) (*x509.Certificate, error) {
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
@@ -0,0 +1,68 @@
+package ocsptest
+
+import (
+	"bytes"
+	"testing"
+
+	"go.step.sm/ocsp"
+)
+
+func TestNewFixtures(t *testing.T) {
+	f, err := NewFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Leaf.CheckSignatureFrom(f.Intermediate); err != nil {
+		t.Error(err)
+	}
+	if err := f.Intermediate.CheckSignatureFrom(f.Root); err != nil {
+		t.Error(err)
+	}
+	if !ocsp.HasNoCheck(f.Responder) {
+		t.Error("the responder certificate does not have the nocheck extension")
+	}
+
+	req, err := ocsp.ParseRequest(f.Request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.MatchesIssuer(f.Intermediate) || req.SerialNumber.Cmp(f.Leaf.SerialNumber) != 0 {
+		t.Error("the request does not match the leaf certificate")
+	}
+
+	for _, test := range []struct {
+		der    []byte
+		status int
+	}{
+		{f.Good, ocsp.Good},
+		{f.Revoked, ocsp.Revoked},
+		{f.Unknown, ocsp.Unknown},
+	} {
+		resp, err := ocsp.ParseResponseForCert(test.der, f.Leaf, f.Intermediate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != test.status || !resp.ProducedAt.Equal(FixtureTime) || !resp.ThisUpdate.Equal(FixtureTime) {
+			t.Errorf("got status %d produced at %v, want %d at %v", resp.Status, resp.ProducedAt, test.status, FixtureTime)
+		}
+	}
+
+	again, err := NewFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2][]byte{
+		{f.Root.Raw, again.Root.Raw},
+		{f.Intermediate.Raw, again.Intermediate.Raw},
+		{f.Leaf.Raw, again.Leaf.Raw},
+		{f.Responder.Raw, again.Responder.Raw},
+		{f.Request, again.Request},
+		{f.Good, again.Good},
+		{f.Revoked, again.Revoked},
+		{f.Unknown, again.Unknown},
+	} {
+		if !bytes.Equal(pair[0], pair[1]) {
+			t.Error("NewFixtures is not deterministic")
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package ocsptest provides an in-process OCSP responder for testing. It can
+// be told what to answer for each serial number, including failures like
+// latency, malformed responses, stale times, wrong nonces or error responses,
+// so applications can test their revocation handling without depending on
+// real CAs. It also provides deterministic fixtures for golden files.
+package ocsptest
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+// maxRequestSize is the maximum size of the POST requests read by a
+// Responder.
+const maxRequestSize = 64 << 10
+
+// Answer describes the response of a Responder for a certificate.
+type Answer struct {
+	// Status is one of {ocsp.Good, ocsp.Revoked, ocsp.Unknown}.
+	Status int
+	// RevokedAt and RevocationReason are used if Status is ocsp.Revoked.
+	RevokedAt        time.Time
+	RevocationReason int
+	// ThisUpdate and NextUpdate are the times of the response. If
+	// ThisUpdate is zero, the current time is used, and if NextUpdate is
+	// zero, it is set to ThisUpdate plus the validity set with SetValidity.
+	// Use times in the past to return stale responses.
+	ThisUpdate, NextUpdate time.Time
+	// Delay is the time waited before answering, to simulate a slow
+	// responder.
+	Delay time.Duration
+	// Nonce, if not nil, replaces the nonce echoed from the request, to
+	// simulate a wrong nonce.
+	Nonce []byte
+	// Raw, if not nil, is sent as is instead of a signed response. It can be
+	// an error response, like ocsp.TryLaterErrorResponse, or malformed
+	// bytes.
+	Raw []byte
+	// HTTPStatus, if not zero, is the HTTP status code of the reply.
+	HTTPStatus int
+}
+
+// Responder is an http.Handler answering OCSP requests sent with the GET or
+// POST methods, as described in RFC 6960, Appendix A. Its methods are safe
+// for concurrent use.
+type Responder struct {
+	issuer        *x509.Certificate
+	responderCert *x509.Certificate
+	signer        crypto.Signer
+
+	mu       sync.Mutex
+	answers  map[string]Answer
+	fallback Answer
+	validity time.Duration
+	requests []*ocsp.Request
+}
+
+// NewResponder returns a Responder signing responses for the certificates of
+// issuer with signer, the key of responderCert. The issuer can also be the
+// responder. By default, all the certificates are unknown.
+func NewResponder(issuer, responderCert *x509.Certificate, signer crypto.Signer) *Responder {
+	return &Responder{
+		issuer:        issuer,
+		responderCert: responderCert,
+		signer:        signer,
+		answers:       make(map[string]Answer),
+		fallback:      Answer{Status: ocsp.Unknown},
+		validity:      time.Hour,
+	}
+}
+
+// Set sets the answer for the certificate with the given serial number.
+func (r *Responder) Set(serial *big.Int, a Answer) {
+	r.mu.Lock()
+	r.answers[serial.String()] = a
+	r.mu.Unlock()
+}
+
+// SetDefault sets the answer for the certificates without an answer set with
+// Set.
+func (r *Responder) SetDefault(a Answer) {
+	r.mu.Lock()
+	r.fallback = a
+	r.mu.Unlock()
+}
+
+// SetValidity sets the validity of the responses without a NextUpdate. The
+// default is one hour.
+func (r *Responder) SetValidity(d time.Duration) {
+	r.mu.Lock()
+	r.validity = d
+	r.mu.Unlock()
+}
+
+// Requests returns the requests received so far, including the ones answered
+// with a Raw response.
+func (r *Responder) Requests() []*ocsp.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*ocsp.Request(nil), r.requests...)
+}
+
+// ServeHTTP answers the OCSP request in req. Malformed requests are answered
+// with ocsp.MalformedRequestErrorResponse.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	der, err := readRequest(req)
+	if err != nil {
+		write(w, http.StatusOK, ocsp.MalformedRequestErrorResponse)
+		return
+	}
+	ocspReq, err := ocsp.ParseRequest(der)
+	if err != nil {
+		write(w, http.StatusOK, ocsp.MalformedRequestErrorResponse)
+		return
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, ocspReq)
+	a, ok := r.answers[ocspReq.SerialNumber.String()]
+	if !ok {
+		a = r.fallback
+	}
+	validity := r.validity
+	r.mu.Unlock()
+
+	if err := sleep(req.Context(), a.Delay); err != nil {
+		return
+	}
+	status := a.HTTPStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if a.Raw != nil {
+		write(w, status, a.Raw)
+		return
+	}
+
+	resp, err := r.respond(ocspReq, a, validity)
+	if err != nil {
+		write(w, http.StatusOK, ocsp.InternalErrorErrorResponse)
+		return
+	}
+	write(w, status, resp)
+}
+
+// respond signs the response to req described by a.
+func (r *Responder) respond(req *ocsp.Request, a Answer, validity time.Duration) ([]byte, error) {
+	template := ocsp.Response{
+		Status:     a.Status,
+		ThisUpdate: a.ThisUpdate,
+		NextUpdate: a.NextUpdate,
+		Nonce:      a.Nonce,
+	}
+	if template.ThisUpdate.IsZero() {
+		template.ThisUpdate = time.Now().Truncate(time.Second).UTC()
+	}
+	if template.NextUpdate.IsZero() {
+		template.NextUpdate = template.ThisUpdate.Add(validity)
+	}
+	if a.Status == ocsp.Revoked {
+		template.RevokedAt = a.RevokedAt
+		template.RevocationReason = a.RevocationReason
+		if template.RevokedAt.IsZero() {
+			template.RevokedAt = template.ThisUpdate
+		}
+	}
+	if r.responderCert != r.issuer {
+		template.Certificate = r.responderCert
+	}
+	return ocsp.CreateResponseForRequest(req, nil, r.responderCert, template, r.signer)
+}
+
+// NewServer starts an httptest.Server running r, closed at the end of the
+// test. The URL of the server is the responder URL.
+func NewServer(t testing.TB, r *Responder) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func readRequest(req *http.Request) ([]byte, error) {
+	switch req.Method {
+	case http.MethodGet:
+		return ocsp.DecodeRequestPath(strings.TrimPrefix(req.URL.EscapedPath(), "/"))
+	case http.MethodPost:
+		return io.ReadAll(io.LimitReader(req.Body, maxRequestSize))
+	default:
+		return nil, http.ErrNotSupported
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func write(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,170 @@
+package ocsptest
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.step.sm/ocsp"
+)
+
+func newCertificate(t *testing.T, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "test " + big.NewInt(serial).String()},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func post(t *testing.T, url string, req []byte) []byte {
+	t.Helper()
+	resp, err := http.Post(url, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestResponder(t *testing.T) {
+	issuer, issuerKey := newCertificate(t, 1, nil, nil)
+	leaf, _ := newCertificate(t, 2, issuer, issuerKey)
+	r := NewResponder(issuer, issuer, issuerKey)
+	srv := NewServer(t, r)
+
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ocsp.ParseResponseForCert(post(t, srv.URL, req), leaf, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != ocsp.Unknown || !resp.NextUpdate.Equal(resp.ThisUpdate.Add(time.Hour)) {
+		t.Errorf("default answer: got status %d from %v to %v", resp.Status, resp.ThisUpdate, resp.NextUpdate)
+	}
+
+	revokedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	r.Set(leaf.SerialNumber, Answer{Status: ocsp.Revoked, RevokedAt: revokedAt, RevocationReason: ocsp.KeyCompromise})
+	httpResp, err := http.Get(ocsp.EncodeRequestURL(srv.URL, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = ocsp.ParseResponseForCert(body, leaf, issuer); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != ocsp.Revoked || !resp.RevokedAt.Equal(revokedAt) || resp.RevocationReason != ocsp.KeyCompromise {
+		t.Errorf("GET: got status %d at %v for reason %d", resp.Status, resp.RevokedAt, resp.RevocationReason)
+	}
+
+	r.Set(leaf.SerialNumber, Answer{Raw: ocsp.TryLaterErrorResponse})
+	if _, err := ocsp.ParseResponse(post(t, srv.URL, req), issuer); err != (ocsp.ResponseError{Status: ocsp.TryLater}) {
+		t.Errorf("Raw: got %v, want TryLater", err)
+	}
+
+	if got := post(t, srv.URL, []byte("malformed")); !bytes.Equal(got, ocsp.MalformedRequestErrorResponse) {
+		t.Errorf("malformed request: got %x", got)
+	}
+
+	if n := len(r.Requests()); n != 3 {
+		t.Errorf("Requests: got %d, want 3", n)
+	}
+}
+
+func TestResponderNonce(t *testing.T) {
+	issuer, issuerKey := newCertificate(t, 1, nil, nil)
+	responder, responderKey := newCertificate(t, 3, issuer, issuerKey)
+	r := NewResponder(issuer, responder, responderKey)
+	srv := NewServer(t, r)
+
+	nonce := []byte("0123456789abcdef")
+	req, err := (&ocsp.Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: make([]byte, 20),
+		IssuerKeyHash:  make([]byte, 20),
+		SerialNumber:   big.NewInt(2),
+		Nonce:          nonce,
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ocsp.ParseResponseWithOptions(post(t, srv.URL, req), nil, nil, &ocsp.ParseOptions{SkipSignatureVerification: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Nonce, nonce) || resp.Certificate == nil || !resp.Certificate.Equal(responder) {
+		t.Errorf("got nonce %x and certificate %v", resp.Nonce, resp.Certificate)
+	}
+
+	stale := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	r.SetDefault(Answer{Status: ocsp.Good, ThisUpdate: stale, NextUpdate: stale.Add(time.Hour), Nonce: []byte("wrong")})
+	if resp, err = ocsp.ParseResponseWithOptions(post(t, srv.URL, req), nil, nil, &ocsp.ParseOptions{SkipSignatureVerification: true}); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Nonce) != "wrong" || !resp.NextUpdate.Before(time.Now()) {
+		t.Errorf("got nonce %q and NextUpdate %v", resp.Nonce, resp.NextUpdate)
+	}
+}
+
+func TestResponderDelay(t *testing.T) {
+	issuer, issuerKey := newCertificate(t, 1, nil, nil)
+	r := NewResponder(issuer, issuer, issuerKey)
+	r.SetDefault(Answer{Delay: time.Minute})
+	srv := NewServer(t, r)
+	req, err := ocsp.CreateRequest(issuer, issuer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := http.DefaultClient.Do(httpReq); err == nil {
+		resp.Body.Close()
+		t.Error("expected timeout")
+	}
+}
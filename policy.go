@@ -0,0 +1,132 @@
+package ocsp
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// PolicyMode defines how a Policy handles failures to obtain a usable
+// response.
+type PolicyMode int
+
+const (
+	// HardFail rejects the certificate unless a valid, current response with
+	// the Good status is available.
+	HardFail PolicyMode = iota
+	// SoftFail accepts the certificate unless a response with the Revoked
+	// status is available.
+	SoftFail
+	// SoftFailWithMaxStaleness is like SoftFail, but expired responses are
+	// only accepted up to Policy.MaxStaleness after their NextUpdate time.
+	SoftFailWithMaxStaleness
+)
+
+func (m PolicyMode) String() string {
+	switch m {
+	case HardFail:
+		return "hard-fail"
+	case SoftFail:
+		return "soft-fail"
+	case SoftFailWithMaxStaleness:
+		return "soft-fail with max staleness"
+	default:
+		return "unknown policy mode " + strconv.Itoa(int(m))
+	}
+}
+
+// DecisionReason is the reason of a Decision.
+type DecisionReason int
+
+const (
+	// ReasonGood indicates a current response with the Good status.
+	ReasonGood DecisionReason = iota
+	// ReasonRevoked indicates a response with the Revoked status.
+	ReasonRevoked
+	// ReasonUnknown indicates a response with the Unknown status.
+	ReasonUnknown
+	// ReasonStale indicates a response past its NextUpdate time.
+	ReasonStale
+	// ReasonNotYetValid indicates a response with a ThisUpdate time in the
+	// future.
+	ReasonNotYetValid
+	// ReasonError indicates that no response could be obtained or parsed.
+	ReasonError
+)
+
+func (r DecisionReason) String() string {
+	switch r {
+	case ReasonGood:
+		return "good"
+	case ReasonRevoked:
+		return "revoked"
+	case ReasonUnknown:
+		return "unknown"
+	case ReasonStale:
+		return "stale"
+	case ReasonNotYetValid:
+		return "not yet valid"
+	case ReasonError:
+		return "error"
+	default:
+		return "unknown reason " + strconv.Itoa(int(r))
+	}
+}
+
+// Decision is the result of evaluating a Policy.
+type Decision struct {
+	// Accept reports whether the certificate should be accepted.
+	Accept bool
+	// Reason is the condition that led to the decision.
+	Reason DecisionReason
+	// Err is the error obtaining the response, if Reason is ReasonError.
+	Err error
+}
+
+// Policy decides whether to accept a certificate based on the outcome of its
+// revocation check.
+type Policy struct {
+	// Mode is the behavior on network errors, Unknown status, and stale
+	// responses.
+	Mode PolicyMode
+	// MaxStaleness is how long after its NextUpdate time a response is still
+	// accepted with SoftFailWithMaxStaleness.
+	MaxStaleness time.Duration
+}
+
+// softFail reports whether p accepts certificates without a usable response.
+// Unknown modes are handled as HardFail.
+func (p Policy) softFail() bool {
+	return p.Mode == SoftFail || p.Mode == SoftFailWithMaxStaleness
+}
+
+// Evaluate returns the decision for the response resp, or for the error err
+// encountered while fetching or parsing it, at the time now. A response with
+// the Revoked status is always rejected.
+func (p Policy) Evaluate(resp *Response, err error, now time.Time) Decision {
+	if err == nil && resp == nil {
+		err = errors.New("ocsp: no response")
+	}
+	if err != nil {
+		return Decision{Accept: p.softFail(), Reason: ReasonError, Err: err}
+	}
+
+	switch resp.Status {
+	case Good:
+	case Revoked:
+		return Decision{Reason: ReasonRevoked}
+	default:
+		return Decision{Accept: p.softFail(), Reason: ReasonUnknown}
+	}
+
+	switch {
+	case now.Before(resp.ThisUpdate):
+		return Decision{Accept: p.softFail(), Reason: ReasonNotYetValid}
+	case resp.Expired(now):
+		accept := p.Mode == SoftFail ||
+			p.Mode == SoftFailWithMaxStaleness && now.Before(resp.NextUpdate.Add(p.MaxStaleness))
+		return Decision{Accept: accept, Reason: ReasonStale}
+	default:
+		return Decision{Accept: true, Reason: ReasonGood}
+	}
+}
@@ -0,0 +1,60 @@
+package ocsp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	fetchErr := errors.New("connection refused")
+	good := &Response{Status: Good, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)}
+	revoked := &Response{Status: Revoked, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)}
+	unknown := &Response{Status: Unknown, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)}
+	stale := &Response{Status: Good, ThisUpdate: now.Add(-3 * time.Hour), NextUpdate: now.Add(-time.Hour)}
+	future := &Response{Status: Good, ThisUpdate: now.Add(time.Hour), NextUpdate: now.Add(2 * time.Hour)}
+
+	hardFail := Policy{Mode: HardFail}
+	softFail := Policy{Mode: SoftFail}
+	maxStaleness := Policy{Mode: SoftFailWithMaxStaleness, MaxStaleness: 2 * time.Hour}
+	shortStaleness := Policy{Mode: SoftFailWithMaxStaleness, MaxStaleness: 30 * time.Minute}
+	badMode := Policy{Mode: PolicyMode(100)}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		resp   *Response
+		err    error
+		accept bool
+		reason DecisionReason
+	}{
+		{"hard-fail good", hardFail, good, nil, true, ReasonGood},
+		{"hard-fail revoked", hardFail, revoked, nil, false, ReasonRevoked},
+		{"hard-fail unknown", hardFail, unknown, nil, false, ReasonUnknown},
+		{"hard-fail stale", hardFail, stale, nil, false, ReasonStale},
+		{"hard-fail not yet valid", hardFail, future, nil, false, ReasonNotYetValid},
+		{"hard-fail error", hardFail, nil, fetchErr, false, ReasonError},
+		{"hard-fail no response", hardFail, nil, nil, false, ReasonError},
+		{"soft-fail good", softFail, good, nil, true, ReasonGood},
+		{"soft-fail revoked", softFail, revoked, nil, false, ReasonRevoked},
+		{"soft-fail unknown", softFail, unknown, nil, true, ReasonUnknown},
+		{"soft-fail stale", softFail, stale, nil, true, ReasonStale},
+		{"soft-fail error", softFail, nil, fetchErr, true, ReasonError},
+		{"max staleness stale", maxStaleness, stale, nil, true, ReasonStale},
+		{"max staleness too stale", shortStaleness, stale, nil, false, ReasonStale},
+		{"max staleness error", shortStaleness, nil, fetchErr, true, ReasonError},
+		{"unknown mode error", badMode, nil, fetchErr, false, ReasonError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.policy.Evaluate(tc.resp, tc.err, now)
+			if d.Accept != tc.accept || d.Reason != tc.reason {
+				t.Errorf("Evaluate: got accept %v, reason %v, want accept %v, reason %v", d.Accept, d.Reason, tc.accept, tc.reason)
+			}
+			if tc.err != nil && !errors.Is(d.Err, tc.err) {
+				t.Errorf("Evaluate: got error %v, want %v", d.Err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SignatureAlgorithmDetails describes a signature algorithm not natively
+// supported by this package. Once registered with RegisterSignatureAlgorithm,
+// ParseResponse recognizes its OID and verifies signatures with it, and
+// CreateResponse can use it to sign responses.
+type SignatureAlgorithmDetails struct {
+	// Algorithm is the value used in Response.SignatureAlgorithm. It must not
+	// be one of the algorithms natively supported by this package.
+	Algorithm x509.SignatureAlgorithm
+	// OID is the object identifier of the algorithm.
+	OID asn1.ObjectIdentifier
+	// Parameters are the parameters of the AlgorithmIdentifier used when
+	// signing responses. Leave it empty to omit them.
+	Parameters asn1.RawValue
+	// Hash is the hash function applied to the signed data before calling
+	// the crypto.Signer. If zero, the signed data is passed to the signer
+	// without hashing, as it is done with Ed25519 keys.
+	Hash crypto.Hash
+
+	// Verify checks that signature is a valid signature of signed made by
+	// the private key corresponding to pub. It is required.
+	Verify func(pub crypto.PublicKey, signed, signature []byte) error
+	// Sign optionally signs the given data with priv. If nil, priv.Sign is
+	// used with the data hashed as described by Hash.
+	Sign func(rand io.Reader, priv crypto.Signer, signed []byte) ([]byte, error)
+	// MatchesPublicKey optionally reports whether the algorithm can be used
+	// with pub. It is used by CreateResponse to select the algorithm if the
+	// template SignatureAlgorithm is not set and the responder key is not an
+	// RSA or ECDSA key.
+	MatchesPublicKey func(pub crypto.PublicKey) bool
+	// ParsePublicKey optionally parses a DER-encoded SubjectPublicKeyInfo. It
+	// is used to verify signatures with certificates whose public key is not
+	// supported by crypto/x509.
+	ParsePublicKey func(spki []byte) (crypto.PublicKey, error)
+	// FIPSApproved reports whether the algorithm is FIPS-approved, and it
+	// can be used when the FIPS mode is enabled.
+	FIPSApproved bool
+}
+
+var signatureAlgorithms struct {
+	sync.RWMutex
+	list []SignatureAlgorithmDetails
+}
+
+// RegisterSignatureAlgorithm registers a signature algorithm to be used when
+// parsing and creating OCSP responses. It returns an error if the algorithm or
+// its OID are already registered or natively supported. It is meant to be
+// called from init functions.
+func RegisterSignatureAlgorithm(details SignatureAlgorithmDetails) error {
+	switch {
+	case details.Algorithm == x509.UnknownSignatureAlgorithm:
+		return errors.New("ocsp: signature algorithm cannot be zero")
+	case len(details.OID) == 0:
+		return errors.New("ocsp: signature algorithm OID cannot be empty")
+	case details.Verify == nil:
+		return errors.New("ocsp: signature algorithm Verify function cannot be nil")
+	}
+
+	for _, d := range signatureAlgorithmDetails {
+		if d.algo == details.Algorithm || d.oid.Equal(details.OID) {
+			return fmt.Errorf("ocsp: signature algorithm %v is already supported", details.Algorithm)
+		}
+	}
+
+	signatureAlgorithms.Lock()
+	defer signatureAlgorithms.Unlock()
+	for _, d := range signatureAlgorithms.list {
+		if d.Algorithm == details.Algorithm || d.OID.Equal(details.OID) {
+			return fmt.Errorf("ocsp: signature algorithm %v is already registered", details.Algorithm)
+		}
+	}
+	signatureAlgorithms.list = append(signatureAlgorithms.list, details)
+	return nil
+}
+
+// lookupSignatureAlgorithm returns the registered details of algo.
+func lookupSignatureAlgorithm(algo x509.SignatureAlgorithm) (SignatureAlgorithmDetails, bool) {
+	signatureAlgorithms.RLock()
+	defer signatureAlgorithms.RUnlock()
+	for _, d := range signatureAlgorithms.list {
+		if d.Algorithm == algo {
+			return d, true
+		}
+	}
+	return SignatureAlgorithmDetails{}, false
+}
+
+// lookupSignatureAlgorithmByOID returns the registered details of the
+// algorithm with the given OID.
+func lookupSignatureAlgorithmByOID(oid asn1.ObjectIdentifier) (SignatureAlgorithmDetails, bool) {
+	signatureAlgorithms.RLock()
+	defer signatureAlgorithms.RUnlock()
+	for _, d := range signatureAlgorithms.list {
+		if d.OID.Equal(oid) {
+			return d, true
+		}
+	}
+	return SignatureAlgorithmDetails{}, false
+}
+
+// lookupSignatureAlgorithmByKey returns the first registered algorithm that
+// can be used with pub.
+func lookupSignatureAlgorithmByKey(pub crypto.PublicKey) (SignatureAlgorithmDetails, bool) {
+	signatureAlgorithms.RLock()
+	defer signatureAlgorithms.RUnlock()
+	for _, d := range signatureAlgorithms.list {
+		if d.MatchesPublicKey != nil && d.MatchesPublicKey(pub) {
+			return d, true
+		}
+	}
+	return SignatureAlgorithmDetails{}, false
+}
+
+// algorithmIdentifier returns the AlgorithmIdentifier used to sign with d.
+func (d SignatureAlgorithmDetails) algorithmIdentifier() pkix.AlgorithmIdentifier {
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  d.OID,
+		Parameters: d.Parameters,
+	}
+}
+
+// publicKey returns the public key of cert, parsing it with ParsePublicKey if
+// crypto/x509 does not support it.
+func (d SignatureAlgorithmDetails) publicKey(cert *x509.Certificate) (crypto.PublicKey, error) {
+	if cert.PublicKey != nil || d.ParsePublicKey == nil {
+		return cert.PublicKey, nil
+	}
+	return d.ParsePublicKey(cert.RawSubjectPublicKeyInfo)
+}
+
+// sign signs the given data with priv.
+func (d SignatureAlgorithmDetails) sign(rand io.Reader, priv crypto.Signer, signed []byte) ([]byte, error) {
+	if d.Sign != nil {
+		return d.Sign(rand, priv, signed)
+	}
+	if d.Hash == crypto.Hash(0) {
+		return priv.Sign(rand, signed, crypto.Hash(0))
+	}
+	if !d.Hash.Available() {
+		return nil, x509.ErrUnsupportedAlgorithm
+	}
+	h := d.Hash.New()
+	h.Write(signed)
+	return priv.Sign(rand, h.Sum(nil), d.Hash)
+}
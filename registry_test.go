@@ -0,0 +1,134 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var oidSignatureEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+func init() {
+	if err := RegisterSignatureAlgorithm(SignatureAlgorithmDetails{
+		Algorithm: x509.PureEd25519,
+		OID:       oidSignatureEd25519,
+		Verify: func(pub crypto.PublicKey, signed, signature []byte) error {
+			key, ok := pub.(ed25519.PublicKey)
+			if !ok {
+				return errors.New("not an Ed25519 key")
+			}
+			if !ed25519.Verify(key, signed, signature) {
+				return errors.New("Ed25519 verification failure")
+			}
+			return nil
+		},
+		MatchesPublicKey: func(pub crypto.PublicKey) bool {
+			_, ok := pub.(ed25519.PublicKey)
+			return ok
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterSignatureAlgorithm(t *testing.T) {
+	verify := func(crypto.PublicKey, []byte, []byte) error { return nil }
+	tests := []struct {
+		name    string
+		details SignatureAlgorithmDetails
+	}{
+		{"zero algorithm", SignatureAlgorithmDetails{OID: asn1.ObjectIdentifier{1, 2, 3}, Verify: verify}},
+		{"empty OID", SignatureAlgorithmDetails{Algorithm: 1000, Verify: verify}},
+		{"nil Verify", SignatureAlgorithmDetails{Algorithm: 1000, OID: asn1.ObjectIdentifier{1, 2, 3}}},
+		{"native algorithm", SignatureAlgorithmDetails{Algorithm: x509.SHA256WithRSA, OID: asn1.ObjectIdentifier{1, 2, 3}, Verify: verify}},
+		{"native OID", SignatureAlgorithmDetails{Algorithm: 1000, OID: oidSignatureSHA256WithRSA, Verify: verify}},
+		{"registered algorithm", SignatureAlgorithmDetails{Algorithm: x509.PureEd25519, OID: asn1.ObjectIdentifier{1, 2, 3}, Verify: verify}},
+		{"registered OID", SignatureAlgorithmDetails{Algorithm: 1000, OID: oidSignatureEd25519, Verify: verify}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := RegisterSignatureAlgorithm(tc.details); err == nil {
+				t.Error("RegisterSignatureAlgorithm didn't fail")
+			}
+		})
+	}
+}
+
+func TestOCSPResponseRegisteredSignatureAlgorithm(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Ed25519 Responder"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sigAlgo := range []x509.SignatureAlgorithm{0, x509.PureEd25519} {
+		der, err = CreateResponse(issuer, responder, Response{
+			Status:             Good,
+			SerialNumber:       big.NewInt(1),
+			ThisUpdate:         time.Now().Truncate(time.Second),
+			Certificate:        responder,
+			SignatureAlgorithm: sigAlgo,
+		}, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ParseResponse(der, responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.SignatureAlgorithm != x509.PureEd25519 {
+			t.Errorf("resp.SignatureAlgorithm: got %v, want %v", resp.SignatureAlgorithm, x509.PureEd25519)
+		}
+		if err := resp.CheckSignatureFrom(responder); err != nil {
+			t.Errorf("CheckSignatureFrom: %v", err)
+		}
+		if err := resp.CheckSignatureFromKey(pub); err != nil {
+			t.Errorf("CheckSignatureFromKey: %v", err)
+		}
+		if err := resp.CheckSignatureFromKey(issuer.PublicKey); err == nil {
+			t.Error("CheckSignatureFromKey didn't fail with the wrong key")
+		}
+	}
+
+	responderPrivateKeyDER, _ := hex.DecodeString(responderPrivateKeyHex)
+	responderPrivateKey, err := x509.ParsePKCS1PrivateKey(responderPrivateKeyDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateResponse(issuer, responder, Response{
+		Status:             Good,
+		SerialNumber:       big.NewInt(1),
+		SignatureAlgorithm: x509.PureEd25519,
+	}, responderPrivateKey); err == nil {
+		t.Error("CreateResponse didn't fail with a key of the wrong type")
+	}
+}
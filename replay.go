@@ -0,0 +1,122 @@
+package ocsp
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNonceReplayed is returned by a ReplayCache for a nonce already seen
+// within its window.
+var ErrNonceReplayed = errors.New("ocsp: nonce replayed")
+
+// ReplayCache is the interface implemented by the caches detecting repeated
+// request nonces.
+type ReplayCache interface {
+	// CheckNonce records nonce, and returns ErrNonceReplayed if it was
+	// already recorded within the window of the cache.
+	CheckNonce(ctx context.Context, nonce []byte) error
+}
+
+// MemoryReplayCache is a ReplayCache keeping the SHA-256 hashes of the nonces
+// in memory for a fixed window. It is safe for concurrent use.
+type MemoryReplayCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	seen       map[[sha256.Size]byte]time.Time
+	order      []replayEntry
+	now        func() time.Time
+}
+
+type replayEntry struct {
+	hash    [sha256.Size]byte
+	expires time.Time
+}
+
+// NewMemoryReplayCache returns a MemoryReplayCache remembering nonces for ttl.
+// If maxEntries is positive, the oldest nonces are forgotten before their ttl
+// to keep at most maxEntries, so replays of them are not detected.
+func NewMemoryReplayCache(ttl time.Duration, maxEntries int) *MemoryReplayCache {
+	return &MemoryReplayCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		seen:       make(map[[sha256.Size]byte]time.Time),
+		now:        time.Now,
+	}
+}
+
+// CheckNonce records nonce, and returns ErrNonceReplayed if it was already
+// recorded less than ttl ago.
+func (c *MemoryReplayCache) CheckNonce(_ context.Context, nonce []byte) error {
+	hash := sha256.Sum256(nonce)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.expire(now)
+	if _, ok := c.seen[hash]; ok {
+		return ErrNonceReplayed
+	}
+	if c.maxEntries > 0 && len(c.order) >= c.maxEntries {
+		c.pop()
+	}
+	expires := now.Add(c.ttl)
+	c.seen[hash] = expires
+	c.order = append(c.order, replayEntry{hash: hash, expires: expires})
+	return nil
+}
+
+// Len returns the number of nonces remembered.
+func (c *MemoryReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.seen)
+}
+
+// expire forgets the nonces expired at now. As the window is fixed, the
+// nonces expire in the order they were recorded. The lock must be held.
+func (c *MemoryReplayCache) expire(now time.Time) {
+	for len(c.order) > 0 && !now.Before(c.order[0].expires) {
+		c.pop()
+	}
+}
+
+// pop forgets the oldest nonce. The lock must be held.
+func (c *MemoryReplayCache) pop() {
+	delete(c.seen, c.order[0].hash)
+	c.order[0] = replayEntry{}
+	c.order = c.order[1:]
+	if len(c.order) == 0 {
+		c.order = nil
+	}
+}
+
+// NoncePolicy sets how a responder handles requests with a nonce.
+type NoncePolicy struct {
+	// ReplayCache, if not nil, is used to reject requests repeating a
+	// nonce.
+	ReplayCache ReplayCache
+	// BypassCache makes the requests with a nonce bypass the cache of
+	// pre-signed responses, so that the response signed for them can echo
+	// the nonce.
+	BypassCache bool
+}
+
+// CheckRequest checks the nonce of req, if any, with the replay cache of the
+// policy. It returns an error matching ErrNonceReplayed if the nonce was
+// already used, and reports whether the request can be answered from the
+// cache of pre-signed responses. A nil policy ignores nonces.
+func (p *NoncePolicy) CheckRequest(ctx context.Context, req *Request) (useCache bool, err error) {
+	if p == nil || len(req.Nonce) == 0 {
+		return true, nil
+	}
+	if p.ReplayCache != nil {
+		if err := p.ReplayCache.CheckNonce(ctx, req.Nonce); err != nil {
+			return false, err
+		}
+	}
+	return !p.BypassCache, nil
+}
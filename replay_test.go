@@ -0,0 +1,81 @@
+package ocsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewMemoryReplayCache(time.Minute, 3)
+	c.now = func() time.Time { return now }
+
+	for _, nonce := range []string{"a", "b"} {
+		if err := c.CheckNonce(ctx, []byte(nonce)); err != nil {
+			t.Fatalf("CheckNonce(%s): %v", nonce, err)
+		}
+	}
+	if err := c.CheckNonce(ctx, []byte("a")); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("CheckNonce: got %v, want ErrNonceReplayed", err)
+	}
+
+	// Nonces are forgotten after the window.
+	now = now.Add(30 * time.Second)
+	if err := c.CheckNonce(ctx, []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := c.CheckNonce(ctx, []byte("a")); err != nil {
+		t.Errorf("CheckNonce: got %v after the window", err)
+	}
+	if err := c.CheckNonce(ctx, []byte("c")); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("CheckNonce: got %v, want ErrNonceReplayed", err)
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("Len: got %d, want 2", n)
+	}
+
+	// The oldest nonces are forgotten to keep at most maxEntries.
+	for i := 0; i < 3; i++ {
+		if err := c.CheckNonce(ctx, []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := c.Len(); n != 3 {
+		t.Errorf("Len: got %d, want 3", n)
+	}
+	if err := c.CheckNonce(ctx, []byte("c")); err != nil {
+		t.Errorf("CheckNonce: got %v for an evicted nonce", err)
+	}
+}
+
+func TestNoncePolicy(t *testing.T) {
+	ctx := context.Background()
+	withNonce := &Request{Nonce: []byte("nonce")}
+	without := &Request{}
+
+	var nilPolicy *NoncePolicy
+	if useCache, err := nilPolicy.CheckRequest(ctx, withNonce); !useCache || err != nil {
+		t.Errorf("CheckRequest: got %v, %v", useCache, err)
+	}
+
+	p := &NoncePolicy{ReplayCache: NewMemoryReplayCache(time.Minute, 0), BypassCache: true}
+	if useCache, err := p.CheckRequest(ctx, without); !useCache || err != nil {
+		t.Errorf("CheckRequest: got %v, %v for a request without nonce", useCache, err)
+	}
+	if useCache, err := p.CheckRequest(ctx, withNonce); useCache || err != nil {
+		t.Errorf("CheckRequest: got %v, %v, want to bypass the cache", useCache, err)
+	}
+	if _, err := p.CheckRequest(ctx, withNonce); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("CheckRequest: got %v, want ErrNonceReplayed", err)
+	}
+
+	p = &NoncePolicy{}
+	if useCache, err := p.CheckRequest(ctx, withNonce); !useCache || err != nil {
+		t.Errorf("CheckRequest: got %v, %v", useCache, err)
+	}
+}
@@ -0,0 +1,137 @@
+package ocsp
+
+import (
+	"encoding/asn1"
+	"errors"
+)
+
+var (
+	// OIDRevocationInfoArchival is the object identifier of the Adobe
+	// adbe-revocationInfoArchival signed attribute, used in PDF signatures
+	// and PAdES to embed the revocation information of the signer.
+	OIDRevocationInfoArchival = asn1.ObjectIdentifier{1, 2, 840, 113583, 1, 1, 8}
+	// OIDRevocationValues is the object identifier of the
+	// id-aa-ets-revocationValues unsigned attribute of RFC 5126, section
+	// 6.3.4, used in CAdES long-term signatures.
+	OIDRevocationValues = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 24}
+)
+
+// RevocationInfo is the revocation information embedded in long-term
+// document signatures, as encoded by MarshalRevocationInfoArchival and
+// MarshalRevocationValues.
+type RevocationInfo struct {
+	// CRLs contains DER-encoded CRLs.
+	CRLs [][]byte
+	// OCSPResponses contains DER-encoded OCSP responses, as returned by
+	// CreateResponse and read by ParseResponse.
+	OCSPResponses [][]byte
+}
+
+// revocationInfo is the ASN.1 structure of both the RevocationInfoArchival
+// and the RevocationValues. The otherRevInfo and otherRevVals fields are not
+// supported, and they are ignored when parsing.
+type revocationInfo struct {
+	CRLs          []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+	OCSPResponses []asn1.RawValue `asn1:"explicit,tag:1,optional"`
+}
+
+// MarshalRevocationInfoArchival returns the DER-encoded RevocationInfoArchival
+// value of the adbe-revocationInfoArchival attribute containing info. The OCSP
+// responses are embedded as is.
+func MarshalRevocationInfoArchival(info *RevocationInfo) ([]byte, error) {
+	return marshalRevocationInfo(info, func(der []byte) ([]byte, error) {
+		return der, nil
+	})
+}
+
+// ParseRevocationInfoArchival parses the DER-encoded RevocationInfoArchival
+// value of an adbe-revocationInfoArchival attribute.
+func ParseRevocationInfoArchival(der []byte) (*RevocationInfo, error) {
+	return parseRevocationInfo(der, func(der []byte) ([]byte, error) {
+		return der, nil
+	})
+}
+
+// MarshalRevocationValues returns the DER-encoded RevocationValues of the
+// id-aa-ets-revocationValues attribute containing info. As RevocationValues
+// contains BasicOCSPResponses, the OCSP responses must be successful, and
+// only their basic response is embedded.
+func MarshalRevocationValues(info *RevocationInfo) ([]byte, error) {
+	return marshalRevocationInfo(info, basicResponseBytes)
+}
+
+// ParseRevocationValues parses the DER-encoded RevocationValues of an
+// id-aa-ets-revocationValues attribute. The BasicOCSPResponses are returned as
+// successful OCSP responses, so they can be read with ParseResponse.
+func ParseRevocationValues(der []byte) (*RevocationInfo, error) {
+	return parseRevocationInfo(der, func(der []byte) ([]byte, error) {
+		return asn1.Marshal(responseASN1{
+			Status: asn1.Enumerated(Success),
+			Response: responseBytes{
+				ResponseType: idPKIXOCSPBasic,
+				Response:     der,
+			},
+		})
+	})
+}
+
+// basicResponseBytes returns the DER-encoded BasicOCSPResponse of the OCSP
+// response der.
+func basicResponseBytes(der []byte) ([]byte, error) {
+	var resp responseASN1
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("trailing data in OCSP response")
+	}
+	if status := ResponseStatus(resp.Status); status != Success {
+		return nil, ResponseError{status}
+	}
+	if !resp.Response.ResponseType.Equal(idPKIXOCSPBasic) {
+		return nil, ParseError("bad OCSP response type")
+	}
+	return resp.Response.Response, nil
+}
+
+func marshalRevocationInfo(info *RevocationInfo, convert func([]byte) ([]byte, error)) ([]byte, error) {
+	if info == nil || len(info.CRLs) == 0 && len(info.OCSPResponses) == 0 {
+		return nil, errors.New("ocsp: revocation information is empty")
+	}
+	var v revocationInfo
+	for _, crl := range info.CRLs {
+		v.CRLs = append(v.CRLs, asn1.RawValue{FullBytes: crl})
+	}
+	for _, resp := range info.OCSPResponses {
+		der, err := convert(resp)
+		if err != nil {
+			return nil, err
+		}
+		v.OCSPResponses = append(v.OCSPResponses, asn1.RawValue{FullBytes: der})
+	}
+	return asn1.Marshal(v)
+}
+
+func parseRevocationInfo(der []byte, convert func([]byte) ([]byte, error)) (*RevocationInfo, error) {
+	var v revocationInfo
+	rest, err := asn1.Unmarshal(der, &v)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("trailing data in revocation information")
+	}
+	info := &RevocationInfo{}
+	for _, crl := range v.CRLs {
+		info.CRLs = append(info.CRLs, crl.FullBytes)
+	}
+	for _, resp := range v.OCSPResponses {
+		der, err := convert(resp.FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		info.OCSPResponses = append(info.OCSPResponses, der)
+	}
+	return info, nil
+}
@@ -0,0 +1,64 @@
+package ocsp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+)
+
+func TestRevocationInfo(t *testing.T) {
+	resp, _ := hex.DecodeString(ocspResponseHex)
+	// A CRL is an opaque SEQUENCE for these helpers.
+	crl, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{5, 0}})
+	info := &RevocationInfo{CRLs: [][]byte{crl}, OCSPResponses: [][]byte{resp, resp}}
+
+	for _, test := range []struct {
+		name    string
+		marshal func(*RevocationInfo) ([]byte, error)
+		parse   func([]byte) (*RevocationInfo, error)
+	}{
+		{"RevocationInfoArchival", MarshalRevocationInfoArchival, ParseRevocationInfoArchival},
+		{"RevocationValues", MarshalRevocationValues, ParseRevocationValues},
+	} {
+		der, err := test.marshal(info)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		got, err := test.parse(der)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(got.CRLs) != 1 || !bytes.Equal(got.CRLs[0], crl) || len(got.OCSPResponses) != 2 {
+			t.Fatalf("%s: got %+v", test.name, got)
+		}
+		for _, der := range got.OCSPResponses {
+			if !bytes.Equal(der, resp) {
+				t.Errorf("%s: got response %x, want %x", test.name, der, resp)
+			}
+		}
+
+		// Only OCSP responses.
+		der, err = test.marshal(&RevocationInfo{OCSPResponses: [][]byte{resp}})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got, err = test.parse(der); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(got.CRLs) != 0 || len(got.OCSPResponses) != 1 {
+			t.Errorf("%s: got %+v", test.name, got)
+		}
+
+		if _, err := test.marshal(&RevocationInfo{}); err == nil {
+			t.Errorf("%s: expected error for empty revocation information", test.name)
+		}
+	}
+
+	// The RevocationValues contain basic responses, so error responses
+	// cannot be embedded.
+	errResp, _ := hex.DecodeString(errorResponseHex)
+	if _, err := MarshalRevocationValues(&RevocationInfo{OCSPResponses: [][]byte{errResp}}); err == nil {
+		t.Error("MarshalRevocationValues: expected error for an error response")
+	}
+}
@@ -0,0 +1,101 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RotatingSigner holds the responder certificate and key used to sign OCSP
+// responses, and allows replacing them atomically, for example, when the
+// files containing them change or on SIGHUP.
+//
+// After a rotation, the previous certificate is still returned by Certificates
+// during a grace period, so responses signed with the previous key, and
+// cached by clients or intermediate caches, can still be verified.
+type RotatingSigner struct {
+	mu        sync.RWMutex
+	cert      *x509.Certificate
+	signer    crypto.Signer
+	previous  *x509.Certificate
+	rotatedAt time.Time
+	grace     time.Duration
+	now       func() time.Time
+}
+
+// NewRotatingSigner returns a RotatingSigner using the given responder
+// certificate and signer. The grace period sets how long the previous
+// certificate is kept after a rotation.
+func NewRotatingSigner(cert *x509.Certificate, signer crypto.Signer, grace time.Duration) (*RotatingSigner, error) {
+	if err := validateResponderKey(cert, signer); err != nil {
+		return nil, err
+	}
+	return &RotatingSigner{
+		cert:   cert,
+		signer: signer,
+		grace:  grace,
+		now:    time.Now,
+	}, nil
+}
+
+// Rotate replaces the responder certificate and signer. The current
+// certificate becomes the previous one, and it is kept for the grace period.
+func (r *RotatingSigner) Rotate(cert *x509.Certificate, signer crypto.Signer) error {
+	if err := validateResponderKey(cert, signer); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.previous = r.cert
+	r.cert = cert
+	r.signer = signer
+	r.rotatedAt = r.now()
+	return nil
+}
+
+// Current returns the responder certificate and signer in use.
+func (r *RotatingSigner) Current() (*x509.Certificate, crypto.Signer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.signer
+}
+
+// Certificates returns the responder certificate in use and, if the last
+// rotation happened within the grace period, the previous one. It can be used
+// with Response.FindResponder to verify responses signed before a rotation.
+func (r *RotatingSigner) Certificates() []*x509.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	certs := []*x509.Certificate{r.cert}
+	if r.previous != nil && r.now().Before(r.rotatedAt.Add(r.grace)) {
+		certs = append(certs, r.previous)
+	}
+	return certs
+}
+
+// CreateResponse creates an OCSP response using CreateResponseContext with the
+// responder certificate and signer in use. If template.Certificate is nil, the
+// responder certificate is embedded in the response.
+func (r *RotatingSigner) CreateResponse(ctx context.Context, issuer *x509.Certificate, template Response) ([]byte, error) {
+	cert, signer := r.Current()
+	if template.Certificate == nil {
+		template.Certificate = cert
+	}
+	return CreateResponseContext(ctx, issuer, cert, template, signer)
+}
+
+// validateResponderKey checks that signer corresponds to the public key in
+// cert.
+func validateResponderKey(cert *x509.Certificate, signer crypto.Signer) error {
+	if cert == nil || signer == nil {
+		return errors.New("ocsp: responder certificate and signer are required")
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return errors.New("ocsp: responder certificate does not match the signer")
+	}
+	return nil
+}
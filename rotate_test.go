@@ -0,0 +1,82 @@
+package ocsp
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestRotatingSigner(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert1, key1 := newTestResponder(t, "Responder 1")
+	cert2, key2 := newTestResponder(t, "Responder 2")
+
+	if _, err := NewRotatingSigner(cert1, key2, time.Hour); err == nil {
+		t.Error("NewRotatingSigner didn't fail with a mismatched key")
+	}
+
+	r, err := NewRotatingSigner(cert1, key1, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   now.Truncate(time.Second),
+	}
+	der, err := r.CreateResponse(context.Background(), issuer, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := old.Certificate; got == nil || !got.Equal(cert1) {
+		t.Errorf("resp.Certificate: got %v, want %v", got, cert1.Subject)
+	}
+
+	if err := r.Rotate(cert2, key1); err == nil {
+		t.Error("Rotate didn't fail with a mismatched key")
+	}
+	if err := r.Rotate(cert2, key2); err != nil {
+		t.Fatal(err)
+	}
+	if cert, signer := r.Current(); cert != cert2 || signer != key2 {
+		t.Errorf("Current: got %v, want %v", cert.Subject, cert2.Subject)
+	}
+
+	der, err = r.CreateResponse(context.Background(), issuer, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := resp.FindResponder(r.Certificates()); err != nil || got != cert2 {
+		t.Errorf("FindResponder: got %v, %v, want %v", got, err, cert2.Subject)
+	}
+
+	// The old response is still verifiable during the grace period.
+	if got, err := old.FindResponder(r.Certificates()); err != nil || got != cert1 {
+		t.Errorf("FindResponder: got %v, %v, want %v", got, err, cert1.Subject)
+	}
+	now = now.Add(2 * time.Hour)
+	if got := r.Certificates(); len(got) != 1 || got[0] != cert2 {
+		t.Errorf("Certificates: got %d certificates, want 1", len(got))
+	}
+	if _, err := old.FindResponder(r.Certificates()); err == nil {
+		t.Error("FindResponder didn't fail after the grace period")
+	}
+}
@@ -0,0 +1,91 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrSigningTimeout is returned when the signing backend does not create a
+// signature before the deadline. Unlike other signing errors, it is usually
+// temporary, so the request can be answered with TryLaterErrorResponse.
+var ErrSigningTimeout = errors.New("ocsp: signing timed out")
+
+// ContextSigner is a crypto.Signer that supports a context, for example, a
+// signer backed by a remote key management service. CreateResponseContext
+// passes its context to signers implementing this interface, so deadlines and
+// cancellations are propagated to the signing backend.
+type ContextSigner interface {
+	crypto.Signer
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// contextBoundSigner is a crypto.Signer that calls SignContext with the given
+// context.
+type contextBoundSigner struct {
+	ContextSigner
+	ctx context.Context
+}
+
+func (s contextBoundSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(s.ctx, rand, digest, opts)
+}
+
+// CreateResponseContext acts like CreateResponse, but if priv implements
+// ContextSigner, the signature is created using ctx. It returns the context
+// error if ctx is done before the response is signed.
+func CreateResponseContext(ctx context.Context, issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cs, ok := priv.(ContextSigner); ok {
+		priv = contextBoundSigner{ContextSigner: cs, ctx: ctx}
+	}
+	return CreateResponse(issuer, responderCert, template, priv)
+}
+
+// CreateResponseWithTimeout acts like CreateResponseContext, but it waits at
+// most timeout for the signature. If timeout is not positive, only the
+// deadline of ctx applies.
+//
+// If the deadline is exceeded, it returns TryLaterErrorResponse together with
+// an error matching both ErrSigningTimeout and context.DeadlineExceeded, so a
+// responder can serve the returned bytes instead of failing the request. Any
+// other error is returned with a nil response. Signers not implementing
+// ContextSigner cannot be interrupted, so they keep running in the background
+// until they return.
+func CreateResponseWithTimeout(ctx context.Context, timeout time.Duration, issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer) ([]byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		der []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		der, err := CreateResponseContext(ctx, issuer, responderCert, template, priv)
+		done <- result{der, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+	if errors.Is(res.err, context.DeadlineExceeded) {
+		return TryLaterErrorResponse, fmt.Errorf("%w: %w", ErrSigningTimeout, res.err)
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	return res.der, nil
+}
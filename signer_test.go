@@ -0,0 +1,160 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type deadlineSigner struct {
+	crypto.Signer
+	deadline time.Time
+}
+
+func (s *deadlineSigner) SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, errors.New("missing deadline")
+	}
+	s.deadline = deadline
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestCreateResponseContext(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	signer := &deadlineSigner{Signer: key}
+	der, err := CreateResponseContext(ctx, issuer, responder, template, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.deadline.Equal(deadline) {
+		t.Errorf("signer deadline: got %v, want %v", signer.deadline, deadline)
+	}
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckSignatureFromKey(key.Public()); err != nil {
+		t.Error(err)
+	}
+
+	cancel()
+	if _, err := CreateResponseContext(ctx, issuer, responder, template, signer); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateResponseContext: got %v, want %v", err, context.Canceled)
+	}
+}
+
+// slowSigner is a signer that does not support a context and blocks until
+// release is closed.
+type slowSigner struct {
+	crypto.Signer
+	release chan struct{}
+}
+
+func (s *slowSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	<-s.release
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+type failingSigner struct {
+	crypto.Signer
+	err error
+}
+
+func (s failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, s.err
+}
+
+func TestCreateResponseWithTimeout(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responderCert, _ := hex.DecodeString(responderCertHex)
+	responder, err := x509.ParseCertificate(responderCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+	}
+	ctx := context.Background()
+
+	der, err := CreateResponseWithTimeout(ctx, time.Minute, issuer, responder, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseResponse(der, nil); err != nil {
+		t.Error(err)
+	}
+
+	// A slow backend results in a TryLater response.
+	slow := &slowSigner{Signer: key, release: make(chan struct{})}
+	defer close(slow.release)
+	der, err = CreateResponseWithTimeout(ctx, 10*time.Millisecond, issuer, responder, template, slow)
+	if !errors.Is(err, ErrSigningTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CreateResponseWithTimeout: got %v, want %v", err, ErrSigningTimeout)
+	}
+	if !bytes.Equal(der, TryLaterErrorResponse) {
+		t.Errorf("CreateResponseWithTimeout: got %x, want the TryLater response", der)
+	}
+
+	// The deadline of the context also applies.
+	deadlineCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := CreateResponseWithTimeout(deadlineCtx, 0, issuer, responder, template, slow); !errors.Is(err, ErrSigningTimeout) {
+		t.Errorf("CreateResponseWithTimeout: got %v, want %v", err, ErrSigningTimeout)
+	}
+
+	// Other errors are not timeouts.
+	errHSM := errors.New("key not found")
+	der, err = CreateResponseWithTimeout(ctx, time.Minute, issuer, responder, template, failingSigner{key, errHSM})
+	if !errors.Is(err, errHSM) || errors.Is(err, ErrSigningTimeout) || der != nil {
+		t.Errorf("CreateResponseWithTimeout: got %x, %v, want %v", der, err, errHSM)
+	}
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := CreateResponseWithTimeout(canceledCtx, time.Minute, issuer, responder, template, key); !errors.Is(err, context.Canceled) || errors.Is(err, ErrSigningTimeout) {
+		t.Errorf("CreateResponseWithTimeout: got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,183 @@
+package ocsp
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SingleResponse is the status of a certificate in an OCSP response, which
+// can contain the status of several certificates. See RFC 6960, section
+// 4.2.1.
+type SingleResponse struct {
+	// Raw contains the DER-encoded SingleResponse.
+	Raw []byte
+	// CertID identifies the certificate. Its HashAlgorithm is zero if the
+	// hash algorithm is not supported.
+	CertID CertID
+	// Status is one of {Good, Revoked, Unknown}.
+	Status int
+	// ThisUpdate, NextUpdate and RevokedAt are the times of the status.
+	// NextUpdate is zero if not set, and RevokedAt is only set if Status is
+	// Revoked.
+	ThisUpdate, NextUpdate, RevokedAt time.Time
+	// RevocationReason is the reason of the revocation if Status is Revoked.
+	RevocationReason int
+	// Extensions contains the singleExtensions of the status.
+	Extensions []pkix.Extension
+}
+
+// rawResponseData is a responseData with the list of responses kept as raw
+// DER, to decode them one at a time.
+type rawResponseData struct {
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      asn1.RawValue
+}
+
+func newSingleResponse(r *singleResponse) SingleResponse {
+	ret := SingleResponse{
+		Raw: r.Raw,
+		CertID: CertID{
+			HashAlgorithm:  getHashAlgorithmFromOID(r.CertID.HashAlgorithm.Algorithm),
+			IssuerNameHash: r.CertID.NameHash,
+			IssuerKeyHash:  r.CertID.IssuerKeyHash,
+			SerialNumber:   r.CertID.SerialNumber,
+		},
+		ThisUpdate: r.ThisUpdate,
+		NextUpdate: r.NextUpdate,
+		Extensions: r.SingleExtensions,
+	}
+	switch {
+	case bool(r.Good):
+		ret.Status = Good
+	case bool(r.Unknown):
+		ret.Status = Unknown
+	default:
+		ret.Status = Revoked
+		ret.RevokedAt = r.Revoked.RevocationTime
+		ret.RevocationReason = int(r.Revoked.Reason)
+	}
+	return ret
+}
+
+// ParseSingleResponse parses a DER-encoded SingleResponse, as found in the
+// Raw field of a SingleResponse.
+func ParseSingleResponse(der []byte) (*SingleResponse, error) {
+	var r singleResponse
+	rest, err := asn1.Unmarshal(der, &r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ParseError("trailing data in OCSP single response")
+	}
+	ret := newSingleResponse(&r)
+	return &ret, nil
+}
+
+// asn1 returns the singleResponse encoding the fields of r. Raw is ignored.
+func (r *SingleResponse) asn1() (singleResponse, error) {
+	hashAlg, ok := getHashAlgorithmIdentifier(r.CertID.HashAlgorithm)
+	if !ok {
+		return singleResponse{}, fmt.Errorf("ocsp: unsupported CertID hash algorithm %v", r.CertID.HashAlgorithm)
+	}
+	if r.CertID.SerialNumber == nil {
+		return singleResponse{}, errors.New("ocsp: serial number is required")
+	}
+	ret := singleResponse{
+		CertID: certID{
+			HashAlgorithm: hashAlg,
+			NameHash:      r.CertID.IssuerNameHash,
+			IssuerKeyHash: r.CertID.IssuerKeyHash,
+			SerialNumber:  r.CertID.SerialNumber,
+		},
+		ThisUpdate:       r.ThisUpdate.UTC(),
+		NextUpdate:       r.NextUpdate.UTC(),
+		SingleExtensions: r.Extensions,
+	}
+	switch r.Status {
+	case Good:
+		ret.Good = true
+	case Unknown:
+		ret.Unknown = true
+	case Revoked:
+		ret.Revoked = revokedInfo{
+			RevocationTime: r.RevokedAt.UTC(),
+			Reason:         asn1.Enumerated(r.RevocationReason),
+		}
+	default:
+		return singleResponse{}, fmt.Errorf("ocsp: invalid status %d", r.Status)
+	}
+	return ret, nil
+}
+
+// Marshal returns the DER encoding of r. It is encoded from the fields of r,
+// so Raw is ignored.
+func (r *SingleResponse) Marshal() ([]byte, error) {
+	single, err := r.asn1()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(single)
+}
+
+// ForEachSingleResponse calls fn with each certificate status in the OCSP
+// response der, in order. The statuses are decoded one at a time, so the
+// memory used does not grow with the number of statuses in the response. If
+// fn returns an error, the iteration stops and the error is returned.
+//
+// ForEachSingleResponse does not verify the signature of the response. The
+// Raw fields of the statuses point into der.
+func ForEachSingleResponse(der []byte, fn func(SingleResponse) error) error {
+	_, tbs, err := parseRawBasicResponse(der)
+	if err != nil {
+		return err
+	}
+
+	for rest := tbs.Responses.Bytes; len(rest) > 0; {
+		var r singleResponse
+		if rest, err = asn1.Unmarshal(rest, &r); err != nil {
+			return err
+		}
+		if err := fn(newSingleResponse(&r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRawBasicResponse decodes the basic response in der, keeping the list
+// of statuses as raw DER.
+func parseRawBasicResponse(der []byte) (*rawBasicResponse, *rawResponseData, error) {
+	var resp responseASN1
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) > 0 {
+		return nil, nil, ParseError("trailing data in OCSP response")
+	}
+	if status := ResponseStatus(resp.Status); status != Success {
+		return nil, nil, ResponseError{status}
+	}
+	if !resp.Response.ResponseType.Equal(idPKIXOCSPBasic) {
+		return nil, nil, ParseError("bad OCSP response type")
+	}
+
+	var basicResp rawBasicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basicResp); err != nil {
+		return nil, nil, err
+	}
+	var tbs rawResponseData
+	if _, err := asn1.Unmarshal(basicResp.TBSResponseData.FullBytes, &tbs); err != nil {
+		return nil, nil, err
+	}
+	if tbs.Responses.Class != asn1.ClassUniversal || tbs.Responses.Tag != asn1.TagSequence || !tbs.Responses.IsCompound {
+		return nil, nil, ParseError("invalid OCSP responses")
+	}
+	return &basicResp, &tbs, nil
+}
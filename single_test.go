@@ -0,0 +1,80 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestForEachSingleResponse(t *testing.T) {
+	der, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var serials []int64
+	err = ForEachSingleResponse(der, func(r SingleResponse) error {
+		serials = append(serials, r.CertID.SerialNumber.Int64())
+		if r.Status != Good || r.CertID.HashAlgorithm != crypto.SHA1 || r.ThisUpdate.IsZero() || r.NextUpdate.IsZero() {
+			t.Errorf("unexpected status %+v", r)
+		}
+		resp, err := ParseResponseForSerial(der, r.CertID.SerialNumber, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(r.Raw, resp.RawSingleResponse) {
+			t.Errorf("Raw of serial %v does not match RawSingleResponse", r.CertID.SerialNumber)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachSingleResponse: %v", err)
+	}
+	if len(serials) != 5 {
+		t.Fatalf("got %d statuses, want 5", len(serials))
+	}
+	for i, serial := range serials {
+		if serial != int64(i) {
+			t.Errorf("serials = %v, want them in order", serials)
+			break
+		}
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = ForEachSingleResponse(der, func(SingleResponse) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || calls != 2 {
+		t.Errorf("ForEachSingleResponse: got %v after %d calls, want the error of fn after 2 calls", err, calls)
+	}
+
+	der, _ = hex.DecodeString(ocspResponseHex)
+	want, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls = 0
+	err = ForEachSingleResponse(der, func(r SingleResponse) error {
+		calls++
+		if r.CertID.SerialNumber.Cmp(want.SerialNumber) != 0 || r.Status != want.Status ||
+			!r.ThisUpdate.Equal(want.ThisUpdate) || !r.NextUpdate.Equal(want.NextUpdate) {
+			t.Errorf("got %+v, want the status of %v", r, want.SerialNumber)
+		}
+		return nil
+	})
+	if err != nil || calls != 1 {
+		t.Errorf("ForEachSingleResponse: got %v after %d calls", err, calls)
+	}
+
+	der, _ = hex.DecodeString(errorResponseHex)
+	if err := ForEachSingleResponse(der, func(SingleResponse) error { return nil }); err == nil {
+		t.Error("ForEachSingleResponse with an error response: expected an error")
+	}
+}
@@ -0,0 +1,70 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+)
+
+// sizingSigner is a crypto.Signer that returns a zero signature of the
+// maximum size for its public key, used to estimate the size of responses
+// without signing them.
+type sizingSigner struct {
+	pub  crypto.PublicKey
+	size int
+}
+
+func (s sizingSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s sizingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return make([]byte, s.size), nil
+}
+
+// maxSignatureSize returns the maximum size of the signatures made with the
+// private key of pub.
+func maxSignatureSize(pub crypto.PublicKey) (int, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return (pub.N.BitLen() + 7) / 8, nil
+	case *ecdsa.PublicKey:
+		// A SEQUENCE of two INTEGERs, each one with a leading zero byte if
+		// the most significant bit is set.
+		n := (pub.Curve.Params().BitSize+7)/8 + 1
+		n = 2 * (n + derHeaderSize(n))
+		return n + derHeaderSize(n), nil
+	default:
+		return 0, fmt.Errorf("ocsp: cannot estimate the signature size of %T keys", pub)
+	}
+}
+
+// derHeaderSize returns the size of the tag and length of a DER element with
+// a content of n bytes.
+func derHeaderSize(n int) int {
+	size := 2
+	for ; n > 127; n >>= 8 {
+		size++
+	}
+	return size
+}
+
+// EstimateResponseSize returns the maximum size of the response that
+// CreateResponseWithOptions would return for the same arguments, without
+// signing it. pub is the public key of the responder, which must be an RSA or
+// ECDSA key. The ProducedAt time is always encoded with the same size, so the
+// estimate holds if the response is created later.
+func EstimateResponseSize(issuer, responderCert *x509.Certificate, template Response, pub crypto.PublicKey, opts *CreateResponseOptions) (int, error) {
+	size, err := maxSignatureSize(pub)
+	if err != nil {
+		return 0, err
+	}
+	der, err := CreateResponseWithOptions(issuer, responderCert, template, sizingSigner{pub: pub, size: size}, opts)
+	if err != nil {
+		return 0, err
+	}
+	return len(der), nil
+}
@@ -0,0 +1,131 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCreateResponseWithOptions(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		Certificate:  responder,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3}, Value: []byte{5, 0}},
+		},
+		ResponseExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 4}, Value: []byte{5, 0}},
+		},
+	}
+
+	full, err := CreateResponseWithOptions(responder, responder, template, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := CreateResponseWithOptions(responder, responder, template, key, &CompactResponseOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compact) >= len(full) {
+		t.Errorf("compact response is %d bytes, full response is %d bytes", len(compact), len(full))
+	}
+
+	resp, err := ParseResponse(compact, responder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Certificate != nil {
+		t.Error("compact response contains a certificate")
+	}
+	if len(resp.Extensions) != 0 || len(resp.ResponseExtensions) != 0 {
+		t.Errorf("compact response contains extensions: %v %v", resp.Extensions, resp.ResponseExtensions)
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(responder.RawSubjectPublicKeyInfo, &spki); err != nil {
+		t.Fatal(err)
+	}
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+	if !bytes.Equal(resp.ResponderKeyHash, keyHash[:]) || resp.ResponderName != nil {
+		t.Errorf("ResponderKeyHash = %x, want %x", resp.ResponderKeyHash, keyHash)
+	}
+}
+
+func TestEstimateResponseSize(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, key := range map[string]crypto.Signer{"RSA": rsaKey, "P-256": p256, "P-521": p521} {
+		t.Run(name, func(t *testing.T) {
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "Responder"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			responder, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, opts := range []*CreateResponseOptions{nil, &CompactResponseOptions} {
+				resp := Response{
+					Status:       Revoked,
+					SerialNumber: big.NewInt(1),
+					ThisUpdate:   time.Now().Truncate(time.Second),
+					NextUpdate:   time.Now().Truncate(time.Second).Add(time.Hour),
+					RevokedAt:    time.Now().Truncate(time.Second),
+					Certificate:  responder,
+				}
+				estimate, err := EstimateResponseSize(responder, responder, resp, key.Public(), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// ECDSA signatures can be a few bytes shorter than the maximum,
+				// and large ones can also lose a byte of their DER lengths.
+				for i := 0; i < 10; i++ {
+					der, err := CreateResponseWithOptions(responder, responder, resp, key, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(der) > estimate || len(der) < estimate-8 {
+						t.Errorf("EstimateResponseSize = %d, response is %d bytes", estimate, len(der))
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := EstimateResponseSize(nil, nil, Response{}, "key", nil); err == nil {
+		t.Error("EstimateResponseSize with an unknown key: expected an error")
+	}
+}
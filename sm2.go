@@ -0,0 +1,110 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io"
+	"sync"
+)
+
+// SM3 is the hash function defined in GB/T 32905-2016. It is not supported by
+// package crypto, and CertIDs using it can only be created after registering
+// an implementation with RegisterSM3. CertIDs using SM3 are always recognized
+// when parsing.
+const SM3 crypto.Hash = 0x1100
+
+// SM2WithSM3 is the SM2 signature algorithm with SM3 defined in GB/T
+// 32918-2016. It is not defined by crypto/x509. Responses signed with it are
+// always recognized when parsing, but they can only be verified and created
+// after registering an implementation with RegisterSM2.
+const SM2WithSM3 x509.SignatureAlgorithm = 0x1100
+
+var (
+	oidSM3                 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+	oidSignatureSM2WithSM3 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}
+)
+
+// SM2 is the interface implemented by SM2 providers.
+//
+// Signatures are created and verified over the raw message, so the
+// implementation is responsible for computing the SM2 Z value with the
+// distinguishing identifier and for hashing the message with SM3.
+type SM2 interface {
+	// Sign signs message with priv.
+	Sign(rand io.Reader, priv crypto.Signer, message []byte) ([]byte, error)
+	// Verify checks that signature is a valid signature of message made by
+	// the private key corresponding to pub.
+	Verify(pub crypto.PublicKey, message, signature []byte) error
+	// IsPublicKey reports whether pub is an SM2 public key.
+	IsPublicKey(pub crypto.PublicKey) bool
+}
+
+var sm2Impl struct {
+	sync.RWMutex
+	impl SM2
+}
+
+func init() {
+	hashOIDs[SM3] = oidSM3
+
+	if err := RegisterSignatureAlgorithm(SignatureAlgorithmDetails{
+		Algorithm: SM2WithSM3,
+		OID:       oidSignatureSM2WithSM3,
+		Verify: func(pub crypto.PublicKey, signed, signature []byte) error {
+			impl := getSM2()
+			if impl == nil {
+				return x509.ErrUnsupportedAlgorithm
+			}
+			return impl.Verify(pub, signed, signature)
+		},
+		Sign: func(rand io.Reader, priv crypto.Signer, signed []byte) ([]byte, error) {
+			impl := getSM2()
+			if impl == nil {
+				return nil, x509.ErrUnsupportedAlgorithm
+			}
+			return impl.Sign(rand, priv, signed)
+		},
+		MatchesPublicKey: func(pub crypto.PublicKey) bool {
+			impl := getSM2()
+			return impl != nil && impl.IsPublicKey(pub)
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterSM2 sets the implementation used to create and verify responses
+// signed with SM2WithSM3. It is meant to be called from init functions.
+//
+// Note that crypto/x509 cannot parse certificates with SM2 public keys, so
+// responses with embedded SM2 certificates cannot be parsed.
+func RegisterSM2(impl SM2) error {
+	if impl == nil {
+		return errors.New("ocsp: SM2 implementation cannot be nil")
+	}
+	sm2Impl.Lock()
+	sm2Impl.impl = impl
+	sm2Impl.Unlock()
+	return nil
+}
+
+func getSM2() SM2 {
+	sm2Impl.RLock()
+	defer sm2Impl.RUnlock()
+	return sm2Impl.impl
+}
+
+// RegisterSM3 sets the implementation of the SM3 hash function, used to create
+// CertIDs with SM3. It is meant to be called from init functions.
+func RegisterSM3(newHash func() hash.Hash) error {
+	if newHash == nil {
+		return errors.New("ocsp: SM3 implementation cannot be nil")
+	}
+	hashOIDsMu.Lock()
+	hashFuncs[SM3] = newHash
+	hashOIDsMu.Unlock()
+	return nil
+}
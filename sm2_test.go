@@ -0,0 +1,137 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeSM2 implements SM2 using ECDSA P-256 with SHA-256. It only exercises the
+// plumbing, it does not produce SM2 signatures.
+type fakeSM2 struct{}
+
+type fakeSM2PublicKey struct {
+	*ecdsa.PublicKey
+}
+
+type fakeSM2PrivateKey struct {
+	*ecdsa.PrivateKey
+}
+
+func (k fakeSM2PrivateKey) Public() crypto.PublicKey {
+	return fakeSM2PublicKey{&k.PrivateKey.PublicKey}
+}
+
+func (fakeSM2) Sign(rand io.Reader, priv crypto.Signer, message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	return priv.Sign(rand, digest[:], crypto.SHA256)
+}
+
+func (fakeSM2) Verify(pub crypto.PublicKey, message, signature []byte) error {
+	key, ok := pub.(fakeSM2PublicKey)
+	if !ok {
+		return errors.New("not an SM2 key")
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(key.PublicKey, digest[:], signature) {
+		return errors.New("SM2 verification failure")
+	}
+	return nil
+}
+
+func (fakeSM2) IsPublicKey(pub crypto.PublicKey) bool {
+	_, ok := pub.(fakeSM2PublicKey)
+	return ok
+}
+
+func TestOCSPResponseSM2(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := fakeSM2PrivateKey{ecdsaKey}
+	responder := &x509.Certificate{RawSubject: []byte{0x30, 0}}
+
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		IssuerHash:   SM3,
+	}
+
+	if getSM2() == nil {
+		if _, err := CreateResponse(issuer, responder, template, priv); err == nil {
+			t.Error("CreateResponse didn't fail without an SM2 implementation")
+		}
+	}
+	if err := RegisterSM2(fakeSM2{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSM3(sha256.New); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sigAlgo := range []x509.SignatureAlgorithm{0, SM2WithSM3} {
+		template.SignatureAlgorithm = sigAlgo
+		der, err := CreateResponse(issuer, responder, template, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.SignatureAlgorithm != SM2WithSM3 {
+			t.Errorf("resp.SignatureAlgorithm: got %v, want %v", resp.SignatureAlgorithm, SM2WithSM3)
+		}
+		if resp.IssuerHash != SM3 {
+			t.Errorf("resp.IssuerHash: got %v, want %v", resp.IssuerHash, SM3)
+		}
+		if err := resp.CheckSignatureFromKey(priv.Public()); err != nil {
+			t.Errorf("CheckSignatureFromKey: %v", err)
+		}
+		if err := resp.CheckSignatureFromKey(ecdsaKey.Public()); err == nil {
+			t.Error("CheckSignatureFromKey didn't fail with a non-SM2 key")
+		}
+	}
+
+	leafCert, _ := hex.DecodeString(leafCertHex)
+	leaf, err := x509.ParseCertificate(leafCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateRequest(leaf, issuer, &RequestOptions{Hash: SM3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.HashAlgorithm != SM3 {
+		t.Errorf("request.HashAlgorithm: got %v, want %v", req.HashAlgorithm, SM3)
+	}
+}
+
+func TestRegisterSM2(t *testing.T) {
+	if err := RegisterSM2(nil); err == nil {
+		t.Error("RegisterSM2 didn't fail with a nil implementation")
+	}
+	if err := RegisterSM3(nil); err == nil {
+		t.Error("RegisterSM3 didn't fail with a nil implementation")
+	}
+}
@@ -0,0 +1,159 @@
+package ocsp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// StapleExporter writes OCSP staples to a file in the raw DER format read by
+// reverse proxies, like the file configured with ssl_stapling_file in nginx,
+// or the .ocsp file next to a certificate in HAProxy.
+type StapleExporter struct {
+	// Path is the file the staple is written to.
+	Path string
+	// Perm is the permission of the file. If zero, 0644 is used.
+	Perm os.FileMode
+	// PostUpdate is optionally called after the file is replaced, for
+	// example, to signal the proxy to reload it.
+	PostUpdate func(path string) error
+}
+
+// HAProxyStaplePath returns the path of the staple file HAProxy loads for the
+// certificate at certPath.
+func HAProxyStaplePath(certPath string) string {
+	return certPath + ".ocsp"
+}
+
+// Export atomically replaces the file with the DER-encoded OCSP response der
+// and calls PostUpdate. If the file already contains der, it is not written
+// again and PostUpdate is not called. Responses that are not successful are
+// rejected, as proxies would serve them as is.
+func (e *StapleExporter) Export(der []byte) error {
+	var resp responseASN1
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return ParseError("trailing data in OCSP response")
+	}
+	if status := ResponseStatus(resp.Status); status != Success {
+		return ResponseError{status}
+	}
+
+	if current, err := os.ReadFile(e.Path); err == nil && bytes.Equal(current, der) {
+		return nil
+	}
+	perm := e.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+	if err := WriteStapleFile(e.Path, der, perm); err != nil {
+		return err
+	}
+	if e.PostUpdate != nil {
+		return e.PostUpdate(e.Path)
+	}
+	return nil
+}
+
+// WriteStapleFile atomically replaces the file at path with data, by writing
+// it to a temporary file in the same directory and renaming it, so readers
+// never see a partially written staple.
+func WriteStapleFile(path string, data []byte, perm os.FileMode) error {
+	if path == "" {
+		return errors.New("ocsp: staple path cannot be empty")
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// maxUint24 is the maximum length of the TLS vectors of an OCSPResponseList.
+const maxUint24 = 1<<24 - 1
+
+// MarshalOCSPResponseList returns the OCSPResponseList sent by TLS servers in
+// the CertificateStatus message of the status_request_v2 extension, with the
+// ocsp_multi status type. See RFC 6961, section 2.2. The responses are the
+// DER-encoded OCSP responses of each certificate of the chain, starting with
+// the server certificate. An empty response indicates that no response is
+// available for that certificate.
+func MarshalOCSPResponseList(responses [][]byte) ([]byte, error) {
+	if len(responses) == 0 {
+		return nil, errors.New("ocsp: OCSPResponseList cannot be empty")
+	}
+	n := 0
+	for _, der := range responses {
+		if len(der) > maxUint24 {
+			return nil, errors.New("ocsp: response is too large for an OCSPResponseList")
+		}
+		n += 3 + len(der)
+	}
+	if n > maxUint24 {
+		return nil, errors.New("ocsp: responses are too large for an OCSPResponseList")
+	}
+	b := make([]byte, 0, 3+n)
+	b = appendUint24(b, n)
+	for _, der := range responses {
+		b = appendUint24(b, len(der))
+		b = append(b, der...)
+	}
+	return b, nil
+}
+
+// ParseOCSPResponseList parses the OCSPResponseList of a CertificateStatus
+// message with the ocsp_multi status type, and returns the responses it
+// contains, in the order of the certificate chain. Missing responses are
+// returned as empty slices.
+func ParseOCSPResponseList(b []byte) ([][]byte, error) {
+	list, rest, ok := readUint24Vector(b)
+	if !ok || len(rest) > 0 || len(list) == 0 {
+		return nil, ParseError("invalid OCSPResponseList")
+	}
+	var responses [][]byte
+	for len(list) > 0 {
+		var der []byte
+		if der, list, ok = readUint24Vector(list); !ok {
+			return nil, ParseError("invalid OCSPResponseList")
+		}
+		responses = append(responses, der)
+	}
+	return responses, nil
+}
+
+func appendUint24(b []byte, n int) []byte {
+	return append(b, byte(n>>16), byte(n>>8), byte(n))
+}
+
+// readUint24Vector reads a TLS vector with a 24-bit length prefix from b.
+func readUint24Vector(b []byte) (vector, rest []byte, ok bool) {
+	if len(b) < 3 {
+		return nil, nil, false
+	}
+	n := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	if len(b)-3 < n {
+		return nil, nil, false
+	}
+	return b[3 : 3+n : 3+n], b[3+n:], true
+}
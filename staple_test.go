@@ -0,0 +1,141 @@
+package ocsp
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStapleExporter(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	newStaple := func(serial int64) []byte {
+		der, err := CreateResponse(responder, responder, Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(serial),
+			ThisUpdate:   time.Now().Truncate(time.Second),
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	dir := t.TempDir()
+	var updates []string
+	e := &StapleExporter{
+		Path: HAProxyStaplePath(filepath.Join(dir, "server.pem")),
+		PostUpdate: func(path string) error {
+			updates = append(updates, path)
+			return nil
+		},
+	}
+	if e.Path != filepath.Join(dir, "server.pem.ocsp") {
+		t.Errorf("HAProxyStaplePath: got %s", e.Path)
+	}
+
+	for i, der := range [][]byte{newStaple(1), newStaple(2)} {
+		if err := e.Export(der); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(e.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, der) {
+			t.Error("Export: file does not contain the staple")
+		}
+		if len(updates) != i+1 || updates[i] != e.Path {
+			t.Errorf("PostUpdate: got calls %v", updates)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if fi, err := os.Stat(e.Path); err != nil || fi.Mode().Perm() != 0o644 {
+			t.Errorf("Export: got mode %v, %v", fi.Mode(), err)
+		}
+	}
+
+	// The same staple is not written again.
+	current, _ := os.ReadFile(e.Path)
+	if err := e.Export(current); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 {
+		t.Errorf("PostUpdate: called for an unchanged staple")
+	}
+
+	// Invalid and unsuccessful responses are rejected.
+	for _, der := range [][]byte{{0x30, 0x00}, append(current, 0), UnauthorizedErrorResponse} {
+		if err := e.Export(der); err == nil {
+			t.Errorf("Export(%x) didn't fail", der)
+		}
+	}
+	if got, _ := os.ReadFile(e.Path); !bytes.Equal(got, current) {
+		t.Error("Export: rejected staple replaced the file")
+	}
+
+	e.PostUpdate = func(string) error { return errors.New("reload failed") }
+	if err := e.Export(newStaple(3)); err == nil {
+		t.Error("Export didn't return the PostUpdate error")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Export left temporary files: %v", entries)
+	}
+}
+
+func TestWriteStapleFile(t *testing.T) {
+	if err := WriteStapleFile("", []byte{1}, 0o600); err == nil {
+		t.Error("WriteStapleFile didn't fail with an empty path")
+	}
+	if err := WriteStapleFile(filepath.Join(t.TempDir(), "missing", "staple"), []byte{1}, 0o600); err == nil {
+		t.Error("WriteStapleFile didn't fail with a missing directory")
+	}
+}
+
+func TestOCSPResponseList(t *testing.T) {
+	responses := [][]byte{{0x30, 0x03, 0x0a, 0x01, 0x00}, {}, {0x30, 0x00}}
+	b, err := MarshalOCSPResponseList(responses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 0, 16, 0, 0, 5, 0x30, 0x03, 0x0a, 0x01, 0x00, 0, 0, 0, 0, 0, 2, 0x30, 0x00}
+	if !bytes.Equal(b, want) {
+		t.Errorf("MarshalOCSPResponseList: got %x, want %x", b, want)
+	}
+	got, err := ParseOCSPResponseList(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(responses) {
+		t.Fatalf("ParseOCSPResponseList: got %d responses, want %d", len(got), len(responses))
+	}
+	for i := range got {
+		if !bytes.Equal(got[i], responses[i]) {
+			t.Errorf("ParseOCSPResponseList: got %x, want %x", got[i], responses[i])
+		}
+	}
+
+	if _, err := MarshalOCSPResponseList(nil); err == nil {
+		t.Error("MarshalOCSPResponseList: expected error for an empty list")
+	}
+	for _, b := range [][]byte{
+		nil,
+		{0, 0, 0},
+		{0, 0, 4, 0, 0, 2, 0},
+		{0, 0, 3, 0, 0, 0, 0},
+		{0, 0, 1},
+	} {
+		if _, err := ParseOCSPResponseList(b); err == nil {
+			t.Errorf("ParseOCSPResponseList(%x): expected error", b)
+		}
+	}
+}
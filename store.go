@@ -0,0 +1,234 @@
+package ocsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// The response store file format is:
+//
+//	header:  magic "OCSPSTOR" | version uint32 | reserved uint32
+//	blobs:   the DER-encoded responses, one after the other
+//	index:   count entries sorted by key, each one
+//	         key [32]byte | offset uint64 | length uint32 |
+//	         thisUpdate int64 | nextUpdate int64
+//	footer:  index offset uint64 | count uint64 | magic "OCSPINDX"
+//
+// All integers are big-endian, and times are Unix nanoseconds, or zero if not
+// set. The fixed-size index allows looking up responses with a binary search
+// directly on the mapped file.
+const (
+	storeVersion     = 1
+	storeHeaderSize  = 16
+	storeFooterSize  = 24
+	storeIndexSize   = len(CertIDKey{}) + 8 + 4 + 8 + 8
+	storeHeaderMagic = "OCSPSTOR"
+	storeFooterMagic = "OCSPINDX"
+)
+
+// ErrInvalidStore is returned when opening a file that is not a valid
+// response store.
+var ErrInvalidStore = errors.New("ocsp: invalid response store")
+
+type storeIndexEntry struct {
+	key        CertIDKey
+	offset     uint64
+	length     uint32
+	thisUpdate int64
+	nextUpdate int64
+}
+
+// StoreWriter writes a response store that can be opened with OpenStore.
+// Responses are written as they are added, and the index is written by Close.
+type StoreWriter struct {
+	w      *bufio.Writer
+	offset uint64
+	index  []storeIndexEntry
+	err    error
+}
+
+// NewStoreWriter returns a StoreWriter writing to w.
+func NewStoreWriter(w io.Writer) *StoreWriter {
+	sw := &StoreWriter{w: bufio.NewWriter(w)}
+	var header [storeHeaderSize]byte
+	copy(header[:], storeHeaderMagic)
+	binary.BigEndian.PutUint32(header[8:], storeVersion)
+	sw.write(header[:])
+	return sw
+}
+
+func (sw *StoreWriter) write(b []byte) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = sw.w.Write(b)
+	sw.offset += uint64(len(b))
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Add writes the response in entry, to be found by key.
+func (sw *StoreWriter) Add(key CertIDKey, entry CacheEntry) error {
+	if uint64(len(entry.Response)) > math.MaxUint32 {
+		return errors.New("ocsp: response too large for the store")
+	}
+	sw.index = append(sw.index, storeIndexEntry{
+		key:        key,
+		offset:     sw.offset,
+		length:     uint32(len(entry.Response)),
+		thisUpdate: unixNano(entry.ThisUpdate),
+		nextUpdate: unixNano(entry.NextUpdate),
+	})
+	sw.write(entry.Response)
+	return sw.err
+}
+
+// Close writes the index and flushes the written data. It does not close the
+// underlying writer. It returns an error if the same key was added more than
+// once.
+func (sw *StoreWriter) Close() error {
+	sort.Slice(sw.index, func(i, j int) bool {
+		return bytes.Compare(sw.index[i].key[:], sw.index[j].key[:]) < 0
+	})
+	for i := 1; i < len(sw.index); i++ {
+		if sw.index[i].key == sw.index[i-1].key {
+			return fmt.Errorf("ocsp: duplicate key %x in response store", sw.index[i].key)
+		}
+	}
+
+	indexOffset := sw.offset
+	var buf [storeIndexSize]byte
+	for _, e := range sw.index {
+		n := copy(buf[:], e.key[:])
+		binary.BigEndian.PutUint64(buf[n:], e.offset)
+		binary.BigEndian.PutUint32(buf[n+8:], e.length)
+		binary.BigEndian.PutUint64(buf[n+12:], uint64(e.thisUpdate))
+		binary.BigEndian.PutUint64(buf[n+20:], uint64(e.nextUpdate))
+		sw.write(buf[:])
+	}
+
+	var footer [storeFooterSize]byte
+	binary.BigEndian.PutUint64(footer[:], indexOffset)
+	binary.BigEndian.PutUint64(footer[8:], uint64(len(sw.index)))
+	copy(footer[16:], storeFooterMagic)
+	sw.write(footer[:])
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.w.Flush()
+}
+
+// Store is a read-only response store. On Unix systems the file is mapped in
+// memory, so opening it is instantaneous and the responses do not use heap
+// memory.
+type Store struct {
+	data  []byte
+	index []byte
+	count int
+	close func() error
+}
+
+// OpenStore opens the response store at path, written by a StoreWriter.
+func OpenStore(path string) (*Store, error) {
+	data, closeFn, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newStore(data)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	s.close = closeFn
+	return s, nil
+}
+
+// newStore validates the header and footer of data and returns a Store
+// reading from it.
+func newStore(data []byte) (*Store, error) {
+	if len(data) < storeHeaderSize+storeFooterSize ||
+		string(data[:8]) != storeHeaderMagic ||
+		string(data[len(data)-8:]) != storeFooterMagic {
+		return nil, ErrInvalidStore
+	}
+	if binary.BigEndian.Uint32(data[8:]) != storeVersion {
+		return nil, fmt.Errorf("%w: unsupported version", ErrInvalidStore)
+	}
+	footer := data[len(data)-storeFooterSize:]
+	indexOffset := binary.BigEndian.Uint64(footer)
+	count := binary.BigEndian.Uint64(footer[8:])
+	indexEnd := uint64(len(data) - storeFooterSize)
+	if indexOffset < storeHeaderSize || indexOffset > indexEnd ||
+		count != (indexEnd-indexOffset)/uint64(storeIndexSize) ||
+		(indexEnd-indexOffset)%uint64(storeIndexSize) != 0 {
+		return nil, fmt.Errorf("%w: bad index", ErrInvalidStore)
+	}
+	return &Store{
+		data:  data[:indexOffset],
+		index: data[indexOffset:indexEnd],
+		count: int(count),
+	}, nil
+}
+
+// Len returns the number of responses in the store.
+func (s *Store) Len() int {
+	return s.count
+}
+
+// Get returns the entry stored for key. The returned response is backed by
+// the mapped file: it must not be modified, and it is only valid until the
+// store is closed.
+func (s *Store) Get(key CertIDKey) (CacheEntry, bool) {
+	i := sort.Search(s.count, func(i int) bool {
+		return bytes.Compare(s.index[i*storeIndexSize:i*storeIndexSize+len(key)], key[:]) >= 0
+	})
+	if i == s.count {
+		return CacheEntry{}, false
+	}
+	e := s.index[i*storeIndexSize : (i+1)*storeIndexSize]
+	if !bytes.Equal(e[:len(key)], key[:]) {
+		return CacheEntry{}, false
+	}
+	e = e[len(key):]
+	offset := binary.BigEndian.Uint64(e)
+	length := uint64(binary.BigEndian.Uint32(e[8:]))
+	if offset < storeHeaderSize || offset > uint64(len(s.data)) || length > uint64(len(s.data))-offset {
+		return CacheEntry{}, false
+	}
+	return CacheEntry{
+		Response:   s.data[offset : offset+length : offset+length],
+		ThisUpdate: fromUnixNano(int64(binary.BigEndian.Uint64(e[12:]))),
+		NextUpdate: fromUnixNano(int64(binary.BigEndian.Uint64(e[20:]))),
+	}, true
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+// Close releases the resources used by the store. The responses returned by
+// Get must not be used after calling Close.
+func (s *Store) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	err := s.close()
+	s.close = nil
+	s.data, s.index, s.count = nil, nil, 0
+	return err
+}
@@ -0,0 +1,169 @@
+package ocsp
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestStore(t *testing.T, entries map[CertIDKey]CacheEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewStoreWriter(&buf)
+	for k, e := range entries {
+		if err := w.Add(k, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "responses.store")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStore(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	thisUpdate := time.Now().Truncate(time.Second).UTC()
+
+	entries := map[CertIDKey]CacheEntry{}
+	for i := 0; i < 100; i++ {
+		template := Response{
+			Status:       Good,
+			SerialNumber: big.NewInt(int64(i)),
+			ThisUpdate:   thisUpdate,
+		}
+		if i%2 == 0 {
+			template.NextUpdate = thisUpdate.Add(time.Hour)
+		}
+		der, err := CreateResponse(responder, responder, template, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ParseResponse(der, responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[resp.Key()] = CacheEntry{Response: der, ThisUpdate: resp.ThisUpdate, NextUpdate: resp.NextUpdate}
+	}
+
+	s, err := OpenStore(writeTestStore(t, entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.Len() != len(entries) {
+		t.Errorf("Len: got %d, want %d", s.Len(), len(entries))
+	}
+	for k, want := range entries {
+		got, ok := s.Get(k)
+		if !ok {
+			t.Fatalf("Get(%x): not found", k)
+		}
+		if !bytes.Equal(got.Response, want.Response) || !got.ThisUpdate.Equal(want.ThisUpdate) || !got.NextUpdate.Equal(want.NextUpdate) {
+			t.Errorf("Get(%x): got %v, want %v", k, got, want)
+		}
+		resp, err := ParseResponse(got.Response, responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Key() != k {
+			t.Errorf("Get(%x): got response for %x", k, resp.Key())
+		}
+	}
+	if _, ok := s.Get(testCacheKey(1000)); ok {
+		t.Error("Get: found missing key")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, ok := s.Get(testCacheKey(1)); ok {
+		t.Error("Get: found key after Close")
+	}
+}
+
+func TestStoreEmpty(t *testing.T) {
+	s, err := OpenStore(writeTestStore(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Len() != 0 {
+		t.Errorf("Len: got %d, want 0", s.Len())
+	}
+	if _, ok := s.Get(testCacheKey(1)); ok {
+		t.Error("Get: found key in empty store")
+	}
+}
+
+func TestStoreWriterDuplicateKey(t *testing.T) {
+	w := NewStoreWriter(new(bytes.Buffer))
+	for i := 0; i < 2; i++ {
+		if err := w.Add(testCacheKey(1), CacheEntry{Response: []byte{1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close didn't fail with a duplicate key")
+	}
+}
+
+func TestOpenStoreInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewStoreWriter(&buf)
+	if err := w.Add(testCacheKey(1), CacheEntry{Response: []byte{1, 2, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+
+	corrupt := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), valid...))
+	}
+	tests := map[string][]byte{
+		"empty":        {},
+		"truncated":    valid[:len(valid)-1],
+		"header magic": corrupt(func(b []byte) []byte { b[0] = 'Y'; return b }),
+		"footer magic": corrupt(func(b []byte) []byte { b[len(b)-1] = 'Y'; return b }),
+		"version":      corrupt(func(b []byte) []byte { b[11] = 2; return b }),
+		"index offset": corrupt(func(b []byte) []byte { b[len(b)-storeFooterSize+7]++; return b }),
+		"count":        corrupt(func(b []byte) []byte { b[len(b)-storeFooterSize+15]++; return b }),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "responses.store")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := OpenStore(path); !errors.Is(err, ErrInvalidStore) {
+				t.Errorf("OpenStore: got %v, want ErrInvalidStore", err)
+			}
+		})
+	}
+
+	if _, err := OpenStore(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("OpenStore didn't fail with a missing file")
+	}
+
+	// An entry pointing outside of the responses is not returned.
+	b := append([]byte(nil), valid...)
+	b[storeHeaderSize+3+len(CertIDKey{})+7] = 0xff
+	s, err := newStore(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get(testCacheKey(1)); ok {
+		t.Error("Get: returned entry with a bad offset")
+	}
+}
@@ -0,0 +1,119 @@
+package ocsp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"time"
+)
+
+// EncodedTime is a time of an OCSP response with its original encoding, which
+// is lost when it is decoded into a time.Time.
+type EncodedTime struct {
+	// Raw is the content of the GeneralizedTime, like "20211107142553Z", or
+	// nil if the time is not present in the response.
+	Raw []byte
+	// Time is the decoded time.
+	Time time.Time
+}
+
+// HasFractionalSeconds reports whether the time is encoded with fractional
+// seconds.
+func (t EncodedTime) HasFractionalSeconds() bool {
+	return bytes.IndexByte(t.Raw, '.') >= 0
+}
+
+// IsCanonical reports whether the time is encoded as required by RFC 5280,
+// section 4.1.2.5.2: in UTC, with seconds and without fractional seconds,
+// like "YYYYMMDDHHMMSSZ". Absent times are canonical.
+func (t EncodedTime) IsCanonical() bool {
+	if t.Raw == nil {
+		return true
+	}
+	if len(t.Raw) != len("20060102150405Z") || t.Raw[len(t.Raw)-1] != 'Z' {
+		return false
+	}
+	for _, c := range t.Raw[:len(t.Raw)-1] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ResponseTimes are the times of a response with their original encoding.
+type ResponseTimes struct {
+	ProducedAt EncodedTime
+	ThisUpdate EncodedTime
+	NextUpdate EncodedTime
+	RevokedAt  EncodedTime
+}
+
+// encodedTimesResponseData is a responseData with producedAt kept as raw
+// DER.
+type encodedTimesResponseData struct {
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     asn1.RawValue
+}
+
+// encodedTimesSingleResponse is a singleResponse with the times and status
+// kept as raw DER.
+type encodedTimesSingleResponse struct {
+	CertID     asn1.RawValue
+	Status     asn1.RawValue
+	ThisUpdate asn1.RawValue
+	NextUpdate asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// EncodedTimes returns the times of resp with their original encoding, read
+// from TBSResponseData and RawSingleResponse. It can be used by strict
+// validators to detect encodings not allowed by RFC 5280, like fractional
+// seconds.
+func (resp *Response) EncodedTimes() (*ResponseTimes, error) {
+	var tbs encodedTimesResponseData
+	if _, err := asn1.Unmarshal(resp.TBSResponseData, &tbs); err != nil {
+		return nil, err
+	}
+	var single encodedTimesSingleResponse
+	if _, err := asn1.Unmarshal(resp.RawSingleResponse, &single); err != nil {
+		return nil, err
+	}
+
+	var times ResponseTimes
+	var err error
+	if times.ProducedAt, err = decodeEncodedTime(tbs.ProducedAt.FullBytes); err != nil {
+		return nil, err
+	}
+	if times.ThisUpdate, err = decodeEncodedTime(single.ThisUpdate.FullBytes); err != nil {
+		return nil, err
+	}
+	if len(single.NextUpdate.FullBytes) > 0 {
+		// The explicit tag is kept by Unmarshal.
+		if times.NextUpdate, err = decodeEncodedTime(single.NextUpdate.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	if single.Status.Class == asn1.ClassContextSpecific && single.Status.Tag == 1 {
+		// The first field of the RevokedInfo is the revocationTime.
+		if times.RevokedAt, err = decodeEncodedTime(single.Status.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	return &times, nil
+}
+
+// decodeEncodedTime decodes the first GeneralizedTime in der.
+func decodeEncodedTime(der []byte) (EncodedTime, error) {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &raw); err != nil {
+		return EncodedTime{}, err
+	}
+	if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagGeneralizedTime {
+		return EncodedTime{}, ParseError("invalid GeneralizedTime")
+	}
+	var t time.Time
+	if _, err := asn1.UnmarshalWithParams(raw.FullBytes, &t, "generalized"); err != nil {
+		return EncodedTime{}, err
+	}
+	return EncodedTime{Raw: raw.Bytes, Time: t}, nil
+}
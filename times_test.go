@@ -0,0 +1,84 @@
+package ocsp
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestEncodedTimes(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	times, err := resp.EncodedTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tt := range map[string]struct {
+		got  EncodedTime
+		raw  string
+		want time.Time
+	}{
+		"ProducedAt": {times.ProducedAt, "20211107142553Z", resp.ProducedAt},
+		"ThisUpdate": {times.ThisUpdate, "20211107142551Z", resp.ThisUpdate},
+		"NextUpdate": {times.NextUpdate, "20211114132550Z", resp.NextUpdate},
+	} {
+		if string(tt.got.Raw) != tt.raw || !tt.got.Time.Equal(tt.want) || !tt.got.IsCanonical() || tt.got.HasFractionalSeconds() {
+			t.Errorf("%s: got %q (%v), want %q (%v)", name, tt.got.Raw, tt.got.Time, tt.raw, tt.want)
+		}
+	}
+	if times.RevokedAt.Raw != nil || !times.RevokedAt.IsCanonical() {
+		t.Errorf("RevokedAt: got %q for a good response", times.RevokedAt.Raw)
+	}
+
+	responder, key := newTestResponder(t, "Responder")
+	now := time.Now().UTC().Truncate(time.Second)
+	der, err = CreateResponse(responder, responder, Response{
+		Status:       Revoked,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   now,
+		RevokedAt:    now.Add(-time.Hour),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = ParseResponse(der, nil); err != nil {
+		t.Fatal(err)
+	}
+	if times, err = resp.EncodedTimes(); err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(-time.Hour).Format("20060102150405Z"); string(times.RevokedAt.Raw) != want || !times.RevokedAt.Time.Equal(resp.RevokedAt) {
+		t.Errorf("RevokedAt: got %q, want %q", times.RevokedAt.Raw, want)
+	}
+	if times.NextUpdate.Raw != nil {
+		t.Errorf("NextUpdate: got %q, want none", times.NextUpdate.Raw)
+	}
+
+	for _, tt := range []struct {
+		raw        string
+		fractional bool
+	}{
+		{"20240101000000.5Z", true},
+		{"20240101010000+0100", false},
+	} {
+		der := newTestCanonicalResponse(t, func(tbs *testResponseData) {
+			tbs.Responses[0].ThisUpdate.Bytes = []byte(tt.raw)
+		})
+		resp, err := ParseResponse(der, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		times, err := resp.EncodedTimes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := times.ThisUpdate
+		if string(got.Raw) != tt.raw || !got.Time.Equal(resp.ThisUpdate) || got.IsCanonical() || got.HasFractionalSeconds() != tt.fractional {
+			t.Errorf("%s: got %q, canonical %v, fractional %v", tt.raw, got.Raw, got.IsCanonical(), got.HasFractionalSeconds())
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ResponseDigest summarizes a certificate status in a signed OCSP response.
+// Digests are published to an append-only log by a TransparencyExporter, so
+// external monitors can detect back-dated or contradictory responses without
+// access to the responses themselves.
+type ResponseDigest struct {
+	// CertID identifies the certificate.
+	CertID CertID
+	// Status is one of {Good, Revoked, Unknown}.
+	Status int
+	// ProducedAt is the time the response was signed, as claimed by the
+	// responder.
+	ProducedAt time.Time
+	// ThisUpdate, NextUpdate and RevokedAt are the times of the status.
+	ThisUpdate, NextUpdate, RevokedAt time.Time
+	// SignatureHash is the SHA-256 hash of the signature of the response.
+	SignatureHash []byte
+}
+
+// NewResponseDigests returns a digest of each certificate status in the OCSP
+// response der. It does not verify the signature of the response.
+func NewResponseDigests(der []byte) ([]ResponseDigest, error) {
+	basicResp, tbs, err := parseRawBasicResponse(der)
+	if err != nil {
+		return nil, err
+	}
+	sigHash := sha256.Sum256(basicResp.Signature.RightAlign())
+
+	var digests []ResponseDigest
+	for rest := tbs.Responses.Bytes; len(rest) > 0; {
+		var r singleResponse
+		if rest, err = asn1.Unmarshal(rest, &r); err != nil {
+			return nil, err
+		}
+		single := newSingleResponse(&r)
+		digests = append(digests, ResponseDigest{
+			CertID:        single.CertID,
+			Status:        single.Status,
+			ProducedAt:    tbs.ProducedAt,
+			ThisUpdate:    single.ThisUpdate,
+			NextUpdate:    single.NextUpdate,
+			RevokedAt:     single.RevokedAt,
+			SignatureHash: sigHash[:],
+		})
+	}
+	return digests, nil
+}
+
+// Contradicts reports whether d and other are for the same certificate and
+// make claims that cannot both be true: a Good status with a ThisUpdate time
+// after the RevokedAt time of a Revoked status, or two Revoked statuses with
+// different RevokedAt times. A certificate revoked after a Good status is not
+// a contradiction. As digests do not include the revocation reason, a lifted
+// certificate hold is reported as a contradiction.
+//
+// Certificates are identified by their serial number and issuer. CertIDs
+// using different hash algorithms can only be compared with issuer, and are
+// considered for other certificates if it is nil.
+func (d *ResponseDigest) Contradicts(other *ResponseDigest, issuer *x509.Certificate) bool {
+	if !sameCertificate(&d.CertID, &other.CertID, issuer) {
+		return false
+	}
+	switch {
+	case d.Status == Revoked && other.Status == Revoked:
+		return !d.RevokedAt.Equal(other.RevokedAt)
+	case d.Status == Revoked && other.Status == Good:
+		return other.ThisUpdate.After(d.RevokedAt)
+	case d.Status == Good && other.Status == Revoked:
+		return d.ThisUpdate.After(other.RevokedAt)
+	default:
+		return false
+	}
+}
+
+// sameCertificate reports whether a and b have the same serial number and
+// issuer. If they use different hash algorithms, their issuer hashes are
+// compared with the ones of issuer.
+func sameCertificate(a, b *CertID, issuer *x509.Certificate) bool {
+	if a.SerialNumber == nil || b.SerialNumber == nil || a.SerialNumber.Cmp(b.SerialNumber) != 0 {
+		return false
+	}
+	if a.HashAlgorithm == b.HashAlgorithm {
+		return bytes.Equal(a.IssuerNameHash, b.IssuerNameHash) && bytes.Equal(a.IssuerKeyHash, b.IssuerKeyHash)
+	}
+	return issuer != nil && issuedBy(a, issuer) && issuedBy(b, issuer)
+}
+
+// issuedBy reports whether the issuer hashes of id are the ones of issuer.
+func issuedBy(id *CertID, issuer *x509.Certificate) bool {
+	nameHash, keyHash, err := issuerHashes(issuer, id.HashAlgorithm)
+	return err == nil && bytes.Equal(id.IssuerNameHash, nameHash) && bytes.Equal(id.IssuerKeyHash, keyHash)
+}
+
+// digestLine is the JSON encoding of a ResponseDigest.
+type digestLine struct {
+	HashAlgorithm  string     `json:"hashAlgorithm"`
+	IssuerNameHash string     `json:"issuerNameHash"`
+	IssuerKeyHash  string     `json:"issuerKeyHash"`
+	SerialNumber   string     `json:"serialNumber"`
+	Status         int        `json:"status"`
+	ProducedAt     time.Time  `json:"producedAt"`
+	ThisUpdate     time.Time  `json:"thisUpdate"`
+	NextUpdate     *time.Time `json:"nextUpdate,omitempty"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+	SignatureHash  string     `json:"signatureHash"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// MarshalJSON returns the JSON encoding of d, with hex-encoded hashes and
+// serial number, and the hash algorithm as an object identifier.
+func (d ResponseDigest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(digestLine{
+		HashAlgorithm:  getOIDFromHashAlgorithm(d.CertID.HashAlgorithm).String(),
+		IssuerNameHash: hex.EncodeToString(d.CertID.IssuerNameHash),
+		IssuerKeyHash:  hex.EncodeToString(d.CertID.IssuerKeyHash),
+		SerialNumber:   serialHex(d.CertID.SerialNumber),
+		Status:         d.Status,
+		ProducedAt:     d.ProducedAt,
+		ThisUpdate:     d.ThisUpdate,
+		NextUpdate:     optionalTime(d.NextUpdate),
+		RevokedAt:      optionalTime(d.RevokedAt),
+		SignatureHash:  hex.EncodeToString(d.SignatureHash),
+	})
+}
+
+// UnmarshalJSON decodes a digest encoded by MarshalJSON.
+func (d *ResponseDigest) UnmarshalJSON(b []byte) error {
+	var line digestLine
+	if err := json.Unmarshal(b, &line); err != nil {
+		return err
+	}
+	oid, err := parseOID(line.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	var ret ResponseDigest
+	ret.CertID.HashAlgorithm = getHashAlgorithmFromOID(oid)
+	if ret.CertID.IssuerNameHash, err = hex.DecodeString(line.IssuerNameHash); err != nil {
+		return err
+	}
+	if ret.CertID.IssuerKeyHash, err = hex.DecodeString(line.IssuerKeyHash); err != nil {
+		return err
+	}
+	var ok bool
+	if ret.CertID.SerialNumber, ok = new(big.Int).SetString(line.SerialNumber, 16); !ok {
+		return fmt.Errorf("ocsp: bad serial number %q in digest", line.SerialNumber)
+	}
+	if ret.SignatureHash, err = hex.DecodeString(line.SignatureHash); err != nil {
+		return err
+	}
+	ret.Status = line.Status
+	ret.ProducedAt = line.ProducedAt
+	ret.ThisUpdate = line.ThisUpdate
+	if line.NextUpdate != nil {
+		ret.NextUpdate = *line.NextUpdate
+	}
+	if line.RevokedAt != nil {
+		ret.RevokedAt = *line.RevokedAt
+	}
+	*d = ret
+	return nil
+}
+
+// LogSink is the interface of the append-only logs receiving the digests
+// published by a TransparencyExporter.
+type LogSink interface {
+	// Append adds entry at the end of the log.
+	Append(ctx context.Context, entry []byte) error
+}
+
+// TransparencyExporter is an Archive publishing the digests of the signed
+// responses to a LogSink, one JSON-encoded ResponseDigest per entry, which
+// monitors can decode with json.Unmarshal. It can be used as the Archive of
+// SignBatch and StatusUpdater, together with other archives using
+// MultiArchive.
+type TransparencyExporter struct {
+	sink LogSink
+}
+
+// NewTransparencyExporter returns a TransparencyExporter publishing to sink.
+func NewTransparencyExporter(sink LogSink) *TransparencyExporter {
+	return &TransparencyExporter{sink: sink}
+}
+
+// Archive appends the digests of the statuses in rec.Response to the log.
+func (e *TransparencyExporter) Archive(ctx context.Context, rec ArchiveRecord) error {
+	digests, err := NewResponseDigests(rec.Response)
+	if err != nil {
+		return err
+	}
+	for _, d := range digests {
+		entry, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if err := e.sink.Append(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
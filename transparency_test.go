@@ -0,0 +1,155 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLog is a LogSink keeping the entries in memory.
+type memoryLog struct {
+	mu      sync.Mutex
+	entries [][]byte
+}
+
+func (l *memoryLog) Append(_ context.Context, entry []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func TestNewResponseDigests(t *testing.T) {
+	der, _ := hex.DecodeString(ocspResponseHex)
+	resp, err := ParseResponse(der, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests, err := NewResponseDigests(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 1 {
+		t.Fatalf("got %d digests, want 1", len(digests))
+	}
+	d := digests[0]
+	sigHash := sha256.Sum256(resp.Signature)
+	if d.CertID.Key() != resp.Key() || d.Status != resp.Status ||
+		!d.ProducedAt.Equal(resp.ProducedAt) || !d.ThisUpdate.Equal(resp.ThisUpdate) ||
+		!d.NextUpdate.Equal(resp.NextUpdate) || !bytes.Equal(d.SignatureHash, sigHash[:]) {
+		t.Errorf("NewResponseDigests: got %+v", d)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ResponseDigest
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Errorf("JSON round trip: got %+v, want %+v", got, d)
+	}
+
+	multi, err := createMultiResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digests, err := NewResponseDigests(multi); err != nil || len(digests) != 5 {
+		t.Errorf("NewResponseDigests: got %d digests, %v, want 5", len(digests), err)
+	}
+	errResp, _ := hex.DecodeString(errorResponseHex)
+	if _, err := NewResponseDigests(errResp); err == nil {
+		t.Error("NewResponseDigests didn't fail with an error response")
+	}
+}
+
+func TestResponseDigestContradicts(t *testing.T) {
+	issuer, _ := newTestResponder(t, "issuer")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	digest := func(hash crypto.Hash, serial int64, status int, thisUpdate, revokedAt time.Duration) *ResponseDigest {
+		d := &ResponseDigest{
+			CertID:     *testCertID(t, issuer, hash, serial),
+			Status:     status,
+			ThisUpdate: start.Add(thisUpdate),
+			NextUpdate: start.Add(thisUpdate + 7*24*time.Hour),
+		}
+		if status == Revoked {
+			d.RevokedAt = start.Add(revokedAt)
+		}
+		return d
+	}
+	day := 24 * time.Hour
+	good := digest(crypto.SHA1, 1, Good, 0, 0)
+	tests := []struct {
+		name   string
+		a, b   *ResponseDigest
+		issuer *x509.Certificate
+		want   bool
+	}{
+		{"same status", good, digest(crypto.SHA1, 1, Good, day, 0), nil, false},
+		{"revoked later", good, digest(crypto.SHA1, 1, Revoked, day, day/2), nil, false},
+		{"good after revocation", digest(crypto.SHA1, 1, Good, 2*day, 0), digest(crypto.SHA1, 1, Revoked, day, day/2), nil, true},
+		{"back-dated revocation", good, digest(crypto.SHA1, 1, Revoked, day, -day), nil, true},
+		{"different revocation times", digest(crypto.SHA1, 1, Revoked, 0, -day), digest(crypto.SHA1, 1, Revoked, day, -2*day), nil, true},
+		{"same revocation time", digest(crypto.SHA1, 1, Revoked, 0, -day), digest(crypto.SHA1, 1, Revoked, day, -day), nil, false},
+		{"unknown", good, digest(crypto.SHA1, 1, Unknown, day, 0), nil, false},
+		{"other certificate", good, digest(crypto.SHA1, 2, Revoked, day, -day), nil, false},
+		{"other hash with issuer", good, digest(crypto.SHA256, 1, Revoked, day, -day), issuer, true},
+		{"other hash without issuer", good, digest(crypto.SHA256, 1, Revoked, day, -day), nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Contradicts(tt.b, tt.issuer); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		if got := tt.b.Contradicts(tt.a, tt.issuer); got != tt.want {
+			t.Errorf("%s reversed: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransparencyExporter(t *testing.T) {
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, key := newTestResponder(t, "responder")
+	templates := []Response{
+		{Status: Good, SerialNumber: big.NewInt(1), ThisUpdate: time.Now().Truncate(time.Second)},
+		{Status: Unknown, SerialNumber: big.NewInt(2), ThisUpdate: time.Now().Truncate(time.Second)},
+	}
+
+	log := &memoryLog{}
+	archive := &memoryArchive{}
+	results := SignBatch(context.Background(), issuer, responder, templates, key, &BatchOptions{
+		Concurrency: 1,
+		Archive:     MultiArchive(NewTransparencyExporter(log), archive),
+	})
+	if len(log.entries) != 2 || len(archive.records) != 2 {
+		t.Fatalf("got %d log entries and %d records, want 2", len(log.entries), len(archive.records))
+	}
+	for i, entry := range log.entries {
+		var d ResponseDigest
+		if err := json.Unmarshal(entry, &d); err != nil {
+			t.Fatal(err)
+		}
+		want, err := NewResponseDigests(results[i].Response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(d, want[0]) {
+			t.Errorf("entry %d: got %+v, want %+v", i, d, want[0])
+		}
+	}
+}
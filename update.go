@@ -0,0 +1,176 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// StatusUpdaterOptions contains options for NewStatusUpdater.
+type StatusUpdaterOptions struct {
+	// Hashes are the CertID hash algorithms responses are cached for. A
+	// response is signed and cached for each one. If empty, only SHA-1 is
+	// used.
+	Hashes []crypto.Hash
+
+	// Validity sets the validity interval of the signed responses. If
+	// Validity.Validity is zero, the responses have no NextUpdate.
+	Validity ValidityPolicy
+
+	// Archive optionally receives every signed response. Responses that
+	// cannot be archived are not cached.
+	Archive Archive
+}
+
+func (opts *StatusUpdaterOptions) hashes() []crypto.Hash {
+	if opts == nil || len(opts.Hashes) == 0 {
+		return []crypto.Hash{crypto.SHA1}
+	}
+	return opts.Hashes
+}
+
+func (opts *StatusUpdaterOptions) validity() ValidityPolicy {
+	if opts == nil {
+		return ValidityPolicy{}
+	}
+	return opts.Validity
+}
+
+func (opts *StatusUpdaterOptions) archive() Archive {
+	if opts == nil {
+		return nil
+	}
+	return opts.Archive
+}
+
+// StatusUpdater propagates revocation status changes to a Cache. Each change
+// signs new responses right away and replaces the cached ones, so it is
+// served within seconds instead of at the next batch run.
+//
+// To also override the responses of a pre-signed Store, use a LayeredCache
+// with the Store as its last layer: the new responses are stored in the
+// layers before it, and they are found first.
+type StatusUpdater struct {
+	cache  Cache
+	issuer *x509.Certificate
+	signer *RotatingSigner
+	opts   *StatusUpdaterOptions
+	now    func() time.Time
+}
+
+// NewStatusUpdater returns a StatusUpdater signing responses for the
+// certificates of issuer with signer, and storing them in cache. If opts is
+// nil then sensible defaults are used.
+func NewStatusUpdater(cache Cache, issuer *x509.Certificate, signer *RotatingSigner, opts *StatusUpdaterOptions) (*StatusUpdater, error) {
+	if cache == nil || issuer == nil || signer == nil {
+		return nil, errors.New("ocsp: cache, issuer and signer are required")
+	}
+	for _, hash := range opts.hashes() {
+		if _, ok := getHashAlgorithmIdentifier(hash); !ok {
+			return nil, fmt.Errorf("ocsp: unsupported CertID hash algorithm %v", hash)
+		}
+	}
+	return &StatusUpdater{
+		cache:  cache,
+		issuer: issuer,
+		signer: signer,
+		opts:   opts,
+		now:    time.Now,
+	}, nil
+}
+
+// Revoke marks the certificate with the given serial number as revoked at
+// revokedAt for the given reason.
+func (u *StatusUpdater) Revoke(ctx context.Context, serial *big.Int, revokedAt time.Time, reason int) error {
+	return u.update(ctx, Response{
+		Status:           Revoked,
+		SerialNumber:     serial,
+		RevokedAt:        revokedAt,
+		RevocationReason: reason,
+	})
+}
+
+// Unrevoke marks the certificate with the given serial number as good, for
+// example, after lifting a certificate hold.
+func (u *StatusUpdater) Unrevoke(ctx context.Context, serial *big.Int) error {
+	return u.update(ctx, Response{
+		Status:       Good,
+		SerialNumber: serial,
+	})
+}
+
+// SetUnknown marks the status of the certificate with the given serial number
+// as unknown.
+func (u *StatusUpdater) SetUnknown(ctx context.Context, serial *big.Int) error {
+	return u.update(ctx, Response{
+		Status:       Unknown,
+		SerialNumber: serial,
+	})
+}
+
+// update signs and caches a response based on template for each CertID hash
+// algorithm. Invalid templates are rejected without touching the cache. If a
+// valid response cannot be signed or archived, the cached one is removed, so
+// the previous status is not served anymore.
+func (u *StatusUpdater) update(ctx context.Context, template Response) error {
+	if template.SerialNumber == nil {
+		return errors.New("ocsp: serial number is required")
+	}
+	now := u.now()
+	if p := u.opts.validity(); p.Validity > 0 {
+		if err := p.Apply(&template, now); err != nil {
+			return err
+		}
+	} else {
+		template.ThisUpdate = now.Truncate(time.Second).UTC()
+	}
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, hash := range u.opts.hashes() {
+		key, err := u.key(hash, template.SerialNumber)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		template.IssuerHash = hash
+		der, err := u.signer.CreateResponse(ctx, u.issuer, template)
+		if err == nil {
+			err = archive(ctx, u.opts.archive(), der, now)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if err := u.cache.Delete(ctx, key); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		// The new status replaces the cached one even if it is not fresher,
+		// for example, if it changes twice in the same second.
+		if err := u.cache.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entry := CacheEntry{Response: der, ThisUpdate: template.ThisUpdate, NextUpdate: template.NextUpdate}
+		if err := u.cache.Put(ctx, key, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// key returns the CertIDKey of the certificate of the issuer with the given
+// serial number, using hash for the issuer hashes.
+func (u *StatusUpdater) key(hash crypto.Hash, serial *big.Int) (CertIDKey, error) {
+	nameHash, keyHash, err := issuerHashes(u.issuer, hash)
+	if err != nil {
+		return CertIDKey{}, err
+	}
+	return certIDKey(hash, nameHash, keyHash, serial), nil
+}
@@ -0,0 +1,119 @@
+package ocsp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestStatusUpdater(t *testing.T) {
+	ctx := context.Background()
+	issuerCert, _ := hex.DecodeString(issuerCertHex)
+	issuer, err := x509.ParseCertificate(issuerCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, key := newTestResponder(t, "responder")
+	signer, err := NewRotatingSigner(cert, key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStatusUpdater(nil, issuer, signer, nil); err == nil {
+		t.Error("NewStatusUpdater didn't fail without a cache")
+	}
+
+	cache := &mapCache{items: map[CertIDKey]CacheEntry{}}
+	archive := &memoryArchive{}
+	u, err := NewStatusUpdater(cache, issuer, signer, &StatusUpdaterOptions{
+		Hashes:   []crypto.Hash{crypto.SHA1, crypto.SHA256},
+		Validity: ValidityPolicy{Validity: time.Hour},
+		Archive:  archive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	u.now = func() time.Time { return now }
+
+	serial := big.NewInt(42)
+	check := func(status int) {
+		t.Helper()
+		if len(cache.items) != 2 {
+			t.Fatalf("got %d cached responses, want 2", len(cache.items))
+		}
+		for k, entry := range cache.items {
+			resp, err := ParseResponse(entry.Response, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Key() != k {
+				t.Errorf("response for %v cached with the wrong key", resp.IssuerHash)
+			}
+			if resp.Status != status || resp.SerialNumber.Cmp(serial) != 0 {
+				t.Errorf("got status %d for serial %v, want %d for %v", resp.Status, resp.SerialNumber, status, serial)
+			}
+			if !entry.ThisUpdate.Equal(now) || !entry.NextUpdate.Equal(now.Add(time.Hour)) {
+				t.Errorf("got validity %v to %v", entry.ThisUpdate, entry.NextUpdate)
+			}
+		}
+	}
+
+	if err := u.Revoke(ctx, serial, now.Add(-time.Minute), KeyCompromise); err != nil {
+		t.Fatal(err)
+	}
+	check(Revoked)
+	if err := u.Unrevoke(ctx, serial); err != nil {
+		t.Fatal(err)
+	}
+	check(Good)
+	if err := u.SetUnknown(ctx, serial); err != nil {
+		t.Fatal(err)
+	}
+	check(Unknown)
+
+	// Invalid statuses do not evict the cached responses.
+	if err := u.Revoke(ctx, serial, now, 7); err == nil {
+		t.Error("Revoke didn't fail with an invalid reason")
+	}
+	check(Unknown)
+
+	// Responses that cannot be archived are removed from the cache.
+	archive.fail = map[int64]bool{42: true}
+	if err := u.Unrevoke(ctx, serial); err == nil {
+		t.Error("Unrevoke didn't fail with a failing archive")
+	}
+	if len(cache.items) != 0 {
+		t.Errorf("got %d cached responses after a failed update, want 0", len(cache.items))
+	}
+	if err := u.Unrevoke(ctx, nil); err == nil {
+		t.Error("Unrevoke didn't fail without a serial number")
+	}
+
+	// Status changes replace cached responses that are equally fresh.
+	sharded := NewShardedCache(1<<20, 1)
+	if u, err = NewStatusUpdater(sharded, issuer, signer, nil); err != nil {
+		t.Fatal(err)
+	}
+	u.now = func() time.Time { return now }
+	if err := u.Revoke(ctx, serial, now, KeyCompromise); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Unrevoke(ctx, serial); err != nil {
+		t.Fatal(err)
+	}
+	cacheKey, err := u.key(crypto.SHA1, serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := sharded.Get(ctx, cacheKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := ParseResponse(entry.Response, nil); err != nil || resp.Status != Good {
+		t.Errorf("got %v, %v after a status change in the same second", resp, err)
+	}
+}
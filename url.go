@@ -0,0 +1,57 @@
+package ocsp
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// EncodeRequestURL returns the URL used to send the DER-encoded OCSP request
+// to the responder at responderURL with the GET method, as described in RFC
+// 6960, Appendix A.1: the base64 encoding of the request, URL-encoded and
+// appended to the responder URL as a path segment.
+//
+// The '+', '/' and '=' characters are always percent-encoded, as '+' is
+// commonly decoded as a space and '/' would split the path.
+//
+// RFC 5019 recommends using GET only for requests of up to 255 bytes once
+// encoded, and POST for larger requests.
+func EncodeRequestURL(responderURL string, reqDER []byte) string {
+	encoded := url.QueryEscape(base64.StdEncoding.EncodeToString(reqDER))
+	if strings.HasSuffix(responderURL, "/") {
+		return responderURL + encoded
+	}
+	return responderURL + "/" + encoded
+}
+
+// DecodeRequestPath returns the DER-encoded OCSP request in the path of a GET
+// request, relative to the responder URL and with or without a leading slash.
+//
+// Besides the encoding described in RFC 6960, Appendix A.1, it accepts the
+// variants sent by clients in the wild: unescaped '+', '/' and '=' characters,
+// '+' characters decoded as spaces by proxies, missing padding, and the URL
+// safe base64 alphabet.
+func DecodeRequestPath(path string) ([]byte, error) {
+	unescaped, err := url.PathUnescape(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	unescaped = strings.ReplaceAll(unescaped, " ", "+")
+	if unescaped == "" {
+		return nil, errors.New("ocsp: empty request path")
+	}
+
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(unescaped, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(unescaped, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	der, err := encoding.Strict().DecodeString(unescaped)
+	if err != nil {
+		return nil, errors.New("ocsp: invalid base64 request path: " + err.Error())
+	}
+	return der, nil
+}
@@ -0,0 +1,81 @@
+package ocsp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEncodeRequestURL(t *testing.T) {
+	der := []byte{0xfb, 0xff, 0xfe, 0x01}
+	tests := []struct {
+		responderURL string
+		want         string
+	}{
+		{"http://ocsp.example.com", "http://ocsp.example.com/%2B%2F%2F%2BAQ%3D%3D"},
+		{"http://ocsp.example.com/", "http://ocsp.example.com/%2B%2F%2F%2BAQ%3D%3D"},
+		{"http://example.com/ocsp", "http://example.com/ocsp/%2B%2F%2F%2BAQ%3D%3D"},
+	}
+	for _, tc := range tests {
+		got := EncodeRequestURL(tc.responderURL, der)
+		if got != tc.want {
+			t.Errorf("EncodeRequestURL(%q): got %q, want %q", tc.responderURL, got, tc.want)
+		}
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeRequestPath(u.EscapedPath()[strings.LastIndex(u.EscapedPath(), "/")+1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, der) {
+			t.Errorf("DecodeRequestPath: got %x, want %x", decoded, der)
+		}
+	}
+}
+
+func TestDecodeRequestPath(t *testing.T) {
+	der, _ := hex.DecodeString(ocspRequestHex)
+	std := base64.StdEncoding.EncodeToString(der)
+	if !strings.ContainsAny(std, "+/=") {
+		t.Fatal("test request does not exercise the base64 special characters")
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"escaped", url.PathEscape(std)},
+		{"leading slash", "/" + url.PathEscape(std)},
+		{"unescaped", std},
+		{"spaces", strings.ReplaceAll(std, "+", " ")},
+		{"escaped spaces", url.PathEscape(strings.ReplaceAll(std, "+", " "))},
+		{"no padding", strings.TrimRight(std, "=")},
+		{"url encoding", base64.URLEncoding.EncodeToString(der)},
+		{"raw url encoding", base64.RawURLEncoding.EncodeToString(der)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeRequestPath(tc.path)
+			if err != nil {
+				t.Fatalf("DecodeRequestPath: %v", err)
+			}
+			if !bytes.Equal(got, der) {
+				t.Errorf("DecodeRequestPath: got %x, want %x", got, der)
+			}
+			if _, err := ParseRequest(got); err != nil {
+				t.Errorf("ParseRequest: %v", err)
+			}
+		})
+	}
+
+	for _, path := range []string{"", "/", "%zz", "not*base64", "AQ=A", "+-AB"} {
+		if _, err := DecodeRequestPath(path); err == nil {
+			t.Errorf("DecodeRequestPath(%q) didn't fail", path)
+		}
+	}
+}
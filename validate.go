@@ -0,0 +1,84 @@
+package ocsp
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+)
+
+// checkCertIDHashes checks that the issuer hashes have the size of the digests
+// of hash.
+func checkCertIDHashes(hash crypto.Hash, nameHash, keyHash []byte) error {
+	if _, ok := getHashAlgorithmIdentifier(hash); !ok {
+		return fmt.Errorf("ocsp: unsupported issuer hash algorithm %v", hash)
+	}
+	h, ok := newHash(hash)
+	if !ok {
+		return nil
+	}
+	if len(nameHash) != h.Size() {
+		return fmt.Errorf("ocsp: issuer name hash is %d bytes, %v digests are %d bytes", len(nameHash), hash, h.Size())
+	}
+	if len(keyHash) != h.Size() {
+		return fmt.Errorf("ocsp: issuer key hash is %d bytes, %v digests are %d bytes", len(keyHash), hash, h.Size())
+	}
+	return nil
+}
+
+// Validate checks that the request is structurally valid: it must have a
+// serial number, and issuer hashes matching a supported hash algorithm.
+// Marshal calls Validate before encoding the request.
+func (req *Request) Validate() error {
+	if req.SerialNumber == nil {
+		return errors.New("ocsp: request serial number is missing")
+	}
+	return checkCertIDHashes(req.HashAlgorithm, req.IssuerNameHash, req.IssuerKeyHash)
+}
+
+// validRevocationReason reports whether reason is one of the CRLReason values
+// defined in RFC 5280.
+func validRevocationReason(reason int) bool {
+	return reason >= Unspecified && reason <= AACompromise && reason != 7
+}
+
+// Validate checks that a response template for CreateResponse is structurally
+// valid: it must have a serial number, a known status, a ThisUpdate time, and
+// a NextUpdate time, if set, not before ThisUpdate. Revoked responses must
+// have a RevokedAt time and a known RevocationReason. If IssuerNameHash and
+// IssuerKeyHash are set, they must match IssuerHash, or SHA-1 if not set.
+// CreateResponse calls Validate before signing the response.
+func (resp *Response) Validate() error {
+	if resp.SerialNumber == nil {
+		return errors.New("ocsp: response serial number is missing")
+	}
+	switch resp.Status {
+	case Good, Unknown:
+	case Revoked:
+		if resp.RevokedAt.IsZero() {
+			return errors.New("ocsp: revoked response is missing RevokedAt")
+		}
+		if !validRevocationReason(resp.RevocationReason) {
+			return fmt.Errorf("ocsp: unknown revocation reason %d", resp.RevocationReason)
+		}
+	default:
+		return fmt.Errorf("ocsp: unknown response status %d", resp.Status)
+	}
+	if resp.ThisUpdate.IsZero() {
+		return errors.New("ocsp: response ThisUpdate is missing")
+	}
+	if resp.HasNextUpdate() && resp.NextUpdate.Before(resp.ThisUpdate) {
+		return errors.New("ocsp: response NextUpdate is before ThisUpdate")
+	}
+
+	hash := resp.IssuerHash
+	if hash == 0 {
+		hash = crypto.SHA1
+	}
+	if len(resp.IssuerNameHash) > 0 && len(resp.IssuerKeyHash) > 0 {
+		return checkCertIDHashes(hash, resp.IssuerNameHash, resp.IssuerKeyHash)
+	}
+	if _, ok := getHashAlgorithmIdentifier(hash); !ok {
+		return fmt.Errorf("ocsp: unsupported issuer hash algorithm %v", hash)
+	}
+	return nil
+}
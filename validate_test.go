@@ -0,0 +1,106 @@
+package ocsp
+
+import (
+	"crypto"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestValidate(t *testing.T) {
+	valid := Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: make([]byte, 20),
+		IssuerKeyHash:  make([]byte, 20),
+		SerialNumber:   big.NewInt(1),
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+		errMsg string
+	}{
+		{"nil serial", func(r *Request) { r.SerialNumber = nil }, "serial number is missing"},
+		{"unknown hash", func(r *Request) { r.HashAlgorithm = crypto.MD5 }, "unsupported issuer hash algorithm"},
+		{"name hash length", func(r *Request) { r.HashAlgorithm = crypto.SHA256 }, "issuer name hash is 20 bytes"},
+		{"key hash length", func(r *Request) { r.IssuerKeyHash = r.IssuerKeyHash[:19] }, "issuer key hash is 19 bytes"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.modify(&req)
+			if err := req.Validate(); err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("Validate: got %v, want an error containing %q", err, tc.errMsg)
+			}
+			if _, err := req.Marshal(); err == nil {
+				t.Error("Marshal: expected an error")
+			}
+		})
+	}
+}
+
+func TestResponseValidate(t *testing.T) {
+	thisUpdate := time.Now().Truncate(time.Second)
+	valid := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   thisUpdate,
+		NextUpdate:   thisUpdate.Add(time.Hour),
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Response)
+		errMsg string
+	}{
+		{"nil serial", func(r *Response) { r.SerialNumber = nil }, "serial number is missing"},
+		{"unknown status", func(r *Response) { r.Status = ServerFailed }, "unknown response status 3"},
+		{"zero ThisUpdate", func(r *Response) { r.ThisUpdate = time.Time{} }, "ThisUpdate is missing"},
+		{"NextUpdate before ThisUpdate", func(r *Response) { r.NextUpdate = thisUpdate.Add(-time.Second) }, "NextUpdate is before ThisUpdate"},
+		{"missing RevokedAt", func(r *Response) { r.Status = Revoked }, "missing RevokedAt"},
+		{"unknown reason", func(r *Response) {
+			r.Status, r.RevokedAt, r.RevocationReason = Revoked, thisUpdate, 7
+		}, "unknown revocation reason 7"},
+		{"negative reason", func(r *Response) {
+			r.Status, r.RevokedAt, r.RevocationReason = Revoked, thisUpdate, -1
+		}, "unknown revocation reason -1"},
+		{"unknown hash", func(r *Response) { r.IssuerHash = crypto.MD5 }, "unsupported issuer hash algorithm"},
+		{"hash length", func(r *Response) {
+			r.IssuerHash, r.IssuerNameHash, r.IssuerKeyHash = crypto.SHA256, make([]byte, 20), make([]byte, 20)
+		}, "issuer name hash is 20 bytes"},
+		{"default hash length", func(r *Response) {
+			r.IssuerNameHash, r.IssuerKeyHash = make([]byte, 20), make([]byte, 32)
+		}, "issuer key hash is 32 bytes"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			template := valid
+			tc.modify(&template)
+			if err := template.Validate(); err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("Validate: got %v, want an error containing %q", err, tc.errMsg)
+			}
+		})
+	}
+
+	responder, key := newTestResponder(t, "Responder")
+	revoked := valid
+	revoked.Status, revoked.RevokedAt, revoked.RevocationReason = Revoked, thisUpdate, AACompromise
+	revoked.NextUpdate = time.Time{}
+	if err := revoked.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if _, err := CreateResponse(responder, responder, revoked, key); err != nil {
+		t.Errorf("CreateResponse: %v", err)
+	}
+	revoked.RevocationReason = 11
+	if _, err := CreateResponse(responder, responder, revoked, key); err == nil || !strings.Contains(err.Error(), "unknown revocation reason 11") {
+		t.Errorf("CreateResponse: got %v, want an unknown revocation reason error", err)
+	}
+}
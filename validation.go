@@ -0,0 +1,107 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ErrNotAllowed is matched by the errors returned when parsing a response
+// that is rejected by the configured ValidationOptions.
+var ErrNotAllowed = errors.New("ocsp: not allowed by validation options")
+
+// ValidationOptions restricts the algorithms and keys accepted when parsing a
+// response, so weakly signed responses are rejected with an error instead of
+// having to inspect them after parsing. The zero value accepts everything.
+type ValidationOptions struct {
+	// MinRSAKeySize is the minimum size in bits of the RSA keys signing the
+	// response and the embedded certificates. If zero, any size is accepted.
+	MinRSAKeySize int
+
+	// AllowedSignatureAlgorithms is the list of signature algorithms accepted
+	// for the response and the embedded certificates. If empty, all supported
+	// algorithms are accepted.
+	AllowedSignatureAlgorithms []x509.SignatureAlgorithm
+
+	// AllowedCertIDHashes is the list of hash algorithms accepted in the
+	// CertID of the response. If empty, all supported hashes are accepted.
+	AllowedCertIDHashes []crypto.Hash
+
+	// RequireNextUpdate rejects responses without a nextUpdate time, which
+	// RFC 6960 allows but some policies, like the Baseline Requirements, do
+	// not.
+	RequireNextUpdate bool
+}
+
+func (opts *ValidationOptions) allowsSignatureAlgorithm(algo x509.SignatureAlgorithm) bool {
+	if len(opts.AllowedSignatureAlgorithms) == 0 {
+		return true
+	}
+	for _, a := range opts.AllowedSignatureAlgorithms {
+		if a == algo {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts *ValidationOptions) allowsCertIDHash(hash crypto.Hash) bool {
+	if len(opts.AllowedCertIDHashes) == 0 {
+		return true
+	}
+	for _, h := range opts.AllowedCertIDHashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts *ValidationOptions) checkKey(pub crypto.PublicKey) error {
+	if pub, ok := pub.(*rsa.PublicKey); ok && pub.N.BitLen() < opts.MinRSAKeySize {
+		return fmt.Errorf("%w: %d-bit RSA key, minimum is %d", ErrNotAllowed, pub.N.BitLen(), opts.MinRSAKeySize)
+	}
+	return nil
+}
+
+// check checks the parsed response against the options. The response is
+// signed by the embedded certificate if there is one, which is signed by
+// issuer, or by issuer otherwise. A nil issuer is not checked.
+func (opts *ValidationOptions) check(resp *Response, issuer *x509.Certificate) error {
+	if !opts.allowsCertIDHash(resp.IssuerHash) {
+		return fmt.Errorf("%w: CertID hash algorithm %v", ErrNotAllowed, resp.IssuerHash)
+	}
+	if opts.RequireNextUpdate && !resp.HasNextUpdate() {
+		return fmt.Errorf("%w: response does not include nextUpdate", ErrNotAllowed)
+	}
+	if !opts.allowsSignatureAlgorithm(resp.SignatureAlgorithm) {
+		return fmt.Errorf("%w: signature algorithm %v", ErrNotAllowed, resp.SignatureAlgorithm)
+	}
+
+	signer := issuer
+	if resp.Certificate != nil {
+		signer = resp.Certificate
+		if !opts.allowsSignatureAlgorithm(resp.Certificate.SignatureAlgorithm) {
+			return fmt.Errorf("%w: responder certificate signature algorithm %v", ErrNotAllowed, resp.Certificate.SignatureAlgorithm)
+		}
+		if issuer != nil {
+			if err := opts.checkKey(issuer.PublicKey); err != nil {
+				return err
+			}
+		}
+		for _, cert := range resp.CertificateChain {
+			if !opts.allowsSignatureAlgorithm(cert.SignatureAlgorithm) {
+				return fmt.Errorf("%w: chain certificate signature algorithm %v", ErrNotAllowed, cert.SignatureAlgorithm)
+			}
+			if err := opts.checkKey(cert.PublicKey); err != nil {
+				return err
+			}
+		}
+	}
+	if signer != nil {
+		return opts.checkKey(signer.PublicKey)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseResponseValidationOptions(t *testing.T) {
+	responder, responderKey := newTestResponder(t, "Responder")
+	template := Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		IssuerHash:   crypto.SHA256,
+	}
+	ecdsaResp, err := CreateResponse(responder, responder, template, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withNextUpdate := template
+	withNextUpdate.NextUpdate = template.ThisUpdate.Add(time.Hour)
+	nextUpdateResp, err := CreateResponse(responder, responder, withNextUpdate, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.IssuerHash = crypto.SHA1
+	template.SignatureAlgorithm = x509.ECDSAWithSHA1
+	sha1Resp, err := CreateResponse(responder, responder, template, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Issuer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, rsaTemplate, rsaTemplate, rsaKey.Public(), rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaIssuer, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SignatureAlgorithm = x509.SHA256WithRSA
+	rsaResp, err := CreateResponse(rsaIssuer, rsaIssuer, template, rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		der    []byte
+		issuer *x509.Certificate
+		opts   ValidationOptions
+		ok     bool
+	}{
+		{"zero value", sha1Resp, responder, ValidationOptions{}, true},
+		{"allowed algorithm", ecdsaResp, responder, ValidationOptions{AllowedSignatureAlgorithms: []x509.SignatureAlgorithm{x509.ECDSAWithSHA256}}, true},
+		{"SHA-1 signature", sha1Resp, responder, ValidationOptions{AllowedSignatureAlgorithms: []x509.SignatureAlgorithm{x509.ECDSAWithSHA256}}, false},
+		{"SHA-1 signature without issuer", sha1Resp, nil, ValidationOptions{AllowedSignatureAlgorithms: []x509.SignatureAlgorithm{x509.ECDSAWithSHA256}}, false},
+		{"allowed hash", ecdsaResp, responder, ValidationOptions{AllowedCertIDHashes: []crypto.Hash{crypto.SHA256}}, true},
+		{"SHA-1 CertID", sha1Resp, responder, ValidationOptions{AllowedCertIDHashes: []crypto.Hash{crypto.SHA256}}, false},
+		{"RSA key size", rsaResp, rsaIssuer, ValidationOptions{MinRSAKeySize: 1024}, true},
+		{"small RSA key", rsaResp, rsaIssuer, ValidationOptions{MinRSAKeySize: 2048}, false},
+		{"ECDSA key with minimum RSA size", ecdsaResp, responder, ValidationOptions{MinRSAKeySize: 2048}, true},
+		{"next update", nextUpdateResp, responder, ValidationOptions{RequireNextUpdate: true}, true},
+		{"missing next update", ecdsaResp, responder, ValidationOptions{RequireNextUpdate: true}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseResponseWithOptions(tc.der, nil, tc.issuer, &ParseOptions{Validation: tc.opts})
+			if tc.ok && err != nil {
+				t.Errorf("ParseResponseWithOptions: %v", err)
+			} else if !tc.ok && !errors.Is(err, ErrNotAllowed) {
+				t.Errorf("ParseResponseWithOptions: got %v, want ErrNotAllowed", err)
+			}
+		})
+	}
+}
+
+func TestParseResponseValidationOptionsResponderCertificate(t *testing.T) {
+	issuer, issuerKey := newTestResponder(t, "Issuer")
+	responderKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Responder"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, issuer, responderKey.Public(), issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := CreateResponse(issuer, responder, Response{
+		Status:       Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Truncate(time.Second),
+		Certificate:  responder,
+	}, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseResponseWithOptions(resp, nil, issuer, &ParseOptions{Validation: ValidationOptions{
+		AllowedSignatureAlgorithms: []x509.SignatureAlgorithm{x509.SHA256WithRSA, x509.ECDSAWithSHA256},
+		MinRSAKeySize:              1024,
+	}}); err != nil {
+		t.Errorf("ParseResponseWithOptions: %v", err)
+	}
+	if _, err := ParseResponseWithOptions(resp, nil, issuer, &ParseOptions{Validation: ValidationOptions{
+		AllowedSignatureAlgorithms: []x509.SignatureAlgorithm{x509.SHA256WithRSA},
+	}}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("ParseResponseWithOptions with a disallowed certificate signature: got %v, want ErrNotAllowed", err)
+	}
+	if _, err := ParseResponseWithOptions(resp, nil, issuer, &ParseOptions{Validation: ValidationOptions{
+		MinRSAKeySize: 2048,
+	}}); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("ParseResponseWithOptions with a small responder key: got %v, want ErrNotAllowed", err)
+	}
+}
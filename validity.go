@@ -0,0 +1,76 @@
+package ocsp
+
+import (
+	"errors"
+	"time"
+)
+
+// ValidityPolicy computes the validity interval of the responses signed by a
+// responder, and decides when they must be signed again.
+type ValidityPolicy struct {
+	// Validity is the time between ThisUpdate and NextUpdate. It is required.
+	Validity time.Duration
+	// Backdate is subtracted from the signing time to set ThisUpdate, to
+	// tolerate clients with clocks behind the responder clock.
+	Backdate time.Duration
+	// RefreshFraction is the fraction of the validity interval after which a
+	// response must be signed again. If zero, 0.5 is used.
+	RefreshFraction float64
+	// StaleGrace is how long a response can still be served after its
+	// refresh time when it cannot be signed again, for example, during a
+	// signer outage. Responses are never served past their NextUpdate.
+	StaleGrace time.Duration
+}
+
+func (p ValidityPolicy) refreshFraction() float64 {
+	if p.RefreshFraction <= 0 || p.RefreshFraction > 1 {
+		return 0.5
+	}
+	return p.RefreshFraction
+}
+
+// Apply sets the ThisUpdate and NextUpdate fields of template for a response
+// signed at now. ThisUpdate is truncated to the second, as the times in a
+// response do not have fractional seconds.
+func (p ValidityPolicy) Apply(template *Response, now time.Time) error {
+	if p.Validity <= 0 {
+		return errors.New("ocsp: validity policy requires a positive validity")
+	}
+	template.ThisUpdate = now.Add(-p.Backdate).Truncate(time.Second).UTC()
+	template.NextUpdate = template.ThisUpdate.Add(p.Validity)
+	return nil
+}
+
+// RefreshAt returns the time after which resp must be signed again. Responses
+// without NextUpdate use the policy validity to compute it.
+func (p ValidityPolicy) RefreshAt(resp *Response) time.Time {
+	lifetime := p.Validity
+	if resp.HasNextUpdate() {
+		lifetime = resp.NextUpdate.Sub(resp.ThisUpdate)
+	}
+	return resp.ThisUpdate.Add(time.Duration(float64(lifetime) * p.refreshFraction()))
+}
+
+// NeedsRefresh reports whether resp has crossed the refresh threshold at now,
+// and it must be signed again.
+func (p ValidityPolicy) NeedsRefresh(resp *Response, now time.Time) bool {
+	return !now.Before(p.RefreshAt(resp))
+}
+
+// ServeStaleUntil returns the time until which resp can be served when it
+// cannot be signed again: StaleGrace after its refresh time, but never after
+// its NextUpdate.
+func (p ValidityPolicy) ServeStaleUntil(resp *Response) time.Time {
+	until := p.RefreshAt(resp).Add(p.StaleGrace)
+	if resp.HasNextUpdate() && resp.NextUpdate.Before(until) {
+		return resp.NextUpdate
+	}
+	return until
+}
+
+// CanServeStale reports whether resp, which needs a refresh that failed, can
+// still be served at now. Responders serving it are running in degraded mode,
+// and should report it in their metrics and logs.
+func (p ValidityPolicy) CanServeStale(resp *Response, now time.Time) bool {
+	return now.Before(p.ServeStaleUntil(resp))
+}
@@ -0,0 +1,105 @@
+package ocsp
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestValidityPolicy(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 500, time.UTC)
+	p := ValidityPolicy{Validity: 8 * time.Hour, Backdate: 5 * time.Minute}
+
+	var template Response
+	if err := p.Apply(&template, now); err != nil {
+		t.Fatal(err)
+	}
+	wantThisUpdate := time.Date(2026, 1, 1, 11, 55, 0, 0, time.UTC)
+	if !template.ThisUpdate.Equal(wantThisUpdate) {
+		t.Errorf("ThisUpdate: got %v, want %v", template.ThisUpdate, wantThisUpdate)
+	}
+	if want := wantThisUpdate.Add(8 * time.Hour); !template.NextUpdate.Equal(want) {
+		t.Errorf("NextUpdate: got %v, want %v", template.NextUpdate, want)
+	}
+
+	refreshAt := wantThisUpdate.Add(4 * time.Hour)
+	if got := p.RefreshAt(&template); !got.Equal(refreshAt) {
+		t.Errorf("RefreshAt: got %v, want %v", got, refreshAt)
+	}
+	if p.NeedsRefresh(&template, refreshAt.Add(-time.Second)) {
+		t.Error("NeedsRefresh: got true before the refresh threshold")
+	}
+	if !p.NeedsRefresh(&template, refreshAt) {
+		t.Error("NeedsRefresh: got false at the refresh threshold")
+	}
+
+	p.RefreshFraction = 0.75
+	if got, want := p.RefreshAt(&template), wantThisUpdate.Add(6*time.Hour); !got.Equal(want) {
+		t.Errorf("RefreshAt with 0.75: got %v, want %v", got, want)
+	}
+	noNextUpdate := &Response{ThisUpdate: wantThisUpdate}
+	if got, want := p.RefreshAt(noNextUpdate), wantThisUpdate.Add(6*time.Hour); !got.Equal(want) {
+		t.Errorf("RefreshAt without NextUpdate: got %v, want %v", got, want)
+	}
+
+	if err := (ValidityPolicy{}).Apply(&template, now); err == nil {
+		t.Error("Apply didn't fail without validity")
+	}
+}
+
+func TestValidityPolicyRoundTrip(t *testing.T) {
+	responder, key := newTestResponder(t, "Responder")
+	p := ValidityPolicy{Validity: time.Hour, Backdate: time.Minute}
+
+	template := Response{Status: Good, SerialNumber: big.NewInt(1)}
+	if err := p.Apply(&template, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateResponse(responder, responder, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ParseResponse(der, responder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.ThisUpdate.Equal(template.ThisUpdate) || !resp.NextUpdate.Equal(template.NextUpdate) {
+		t.Errorf("got %v - %v, want %v - %v", resp.ThisUpdate, resp.NextUpdate, template.ThisUpdate, template.NextUpdate)
+	}
+	if p.NeedsRefresh(resp, time.Now()) {
+		t.Error("NeedsRefresh: got true for a new response")
+	}
+}
+
+func TestValidityPolicyServeStale(t *testing.T) {
+	thisUpdate := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp := &Response{ThisUpdate: thisUpdate, NextUpdate: thisUpdate.Add(8 * time.Hour)}
+	p := ValidityPolicy{Validity: 8 * time.Hour, StaleGrace: time.Hour}
+
+	refreshAt := thisUpdate.Add(4 * time.Hour)
+	if got, want := p.ServeStaleUntil(resp), refreshAt.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("ServeStaleUntil: got %v, want %v", got, want)
+	}
+	if !p.CanServeStale(resp, refreshAt.Add(59*time.Minute)) {
+		t.Error("CanServeStale: got false within the grace period")
+	}
+	if p.CanServeStale(resp, refreshAt.Add(time.Hour)) {
+		t.Error("CanServeStale: got true after the grace period")
+	}
+
+	// The grace period never extends past NextUpdate.
+	p.StaleGrace = 24 * time.Hour
+	if got := p.ServeStaleUntil(resp); !got.Equal(resp.NextUpdate) {
+		t.Errorf("ServeStaleUntil: got %v, want NextUpdate %v", got, resp.NextUpdate)
+	}
+	if p.CanServeStale(resp, resp.NextUpdate) {
+		t.Error("CanServeStale: got true at NextUpdate")
+	}
+
+	// Without a grace period, responses are not served after the refresh
+	// time.
+	p.StaleGrace = 0
+	if p.CanServeStale(resp, refreshAt) {
+		t.Error("CanServeStale: got true without a grace period")
+	}
+}